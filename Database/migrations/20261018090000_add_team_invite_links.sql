@@ -0,0 +1,20 @@
+-- Create "team_invite_links" table
+CREATE TABLE "team_invite_links" (
+  "id" bigserial NOT NULL,
+  "team_id" bigint NOT NULL,
+  "creator_id" bigint NOT NULL,
+  "token" character varying(64) NOT NULL,
+  "role" character varying(20) NOT NULL DEFAULT 'member',
+  "max_uses" bigint NULL,
+  "uses" bigint NOT NULL DEFAULT 0,
+  "expires_at" timestamptz NULL,
+  "revoked_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_team_invite_links_creator" FOREIGN KEY ("creator_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "fk_team_invite_links_team" FOREIGN KEY ("team_id") REFERENCES "teams" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "idx_team_invite_links_team_id" to table: "team_invite_links"
+CREATE INDEX "idx_team_invite_links_team_id" ON "team_invite_links" ("team_id");
+-- Create index "idx_team_invite_links_token" to table: "team_invite_links"
+CREATE UNIQUE INDEX "idx_team_invite_links_token" ON "team_invite_links" ("token");
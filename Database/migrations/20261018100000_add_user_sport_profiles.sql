@@ -0,0 +1,16 @@
+-- Create "user_sport_profiles" table
+CREATE TABLE "user_sport_profiles" (
+  "user_id" bigint NOT NULL,
+  "sport_id" bigint NOT NULL,
+  "level" character varying(20) NULL,
+  "position" character varying(50) NULL,
+  "preferred_days" jsonb NULL DEFAULT '[]',
+  "preferred_times" jsonb NULL DEFAULT '[]',
+  "max_travel_distance_km" bigint NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("user_id", "sport_id"),
+  CONSTRAINT "fk_user_sport_profiles_sport" FOREIGN KEY ("sport_id") REFERENCES "sports" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "fk_users_sport_profiles" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "chk_user_sport_profiles_level" CHECK ((level)::text = ANY ((ARRAY['beginner'::character varying, 'intermediate'::character varying, 'advanced'::character varying, 'elite'::character varying])::text[]))
+);
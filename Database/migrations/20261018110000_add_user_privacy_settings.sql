@@ -0,0 +1,13 @@
+-- Add privacy columns to user_settings table
+ALTER TABLE "user_settings" ADD COLUMN "privacy_age" character varying(20) NOT NULL DEFAULT 'everyone';
+ALTER TABLE "user_settings" ADD COLUMN "privacy_city" character varying(20) NOT NULL DEFAULT 'everyone';
+ALTER TABLE "user_settings" ADD COLUMN "privacy_friends" character varying(20) NOT NULL DEFAULT 'everyone';
+ALTER TABLE "user_settings" ADD COLUMN "privacy_teams_and_challenges" character varying(20) NOT NULL DEFAULT 'everyone';
+ALTER TABLE "user_settings" ADD COLUMN "privacy_friend_requests" character varying(20) NOT NULL DEFAULT 'everyone';
+
+-- Add check constraints for privacy values
+ALTER TABLE "user_settings" ADD CONSTRAINT "chk_user_settings_privacy_age" CHECK ((privacy_age)::text = ANY ((ARRAY['everyone'::character varying, 'friends'::character varying, 'nobody'::character varying])::text[]));
+ALTER TABLE "user_settings" ADD CONSTRAINT "chk_user_settings_privacy_city" CHECK ((privacy_city)::text = ANY ((ARRAY['everyone'::character varying, 'friends'::character varying, 'nobody'::character varying])::text[]));
+ALTER TABLE "user_settings" ADD CONSTRAINT "chk_user_settings_privacy_friends" CHECK ((privacy_friends)::text = ANY ((ARRAY['everyone'::character varying, 'friends'::character varying, 'nobody'::character varying])::text[]));
+ALTER TABLE "user_settings" ADD CONSTRAINT "chk_user_settings_privacy_teams_and_challenges" CHECK ((privacy_teams_and_challenges)::text = ANY ((ARRAY['everyone'::character varying, 'friends'::character varying, 'nobody'::character varying])::text[]));
+ALTER TABLE "user_settings" ADD CONSTRAINT "chk_user_settings_privacy_friend_requests" CHECK ((privacy_friend_requests)::text = ANY ((ARRAY['everyone'::character varying, 'friends'::character varying, 'nobody'::character varying])::text[]));
@@ -0,0 +1,18 @@
+-- Create "data_exports" table
+CREATE TABLE "data_exports" (
+  "id" bigserial NOT NULL,
+  "user_id" bigint NOT NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "token" character varying(64) NULL,
+  "data" bytea NULL,
+  "expires_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "completed_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_data_exports_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "chk_data_exports_status" CHECK ((status)::text = ANY ((ARRAY['pending'::character varying, 'processing'::character varying, 'ready'::character varying, 'failed'::character varying])::text[]))
+);
+-- Create index "idx_data_exports_token" to table: "data_exports"
+CREATE UNIQUE INDEX "idx_data_exports_token" ON "data_exports" ("token");
+-- Create index "idx_data_exports_user_id" to table: "data_exports"
+CREATE INDEX "idx_data_exports_user_id" ON "data_exports" ("user_id");
@@ -0,0 +1,2 @@
+-- Add anonymized_at column to users table
+ALTER TABLE "users" ADD COLUMN "anonymized_at" timestamptz NULL;
@@ -0,0 +1,10 @@
+-- Add email verification fields to users table
+-- Existing accounts are treated as verified
+ALTER TABLE "users" ADD COLUMN "email_verified" boolean NOT NULL DEFAULT true;
+ALTER TABLE "users" ADD COLUMN "email_verification_code" text NULL;
+ALTER TABLE "users" ADD COLUMN "email_verification_code_expires_at" timestamptz NULL;
+ALTER TABLE "users" ADD COLUMN "email_verification_sent_at" timestamptz NULL;
+
+-- Create indexes for email verification fields
+CREATE INDEX IF NOT EXISTS "idx_users_email_verification_code" ON "users" ("email_verification_code");
+CREATE INDEX IF NOT EXISTS "idx_users_email_verification_code_expires_at" ON "users" ("email_verification_code_expires_at");
@@ -0,0 +1,7 @@
+-- Add email change fields to users table
+ALTER TABLE "users" ADD COLUMN "pending_email" text NULL;
+ALTER TABLE "users" ADD COLUMN "pending_email_code" text NULL;
+ALTER TABLE "users" ADD COLUMN "pending_email_code_expires_at" timestamptz NULL;
+
+-- Create index for email change code
+CREATE INDEX IF NOT EXISTS "idx_users_pending_email_code" ON "users" ("pending_email_code");
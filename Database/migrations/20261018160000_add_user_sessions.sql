@@ -0,0 +1,24 @@
+-- Create "user_sessions" table
+CREATE TABLE "user_sessions" (
+  "id" bigserial NOT NULL,
+  "user_id" bigint NOT NULL,
+  "refresh_token_hash" character varying(64) NOT NULL,
+  "previous_refresh_token_hash" character varying(64) NULL,
+  "device_name" text NULL,
+  "user_agent" text NULL,
+  "ip_address" text NULL,
+  "created_at" timestamptz NULL,
+  "last_used_at" timestamptz NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_user_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "idx_user_sessions_refresh_token_hash" to table: "user_sessions"
+CREATE UNIQUE INDEX "idx_user_sessions_refresh_token_hash" ON "user_sessions" ("refresh_token_hash");
+-- Create index "idx_user_sessions_previous_refresh_token_hash" to table: "user_sessions"
+CREATE INDEX "idx_user_sessions_previous_refresh_token_hash" ON "user_sessions" ("previous_refresh_token_hash");
+-- Create index "idx_user_sessions_expires_at" to table: "user_sessions"
+CREATE INDEX "idx_user_sessions_expires_at" ON "user_sessions" ("expires_at");
+-- Create index "idx_user_sessions_user_id" to table: "user_sessions"
+CREATE INDEX "idx_user_sessions_user_id" ON "user_sessions" ("user_id");
@@ -0,0 +1,10 @@
+-- Create "auth_throttles" table
+CREATE TABLE "auth_throttles" (
+  "key" character varying(255) NOT NULL,
+  "failures" bigint NOT NULL DEFAULT 0,
+  "last_failure_at" timestamptz NOT NULL,
+  "locked_until" timestamptz NULL,
+  PRIMARY KEY ("key")
+);
+-- Create index "idx_auth_throttles_last_failure_at" to table: "auth_throttles"
+CREATE INDEX "idx_auth_throttles_last_failure_at" ON "auth_throttles" ("last_failure_at");
@@ -0,0 +1,28 @@
+-- Add role and suspension fields to users table
+ALTER TABLE "users" ADD COLUMN "role" character varying(20) NOT NULL DEFAULT 'user';
+ALTER TABLE "users" ADD COLUMN "suspended_until" timestamptz NULL;
+ALTER TABLE "users" ADD COLUMN "suspension_reason" text NULL;
+-- Create index "idx_users_role" to table: "users"
+CREATE INDEX "idx_users_role" ON "users" ("role");
+-- Add soft delete to sports table
+ALTER TABLE "sports" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "idx_sports_deleted_at" to table: "sports"
+CREATE INDEX "idx_sports_deleted_at" ON "sports" ("deleted_at");
+-- Create "admin_audit_logs" table
+CREATE TABLE "admin_audit_logs" (
+  "id" bigserial NOT NULL,
+  "admin_id" bigint NOT NULL,
+  "action" character varying(50) NOT NULL,
+  "target_type" character varying(50) NOT NULL,
+  "target_id" bigint NOT NULL,
+  "details" jsonb NULL DEFAULT '{}',
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_admin_audit_logs_admin" FOREIGN KEY ("admin_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION
+);
+-- Create index "idx_admin_audit_logs_admin_id" to table: "admin_audit_logs"
+CREATE INDEX "idx_admin_audit_logs_admin_id" ON "admin_audit_logs" ("admin_id");
+-- Create index "idx_admin_audit_logs_action" to table: "admin_audit_logs"
+CREATE INDEX "idx_admin_audit_logs_action" ON "admin_audit_logs" ("action");
+-- Create index "idx_admin_audit_logs_created_at" to table: "admin_audit_logs"
+CREATE INDEX "idx_admin_audit_logs_created_at" ON "admin_audit_logs" ("created_at");
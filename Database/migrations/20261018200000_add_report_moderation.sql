@@ -0,0 +1,34 @@
+-- Add moderation fields to reports table
+ALTER TABLE "reports" ADD COLUMN "severity" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "reports" ADD COLUMN "target_snapshot" text NULL;
+ALTER TABLE "reports" ADD COLUMN "target_owner_id" bigint NULL;
+ALTER TABLE "reports" ADD COLUMN "resolved_by_id" bigint NULL, ADD CONSTRAINT "fk_reports_resolved_by" FOREIGN KEY ("resolved_by_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION;
+ALTER TABLE "reports" ADD COLUMN "resolution_note" text NULL;
+ALTER TABLE "reports" ADD COLUMN "resolved_at" timestamptz NULL;
+-- Backfill severity of existing reports from their reason
+UPDATE "reports" SET "severity" = CASE UPPER(REPLACE("reason", ' ', '_'))
+  WHEN 'CHILD_SAFETY' THEN 5
+  WHEN 'VIOLENCE' THEN 4
+  WHEN 'THREAT' THEN 4
+  WHEN 'SELF_HARM' THEN 4
+  WHEN 'HARASSMENT' THEN 3
+  WHEN 'HATE_SPEECH' THEN 3
+  WHEN 'RACISM' THEN 3
+  WHEN 'SEXUAL_CONTENT' THEN 3
+  WHEN 'SCAM' THEN 2
+  WHEN 'IMPERSONATION' THEN 2
+  WHEN 'INAPPROPRIATE_NAME' THEN 2
+  ELSE 1
+END;
+-- Dismiss duplicate pending reports, keeping the first one per reporter and target
+UPDATE "reports" AS r SET "status" = 'DISMISSED'
+FROM "reports" AS k
+WHERE r."status" = 'PENDING' AND k."status" = 'PENDING'
+  AND r."reporter_id" = k."reporter_id"
+  AND r."target_type" = k."target_type"
+  AND r."target_id" = k."target_id"
+  AND r."id" > k."id";
+-- Create index "idx_reports_pending_reporter_target" to table: "reports"
+CREATE UNIQUE INDEX "idx_reports_pending_reporter_target" ON "reports" ("reporter_id", "target_id", "target_type") WHERE (status = 'PENDING'::text);
+-- Create index "idx_reports_target" to table: "reports"
+CREATE INDEX "idx_reports_target" ON "reports" ("target_id", "target_type", "status");
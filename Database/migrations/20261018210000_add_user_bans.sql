@@ -0,0 +1,5 @@
+-- Add ban fields to users table
+ALTER TABLE "users" ADD COLUMN "banned_at" timestamptz NULL;
+ALTER TABLE "users" ADD COLUMN "ban_reason" text NULL;
+-- Create index "idx_users_banned_at" to table: "users"
+CREATE INDEX "idx_users_banned_at" ON "users" ("banned_at");
@@ -0,0 +1,9 @@
+-- Create "backplane_payloads" table
+CREATE TABLE "backplane_payloads" (
+  "id" bigserial NOT NULL,
+  "payload" bytea NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_backplane_payloads_created_at" to table: "backplane_payloads"
+CREATE INDEX "idx_backplane_payloads_created_at" ON "backplane_payloads" ("created_at");
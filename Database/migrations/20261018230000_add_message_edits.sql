@@ -0,0 +1,13 @@
+-- Modify "messages" table
+ALTER TABLE "messages" ADD COLUMN "edited_at" timestamptz NULL, ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create "message_edits" table
+CREATE TABLE "message_edits" (
+  "id" bigserial NOT NULL,
+  "message_id" bigint NOT NULL,
+  "content" text NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_messages_edits" FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "idx_message_edits_message_id" to table: "message_edits"
+CREATE INDEX "idx_message_edits_message_id" ON "message_edits" ("message_id");
@@ -0,0 +1,13 @@
+-- Create "message_reactions" table
+CREATE TABLE "message_reactions" (
+  "id" bigserial NOT NULL,
+  "message_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "emoji" character varying(32) NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_message_reactions_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "fk_messages_reactions" FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "idx_message_reactions_unique" to table: "message_reactions"
+CREATE UNIQUE INDEX "idx_message_reactions_unique" ON "message_reactions" ("message_id", "user_id", "emoji");
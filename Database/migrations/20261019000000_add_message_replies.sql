@@ -0,0 +1,4 @@
+-- Modify "messages" table
+ALTER TABLE "messages" ADD COLUMN "reply_to_message_id" bigint NULL, ADD CONSTRAINT "fk_messages_reply_to" FOREIGN KEY ("reply_to_message_id") REFERENCES "messages" ("id") ON UPDATE CASCADE ON DELETE SET NULL;
+-- Create index "idx_messages_reply_to_message_id" to table: "messages"
+CREATE INDEX "idx_messages_reply_to_message_id" ON "messages" ("reply_to_message_id");
@@ -0,0 +1,26 @@
+-- Create "message_attachments" table
+CREATE TABLE "message_attachments" (
+  "id" bigserial NOT NULL,
+  "conversation_id" bigint NOT NULL,
+  "message_id" bigint NULL,
+  "uploader_id" bigint NOT NULL,
+  "file_name" text NOT NULL,
+  "content_type" text NOT NULL,
+  "size" bigint NOT NULL,
+  "storage_key" text NOT NULL,
+  "thumbnail_key" text NULL,
+  "width" bigint NULL,
+  "height" bigint NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_message_attachments_uploader" FOREIGN KEY ("uploader_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "fk_messages_attachments" FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON UPDATE CASCADE ON DELETE SET NULL
+);
+-- Create index "idx_message_attachments_conversation_id" to table: "message_attachments"
+CREATE INDEX "idx_message_attachments_conversation_id" ON "message_attachments" ("conversation_id");
+-- Create index "idx_message_attachments_created_at" to table: "message_attachments"
+CREATE INDEX "idx_message_attachments_created_at" ON "message_attachments" ("created_at");
+-- Create index "idx_message_attachments_message_id" to table: "message_attachments"
+CREATE INDEX "idx_message_attachments_message_id" ON "message_attachments" ("message_id");
+-- Create index "idx_message_attachments_uploader_id" to table: "message_attachments"
+CREATE INDEX "idx_message_attachments_uploader_id" ON "message_attachments" ("uploader_id");
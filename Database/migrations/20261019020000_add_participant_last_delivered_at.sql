@@ -0,0 +1,2 @@
+-- Modify "conversation_participants" table
+ALTER TABLE "conversation_participants" ADD COLUMN "last_delivered_at" timestamptz NULL;
@@ -0,0 +1,26 @@
+-- Modify "user_settings" table
+ALTER TABLE "user_settings" ADD COLUMN "show_presence" boolean NULL DEFAULT true;
+-- Create "presence_connections" table
+CREATE TABLE "presence_connections" (
+  "id" bigserial NOT NULL,
+  "user_id" bigint NOT NULL,
+  "last_active_at" timestamptz NOT NULL,
+  "last_heartbeat_at" timestamptz NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_presence_connections_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "idx_presence_connections_user_id" to table: "presence_connections"
+CREATE INDEX "idx_presence_connections_user_id" ON "presence_connections" ("user_id");
+-- Create index "idx_presence_connections_last_heartbeat_at" to table: "presence_connections"
+CREATE INDEX "idx_presence_connections_last_heartbeat_at" ON "presence_connections" ("last_heartbeat_at");
+-- Create "user_presences" table
+CREATE TABLE "user_presences" (
+  "user_id" bigserial NOT NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'offline',
+  "last_seen_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("user_id"),
+  CONSTRAINT "fk_user_presences_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "chk_user_presences_status" CHECK ((status)::text = ANY ((ARRAY['online'::character varying, 'away'::character varying, 'offline'::character varying])::text[]))
+);
@@ -0,0 +1,7 @@
+-- Modify "conversation_participants" table
+ALTER TABLE "conversation_participants" ADD COLUMN "is_admin" boolean NOT NULL DEFAULT false;
+-- Modify "messages" table
+ALTER TABLE "messages" ADD COLUMN "system_event" character varying(30) NULL, ADD COLUMN "target_user_id" bigint NULL;
+-- The creators of existing groups aren't known, so every current participant can manage them
+UPDATE "conversation_participants" SET "is_admin" = true
+WHERE "left_at" IS NULL AND "conversation_id" IN (SELECT "id" FROM "conversations" WHERE "type" = 'group');
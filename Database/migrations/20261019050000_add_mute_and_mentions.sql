@@ -0,0 +1,15 @@
+-- Modify "conversation_participants" table
+ALTER TABLE "conversation_participants" ADD COLUMN "muted_at" timestamptz NULL, ADD COLUMN "muted_until" timestamptz NULL, ADD COLUMN "mentions_only" boolean NOT NULL DEFAULT false;
+-- Create "message_mentions" table
+CREATE TABLE "message_mentions" (
+  "id" bigserial NOT NULL,
+  "message_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_message_mentions_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "fk_messages_mentions" FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "idx_message_mentions_unique" to table: "message_mentions"
+CREATE UNIQUE INDEX "idx_message_mentions_unique" ON "message_mentions" ("message_id", "user_id");
+-- Create index "idx_message_mentions_user_id" to table: "message_mentions"
+CREATE INDEX "idx_message_mentions_user_id" ON "message_mentions" ("user_id");
@@ -0,0 +1,2 @@
+-- Modify "data_exports" table
+ALTER TABLE "data_exports" ADD COLUMN "started_at" timestamptz NULL;
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"server/api/controllers/helpers"
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"server/common/validator"
+)
+
+// --- GET ---
+func AdminGetUsers(w http.ResponseWriter, r *http.Request) {
+	filters := services.AdminUserFilters{
+		Query:  helpers.GetQueryParamOptional(r, "q"),
+		Role:   models.UserRole(helpers.GetQueryParamOptional(r, "role")),
+		Limit:  helpers.GetQueryInt(r, "limit", 50),
+		Offset: helpers.GetQueryInt(r, "offset", 0),
+	}
+
+	// "suspended" filter (e.g. ?suspended=true)
+	if suspendedStr := helpers.GetQueryParamOptional(r, "suspended"); suspendedStr != "" {
+		suspended := suspendedStr == "true"
+		filters.Suspended = &suspended
+	}
+
+	users, err := services.GetAdminUsers(filters)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.AdminUserResponseDto, len(users))
+	for i, u := range users {
+		response[i] = dto.ToAdminUserResponseDto(u)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, err := services.GetAdminUserByID(id)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToAdminUserResponseDto(*user))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func AdminGetReports(w http.ResponseWriter, r *http.Request) {
+	filters := services.AdminReportFilters{
+		Status:     helpers.GetQueryParamOptional(r, "status"),
+		TargetType: models.ReportTargetType(helpers.GetQueryParamOptional(r, "target_type")),
+		Limit:      helpers.GetQueryInt(r, "limit", 50),
+		Offset:     helpers.GetQueryInt(r, "offset", 0),
+	}
+
+	reports, err := services.GetAdminReports(filters)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.ReportResponseDto, len(reports))
+	for i, report := range reports {
+		response[i] = dto.ToReportResponseDto(report)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func AdminGetEulaVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := services.GetEulaVersions(helpers.GetQueryParamOptional(r, "locale"))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.EulaVersionResponseDto, len(versions))
+	for i, v := range versions {
+		response[i] = dto.ToEulaVersionResponseDto(v)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func AdminGetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filters := services.AdminAuditLogFilters{
+		Action: models.AdminAction(helpers.GetQueryParamOptional(r, "action")),
+		Limit:  helpers.GetQueryInt(r, "limit", 50),
+		Offset: helpers.GetQueryInt(r, "offset", 0),
+	}
+
+	if adminID := helpers.GetQueryUint(r, "admin_id", 0); adminID > 0 {
+		filters.AdminID = &adminID
+	}
+
+	logs, err := services.GetAdminAuditLogs(filters)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.AdminAuditLogResponseDto, len(logs))
+	for i, l := range logs {
+		response[i] = dto.ToAdminAuditLogResponseDto(l)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// --- POST ---
+func AdminCreateEulaVersion(w http.ResponseWriter, r *http.Request) {
+	admin, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.EulaVersionCreateDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	version, err := services.CreateEulaVersion(admin.ID, req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(dto.ToEulaVersionResponseDto(version))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func AdminActivateEulaVersion(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	admin, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	version, err := services.ActivateEulaVersion(admin.ID, id)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToEulaVersionResponseDto(version))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func AdminCreateSport(w http.ResponseWriter, r *http.Request) {
+	admin, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.SportCreateDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	sport, err := services.CreateSport(admin.ID, req.Name)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(dto.ToSportResponseDto(sport))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// --- PUT ---
+func AdminUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	admin, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.UpdateUserRoleDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, err := services.UpdateUserRole(admin.ID, id, models.UserRole(req.Role))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToAdminUserResponseDto(*user))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// --- DELETE ---
+func AdminDeleteSport(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	admin, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	if err := services.DeleteSport(admin.ID, id); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,10 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"server/api/controllers/helpers"
 	"server/common/appError"
 	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
 	"server/common/services"
 	"server/common/validator"
 )
@@ -25,23 +29,20 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := services.CreateUser(dto.UserCreateDtoToModel(req), req.Password)
+	user, err := services.RegisterUser(dto.UserCreateDtoToModel(req), req.Password)
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 
-	token, err := services.GenerateJWTToken(user)
+	tokens, err := services.CreateSession(user, sessionClient(r))
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	err = json.NewEncoder(w).Encode(map[string]any{
-		"user":  dto.ToUserResponseDto(*user),
-		"token": token,
-	})
+	err = json.NewEncoder(w).Encode(authResponse(*user, tokens))
 
 	if err != nil {
 		appError.HandleError(w, err)
@@ -64,16 +65,29 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := services.Login(req.Email, req.Password)
+	user, tokens, err := services.Login(req.Email, req.Password, sessionClient(r))
+
+	// 2FA enabled, the app completes the login at /auth/2fa/verify
+	var mfaErr *appError.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		err = json.NewEncoder(w).Encode(map[string]any{
+			"mfa_required":         true,
+			"mfa_token":            mfaErr.Token,
+			"mfa_token_expires_at": mfaErr.ExpiresAt,
+		})
+
+		if err != nil {
+			appError.HandleError(w, err)
+		}
+		return
+	}
+
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(map[string]any{
-		"user":  dto.ToUserResponseDto(*user),
-		"token": token,
-	})
+	err = json.NewEncoder(w).Encode(authResponse(*user, tokens))
 
 	if err != nil {
 		appError.HandleError(w, err)
@@ -96,7 +110,7 @@ func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.RequestPasswordReset(req.Email)
+	err = services.RequestPasswordReset(req.Email, helpers.GetClientIP(r))
 	if err != nil {
 		appError.HandleError(w, err)
 		return
@@ -129,7 +143,7 @@ func ResetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.ResetPassword(req.Email, req.ResetCode, req.NewPassword)
+	err = services.ResetPassword(req.Email, req.ResetCode, req.NewPassword, helpers.GetClientIP(r))
 	if err != nil {
 		appError.HandleError(w, err)
 		return
@@ -146,6 +160,68 @@ func ResetPassword(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.VerifyEmailDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = services.VerifyEmail(user.ID, req.Code)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(map[string]string{
+		"message": "Email has been verified successfully.",
+	})
+
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	err := services.RequestEmailVerification(user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(map[string]string{
+		"message": "A new verification code has been sent.",
+	})
+
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
 func GoogleAuth(w http.ResponseWriter, r *http.Request) {
 	var req dto.GoogleAuthDto
 
@@ -161,16 +237,13 @@ func GoogleAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := services.AuthenticateWithGoogle(req.IDToken)
+	user, tokens, err := services.AuthenticateWithGoogle(req.IDToken, sessionClient(r))
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(map[string]any{
-		"user":  dto.ToUserResponseDto(*user),
-		"token": token,
-	})
+	err = json.NewEncoder(w).Encode(authResponse(*user, tokens))
 
 	if err != nil {
 		appError.HandleError(w, err)
@@ -193,19 +266,147 @@ func AppleAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := services.AuthenticateWithApple(req.IDToken, req.Email, req.FirstName, req.LastName)
+	user, tokens, err := services.AuthenticateWithApple(req.IDToken, req.Email, req.FirstName, req.LastName, sessionClient(r))
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(map[string]any{
-		"user":  dto.ToUserResponseDto(*user),
-		"token": token,
-	})
+	err = json.NewEncoder(w).Encode(authResponse(*user, tokens))
 
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 }
+
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshTokenDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, tokens, err := services.RefreshSession(req.RefreshToken)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(authResponse(*user, tokens))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	sessionID, _ := r.Context().Value(middleware.SessionContextKey).(uint)
+
+	err := services.RevokeSession(user.ID, sessionID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	err := services.RevokeAllSessions(user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	sessionID, _ := r.Context().Value(middleware.SessionContextKey).(uint)
+
+	sessions, err := services.GetActiveSessions(user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.SessionResponseDto, len(sessions))
+	for i, s := range sessions {
+		response[i] = dto.ToSessionResponseDto(s, sessionID)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	err = services.RevokeSession(user.ID, id)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authResponse is the body returned whenever a session is started or refreshed
+func authResponse(user models.User, tokens services.AuthTokens) map[string]any {
+	return map[string]any{
+		"user":             dto.ToUserResponseDto(user),
+		"token":            tokens.AccessToken,
+		"token_expires_at": tokens.AccessTokenExpiresAt,
+		"refresh_token":    tokens.RefreshToken,
+	}
+}
+
+// sessionClient describes the device making the request.
+// Apps can name the device with the X-Device-Name header.
+func sessionClient(r *http.Request) services.SessionClient {
+	return services.SessionClient{
+		DeviceName: r.Header.Get("X-Device-Name"),
+		UserAgent:  r.UserAgent(),
+		IPAddress:  helpers.GetClientIP(r),
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"server/common/models"
 	"server/common/services"
 	"server/common/validator"
+	"strconv"
 )
 
 func GetChallenge(w http.ResponseWriter, r *http.Request) {
@@ -66,6 +67,45 @@ func GetChallenges(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// DiscoverChallenges returns challenges matching the user's sport profiles.
+// Optional query params lat and lon enable the max travel distance filter.
+func DiscoverChallenges(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var origin *models.Point
+	latStr := helpers.GetQueryParamOptional(r, "lat")
+	lonStr := helpers.GetQueryParamOptional(r, "lon")
+	if latStr != "" && lonStr != "" {
+		lat, latErr := strconv.ParseFloat(latStr, 64)
+		lon, lonErr := strconv.ParseFloat(lonStr, 64)
+		if latErr != nil || lonErr != nil {
+			appError.HandleError(w, appError.ErrBadRequest)
+			return
+		}
+		origin = &models.Point{Lat: lat, Lon: lon}
+	}
+
+	challengesModel, err := services.DiscoverChallenges(user.ID, origin)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.ChallengeResponseDto, len(challengesModel))
+	for i, c := range challengesModel {
+		response[i] = dto.ToChallengeResponseDto(c)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+	}
+}
+
 func CreateChallenge(w http.ResponseWriter, r *http.Request) {
 	// Get authenticated user from context
 	user, ok := r.Context().
@@ -207,6 +247,31 @@ func DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func RestoreChallenge(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	challenge, err := services.RestoreChallenge(id, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToChallengeResponseDto(challenge))
+	if err != nil {
+		appError.HandleError(w, err)
+	}
+}
+
 func ConfirmChallenge(w http.ResponseWriter, r *http.Request) {
 	id, err := helpers.GetParamId(r)
 	if err != nil {
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"strconv"
+)
+
+// --- GET ---
+func GetDataExport(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	export, err := services.GetLatestDataExport(user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToDataExportResponseDto(export))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// DownloadDataExport is public, the time-limited token is the credential.
+func DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	if token == "" {
+		appError.HandleError(w, appError.ErrBadRequest)
+		return
+	}
+
+	export, err := services.GetDataExportByToken(token)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("challenger-data-%s.zip", export.CreatedAt.Format("2006-01-02"))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(export.Data)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	_, _ = w.Write(export.Data)
+}
+
+// --- POST ---
+func RequestDataExport(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	export, err := services.RequestDataExport(user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(dto.ToDataExportResponseDto(export))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
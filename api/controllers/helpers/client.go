@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"server/common/config"
+)
+
+// Returns the IP address of the client.
+// X-Forwarded-For can be set by anyone, so it is only read when the request comes from a trusted proxy.
+// Each proxy appends the address it received the request from, the rightmost one that isn't a trusted proxy is the client.
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(host) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			break
+		}
+
+		host = hop
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+
+	return host
+}
+
+func isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, proxy := range config.AppConfig.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+
+		if trusted, err := netip.ParseAddr(proxy); err == nil && trusted.Unmap() == addr {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"server/common/validator"
+)
+
+// --- POST ---
+func VerifyMFALogin(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFAVerifyDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, tokens, err := services.VerifyMFALogin(req.MFAToken, req.Code, sessionClient(r))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(authResponse(*user, tokens))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	sessionID, _ := r.Context().Value(middleware.SessionContextKey).(uint)
+
+	var req dto.MFAEnrollDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	enrollment, err := services.EnrollMFA(user.ID, sessionID, req.Password)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.MFAEnrollmentResponseDto{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	})
+
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.MFACodeDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	codes, err := services.ConfirmMFA(user.ID, req.Code)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.RecoveryCodesResponseDto{RecoveryCodes: codes})
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.MFACodeDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	codes, err := services.RegenerateRecoveryCodes(user.ID, req.Code)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.RecoveryCodesResponseDto{RecoveryCodes: codes})
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func DisableMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.MFACodeDto
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	// Validate
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = services.DisableMFA(user.ID, req.Code)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"server/api/controllers/helpers"
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"server/common/validator"
+)
+
+// --- GET ---
+func GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	filters := services.ModerationQueueFilters{
+		TargetType: models.ReportTargetType(strings.ToUpper(helpers.GetQueryParamOptional(r, "target_type"))),
+		Limit:      helpers.GetQueryInt(r, "limit", 50),
+		Offset:     helpers.GetQueryInt(r, "offset", 0),
+	}
+
+	groups, err := services.GetModerationQueue(filters)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.ReportGroupResponseDto, len(groups))
+	for i, g := range groups {
+		response[i] = dto.ToReportGroupResponseDto(g)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func GetReportsForTarget(w http.ResponseWriter, r *http.Request) {
+	targetType, targetID, err := reportTarget(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	reports, err := services.GetReportsForTarget(targetType, targetID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.ReportResponseDto, len(reports))
+	for i, report := range reports {
+		response[i] = dto.ToReportResponseDto(report)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// --- POST ---
+func ResolveReports(w http.ResponseWriter, r *http.Request) {
+	resolveReports(w, r, models.ReportStatusResolved)
+}
+
+func DismissReports(w http.ResponseWriter, r *http.Request) {
+	resolveReports(w, r, models.ReportStatusDismissed)
+}
+
+func SuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	moderator, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.SuspendUserDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, err := services.SuspendUser(moderator.ID, id, req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToAdminUserResponseDto(*user))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func BanUser(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	moderator, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	var req dto.BanUserDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	user, err := services.BanUser(moderator.ID, id, req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToAdminUserResponseDto(*user))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// --- DELETE ---
+func UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	moderator, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	user, err := services.UnsuspendUser(moderator.ID, id)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToAdminUserResponseDto(*user))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func UnbanUser(w http.ResponseWriter, r *http.Request) {
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	moderator, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	user, err := services.UnbanUser(moderator.ID, id)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToAdminUserResponseDto(*user))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+// Package private methods
+func resolveReports(w http.ResponseWriter, r *http.Request, status string) {
+	targetType, targetID, err := reportTarget(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	moderator, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	// The note is optional, so an empty body is fine
+	var req dto.ResolveReportsDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = services.ResolveReports(moderator.ID, targetType, targetID, status, req.Note)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reportTarget reads the {type} and {id} path parameters of a report target
+func reportTarget(r *http.Request) (models.ReportTargetType, uint, error) {
+	targetType := models.ReportTargetType(strings.ToUpper(r.PathValue("type")))
+
+	switch targetType {
+	case models.ReportTargetUser, models.ReportTargetTeam, models.ReportTargetChallenge, models.ReportTargetMessage:
+	default:
+		return "", 0, appError.ErrBadRequest
+	}
+
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return targetType, id, nil
+}
@@ -126,6 +126,37 @@ func GetCurrentUserTeams(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func GetTeamInviteLinks(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	teamId, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	links, err := services.GetTeamInviteLinks(teamId, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.TeamInviteLinkResponseDto, len(links))
+	for i, l := range links {
+		response[i] = dto.ToTeamInviteLinkResponseDto(l)
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
 // --- POST ---
 func CreateTeam(w http.ResponseWriter, r *http.Request) {
 	// Get authenticated user from context
@@ -179,6 +210,100 @@ func CreateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func CreateTeamInviteLink(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	teamId, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	req := dto.TeamInviteLinkCreateDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	link := dto.TeamInviteLinkCreateDtoToModel(req)
+	link.TeamID = teamId
+	link.CreatorID = user.ID
+
+	created, err := services.CreateTeamInviteLink(link)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dto.ToTeamInviteLinkResponseDto(created)); err != nil {
+		appError.HandleError(w, err)
+	}
+}
+
+func RedeemTeamInviteLink(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	token := r.PathValue("token")
+	if token == "" {
+		appError.HandleError(w, appError.ErrBadRequest)
+		return
+	}
+
+	team, err := services.RedeemTeamInviteLink(token, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToTeamResponseDto(team))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
+func RestoreTeam(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	id, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	team, err := services.RestoreTeam(id, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToTeamResponseDto(team))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+}
+
 // Depricated: Use Invitation system instead
 /*
 func AddUserToTeam(w http.ResponseWriter, r *http.Request) {
@@ -292,6 +417,34 @@ func RemoveUserFromTeam(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func RevokeTeamInviteLink(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	teamId, err := helpers.GetParamId(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	linkId, err := helpers.GetParamIdDynamic(r, "linkId")
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = services.RevokeTeamInviteLink(teamId, linkId, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func LeaveTeam(w http.ResponseWriter, r *http.Request) {
 	// Get authenticated user from context
 	user, ok := r.Context().
@@ -57,10 +57,15 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert to DTOs
+	friendIDs := make(map[uint]bool)
+	for _, id := range services.GetFriendIDs(authUser.ID) {
+		friendIDs[id] = true
+	}
+
+	// Convert to DTOs, respecting each user's privacy settings
 	out := make([]dto.UserResponseDto, len(users))
 	for i, u := range users {
-		out[i] = dto.ToUserResponseDto(u)
+		out[i] = dto.ToUserResponseDtoForViewer(u, dto.ProfileViewer{IsFriend: friendIDs[u.ID]})
 	}
 
 	// Encode next cursor (if any)
@@ -131,7 +136,12 @@ func GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(dto.ToPublicUserDtoResponse(*targetUser))
+	viewer := dto.ProfileViewer{
+		IsSelf:   user.ID == targetUser.ID,
+		IsFriend: services.AreFriends(user.ID, targetUser.ID),
+	}
+
+	err = json.NewEncoder(w).Encode(dto.ToPublicUserDtoResponseForViewer(*targetUser, viewer))
 	if err != nil {
 		appError.HandleError(w, err)
 		return
@@ -203,7 +213,7 @@ func GetFriends(w http.ResponseWriter, r *http.Request) {
 
 	response := make([]dto.UserResponseDto, len(friends))
 	for i, friend := range friends {
-		response[i] = dto.ToUserResponseDto(friend)
+		response[i] = dto.ToUserResponseDtoForViewer(friend, dto.ProfileViewer{IsFriend: true})
 	}
 
 	err = json.NewEncoder(w).Encode(response)
@@ -310,6 +320,12 @@ func UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = validator.V.Struct(req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
 	err = services.UpdateUserSettings(user.ID, req)
 	if err != nil {
 		appError.HandleError(w, err)
@@ -317,6 +333,64 @@ func UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().
+		Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	req := dto.ChangeEmailDto{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = services.RequestEmailChange(user.ID, req.NewEmail, req.Password)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().
+		Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	req := dto.ConfirmEmailChangeDto{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	err = services.ConfirmEmailChange(user.ID, req.Code)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	user, ok := r.Context().
 		Value(middleware.UserContextKey).(*models.User)
@@ -72,6 +72,71 @@ func addTasks(c *cron.Cron) {
 		os.Exit(1)
 	}
 
+	// Run every day to hard delete teams and challenges past the soft delete retention period
+	_, err = c.AddFunc("@daily", tasks.RunPurgeSoftDeletedTeamsAndChallenges)
+	if err != nil {
+		slog.Error("Error scheduling RunPurgeSoftDeletedTeamsAndChallenges", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every day to anonymize users past the account deletion grace period
+	_, err = c.AddFunc("@daily", tasks.RunAnonymizeDeletedUsers)
+	if err != nil {
+		slog.Error("Error scheduling RunAnonymizeDeletedUsers", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every day to delete data exports past their download window
+	_, err = c.AddFunc("@daily", tasks.RunCleanupExpiredDataExports)
+	if err != nil {
+		slog.Error("Error scheduling RunCleanupExpiredDataExports", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every day to delete sessions past their refresh token expiry
+	_, err = c.AddFunc("@daily", tasks.RunCleanupExpiredSessions)
+	if err != nil {
+		slog.Error("Error scheduling RunCleanupExpiredSessions", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every hour to delete unfinished 2FA logins
+	_, err = c.AddFunc("@hourly", tasks.RunCleanupExpiredMFAChallenges)
+	if err != nil {
+		slog.Error("Error scheduling RunCleanupExpiredMFAChallenges", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every hour to delete stale login and password reset throttles
+	_, err = c.AddFunc("@hourly", tasks.RunCleanupAuthThrottles)
+	if err != nil {
+		slog.Error("Error scheduling RunCleanupAuthThrottles", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every hour to delete large realtime events that have been delivered
+	_, err = c.AddFunc("@hourly", tasks.RunCleanupBackplanePayloads)
+	if err != nil {
+		slog.Error("Error scheduling RunCleanupBackplanePayloads", "error", err)
+		os.Exit(1)
+	}
+
+	// Run every hour to delete chat uploads that were never sent
+	_, err = c.AddFunc("@hourly", tasks.RunCleanupOrphanedAttachments)
+	if err != nil {
+		slog.Error("Error scheduling RunCleanupOrphanedAttachments", "error", err)
+		os.Exit(1)
+	}
+
+	// ------- DATA EXPORT TASKS ------- \\
+
+	// Build pending GDPR data exports
+	_, err = c.AddFunc("@every 1m", tasks.RunProcessDataExports)
+	if err != nil {
+		slog.Error("Error scheduling RunProcessDataExports", "error", err)
+		os.Exit(1)
+	}
+
 	// ------- NOTIFI USER TASKS ------- \\
 
 	// Notify users 24 hours before challenge start
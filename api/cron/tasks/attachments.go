@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"log/slog"
+	"server/common/services"
+)
+
+// ------- RUNNERS ------- \\
+
+func RunCleanupOrphanedAttachments() {
+	slog.Info("⏰ Cron: Starting cleanup of orphaned attachments...")
+
+	err := cleanupOrphanedAttachments()
+	if err != nil {
+		slog.Error("❌ Cron: Error cleaning up orphaned attachments", "error", err)
+	} else {
+		slog.Info("✅ Cron: Cleanup of orphaned attachments completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Deletes chat uploads that were never sent, and attachments of hard deleted messages.
+func cleanupOrphanedAttachments() error {
+	count, err := services.DeleteOrphanedAttachments(NowFunc().Add(-services.AttachmentUploadTTL))
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		slog.Info("✅ Cron: Deleted orphaned attachments", "count", count)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"log/slog"
+	"server/common/config"
+	"server/common/models"
+	"time"
+)
+
+// ------- RUNNERS ------- \\
+
+func RunCleanupAuthThrottles() {
+	slog.Info("⏰ Cron: Starting cleanup of auth throttles...")
+
+	err := cleanupAuthThrottles()
+	if err != nil {
+		slog.Error("❌ Cron: Error cleaning up auth throttles", "error", err)
+	} else {
+		slog.Info("✅ Cron: Cleanup of auth throttles completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Deletes throttles without recent failures that are no longer locked.
+// Failures older than a day are ignored by the throttle anyway.
+func cleanupAuthThrottles() error {
+	now := NowFunc()
+
+	result := config.DB.
+		Where("last_failure_at < ?", now.Add(-24*time.Hour)).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Delete(&models.AuthThrottle{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		slog.Info("✅ Cron: Deleted auth throttles", "count", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"log/slog"
+	"server/common/config"
+	"server/common/models"
+	"time"
+)
+
+// ------- RUNNERS ------- \\
+
+func RunCleanupBackplanePayloads() {
+	slog.Info("⏰ Cron: Starting cleanup of backplane payloads...")
+
+	err := cleanupBackplanePayloads()
+	if err != nil {
+		slog.Error("❌ Cron: Error cleaning up backplane payloads", "error", err)
+	} else {
+		slog.Info("✅ Cron: Cleanup of backplane payloads completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Deletes large realtime events that every chat instance has had time to load.
+func cleanupBackplanePayloads() error {
+	result := config.DB.
+		Where("created_at < ?", NowFunc().Add(-10*time.Minute)).
+		Delete(&models.BackplanePayload{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		slog.Info("✅ Cron: Deleted backplane payloads", "count", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package tasks
+
+import (
+	"errors"
+	"log/slog"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"time"
+)
+
+// Exports processing for longer than this were interrupted, e.g. by a restart
+const dataExportProcessingTimeout = 30 * time.Minute
+
+// ------- RUNNERS ------- \\
+
+func RunProcessDataExports() {
+	slog.Info("⏰ Cron: Starting processing of pending data exports...")
+
+	err := processDataExports()
+	if err != nil {
+		slog.Error("❌ Cron: Error processing data exports", "error", err)
+	} else {
+		slog.Info("✅ Cron: Processing of data exports completed successfully")
+	}
+}
+
+func RunCleanupExpiredDataExports() {
+	slog.Info("⏰ Cron: Starting cleanup of expired data exports...")
+
+	err := cleanupExpiredDataExports()
+	if err != nil {
+		slog.Error("❌ Cron: Error cleaning up expired data exports", "error", err)
+	} else {
+		slog.Info("✅ Cron: Cleanup of expired data exports completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Builds the ZIP for every pending export, oldest first.
+// Interrupted exports are marked failed first, otherwise they would block new requests forever.
+func processDataExports() error {
+	result := config.DB.
+		Model(&models.DataExport{}).
+		Where("status = ? AND (started_at IS NULL OR started_at < ?)",
+			models.DataExportProcessing, NowFunc().Add(-dataExportProcessingTimeout)).
+		Update("status", models.DataExportFailed)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		slog.Warn("Cron: Marked interrupted data exports as failed", "count", result.RowsAffected)
+	}
+
+	var exportIDs []uint
+	if err := config.DB.
+		Model(&models.DataExport{}).
+		Where("status = ?", models.DataExportPending).
+		Order("created_at ASC").
+		Pluck("id", &exportIDs).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range exportIDs {
+		if err := services.ProcessDataExport(id); err != nil {
+			slog.Warn("Failed to process data export", "export_id", id, "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Deletes exports whose download window has passed, so the archives don't pile up.
+func cleanupExpiredDataExports() error {
+	result := config.DB.
+		Where("expires_at IS NOT NULL AND expires_at < ?", NowFunc()).
+		Delete(&models.DataExport{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		slog.Info("✅ Cron: Deleted expired data exports", "count", result.RowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"errors"
+	"log/slog"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"time"
+)
+
+// ------- RUNNERS ------- \\
+
+func RunPurgeSoftDeletedTeamsAndChallenges() {
+	slog.Info("⏰ Cron: Starting purge of soft deleted teams and challenges...")
+
+	retentionDays := config.AppConfig.SoftDeleteRetentionDays
+
+	err := purgeSoftDeletedTeamsAndChallenges(retentionDays)
+	if err != nil {
+		slog.Error("❌ Cron: Error purging soft deleted teams and challenges", "error", err)
+	} else {
+		slog.Info("✅ Cron: Purge of soft deleted teams and challenges completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Each team and challenge is purged in its own transaction,
+// so a single failing row doesn't block the rest.
+func purgeSoftDeletedTeamsAndChallenges(retentionDays int) error {
+	cutoff := NowFunc().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+
+	var errs []error
+	purgedTeams, purgedChallenges := 0, 0
+
+	var teamIDs []uint
+	if err := config.DB.Unscoped().
+		Model(&models.Team{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &teamIDs).Error; err != nil {
+		return err
+	}
+
+	for _, id := range teamIDs {
+		if err := services.DeleteTeam(id); err != nil {
+			slog.Warn("Failed to purge team", "team_id", id, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		purgedTeams++
+	}
+
+	var challengeIDs []uint
+	if err := config.DB.Unscoped().
+		Model(&models.Challenge{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &challengeIDs).Error; err != nil {
+		return err
+	}
+
+	for _, id := range challengeIDs {
+		if err := services.HardDeleteChallenge(id); err != nil {
+			slog.Warn("Failed to purge challenge", "challenge_id", id, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		purgedChallenges++
+	}
+
+	if purgedTeams > 0 || purgedChallenges > 0 {
+		slog.Info("✅ Cron: Purged soft deleted rows",
+			"teams", purgedTeams,
+			"challenges", purgedChallenges,
+		)
+	}
+
+	return errors.Join(errs...)
+}
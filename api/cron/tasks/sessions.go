@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"log/slog"
+	"server/common/config"
+	"server/common/models"
+)
+
+// ------- RUNNERS ------- \\
+
+func RunCleanupExpiredSessions() {
+	slog.Info("⏰ Cron: Starting cleanup of expired sessions...")
+
+	err := cleanupExpiredSessions()
+	if err != nil {
+		slog.Error("❌ Cron: Error cleaning up expired sessions", "error", err)
+	} else {
+		slog.Info("✅ Cron: Cleanup of expired sessions completed successfully")
+	}
+}
+
+func RunCleanupExpiredMFAChallenges() {
+	slog.Info("⏰ Cron: Starting cleanup of expired 2FA challenges...")
+
+	err := cleanupExpiredMFAChallenges()
+	if err != nil {
+		slog.Error("❌ Cron: Error cleaning up expired 2FA challenges", "error", err)
+	} else {
+		slog.Info("✅ Cron: Cleanup of expired 2FA challenges completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Deletes sessions whose refresh token has expired.
+// Revoked sessions are kept until then, so reuse of their rotated tokens is still detected.
+func cleanupExpiredSessions() error {
+	result := config.DB.
+		Where("expires_at < ?", NowFunc()).
+		Delete(&models.UserSession{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		slog.Info("✅ Cron: Deleted expired sessions", "count", result.RowsAffected)
+	}
+
+	return nil
+}
+
+// Deletes 2FA login challenges that were never completed.
+func cleanupExpiredMFAChallenges() error {
+	result := config.DB.
+		Where("expires_at < ?", NowFunc()).
+		Delete(&models.MFAChallenge{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		slog.Info("✅ Cron: Deleted expired 2FA challenges", "count", result.RowsAffected)
+	}
+
+	return nil
+}
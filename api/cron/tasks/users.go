@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"errors"
+	"log/slog"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"time"
+)
+
+// ------- RUNNERS ------- \\
+
+func RunAnonymizeDeletedUsers() {
+	slog.Info("⏰ Cron: Starting anonymization of deleted users...")
+
+	graceDays := config.AppConfig.AccountDeletionGraceDays
+
+	err := anonymizeDeletedUsers(graceDays)
+	if err != nil {
+		slog.Error("❌ Cron: Error anonymizing deleted users", "error", err)
+	} else {
+		slog.Info("✅ Cron: Anonymization of deleted users completed successfully")
+	}
+}
+
+// ------- TASKS ------- \\
+
+// Each user is anonymized in its own transaction,
+// so a single failing row doesn't block the rest.
+func anonymizeDeletedUsers(graceDays int) error {
+	cutoff := NowFunc().Add(-time.Duration(graceDays) * 24 * time.Hour)
+
+	var userIDs []uint
+	if err := config.DB.Unscoped().
+		Model(&models.User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", cutoff).
+		Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	anonymized := 0
+
+	for _, id := range userIDs {
+		if err := services.AnonymizeUser(id); err != nil {
+			slog.Warn("Failed to anonymize user", "user_id", id, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		anonymized++
+	}
+
+	if anonymized > 0 {
+		slog.Info("✅ Cron: Anonymized deleted users", "users", anonymized)
+	}
+
+	return errors.Join(errs...)
+}
@@ -6,8 +6,12 @@ import (
 	"os"
 	"time"
 
+	"server/common/backplane"
 	"server/common/config"
+	"server/common/jwtkeys"
 	"server/common/logger" // Import the logger package
+	"server/common/services"
+	"server/common/storage"
 
 	"server/api/cron"
 	"server/api/routes"
@@ -21,6 +25,18 @@ func main() {
 	slog.Info("🚀 Initializing Challenger Backend...")
 
 	config.LoadConfig()
+
+	// The API issues tokens, so the keyset needs an active key it can sign with
+	keys, err := services.JWTKeySet()
+	if err != nil {
+		slog.Error("Failed to load JWT keys", "error", err)
+		os.Exit(1)
+	}
+	if !keys.CanSign() {
+		slog.Error("JWT keys can't sign tokens, set JWT_ACTIVE_KEY_ID to a key with its private part", "error", jwtkeys.ErrNoSigningKey)
+		os.Exit(1)
+	}
+
 	config.ConnectDatabase()
 
 	// Run Atlas migrations
@@ -44,6 +60,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Realtime events are delivered to the app by the chat service, the API only publishes
+	services.SetBackplane(backplane.NewPostgres(config.DatabaseDSN(), config.DB))
+
+	// The cron job removes the files of chat uploads that were never sent
+	attachments, err := storage.NewLocal(config.AppConfig.AttachmentStorageDir)
+	if err != nil {
+		slog.Error("Failed to open attachment storage", "error", err)
+		os.Exit(1)
+	}
+	services.SetAttachmentStorage(attachments)
+
 	cron.Start()
 
 	r := chi.NewRouter()
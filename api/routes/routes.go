@@ -24,10 +24,35 @@ func RegisterRoutes(r chi.Router) {
 			r.Post("/request", controllers.RequestPasswordReset)
 			r.Post("/reset", controllers.ResetPassword)
 		})
+		r.Post("/refresh", controllers.RefreshToken)
+		r.Post("/2fa/verify", controllers.VerifyMFALogin)
+		r.Route("/verify-email", func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware)
+			r.Post("/", controllers.VerifyEmail)
+			r.Post("/resend", controllers.ResendEmailVerification)
+		})
+
+		// Sessions
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware)
+			r.Post("/logout", controllers.Logout)
+			r.Post("/logout-all", controllers.LogoutAllDevices)
+			r.Get("/sessions", controllers.GetSessions)
+			r.Delete("/sessions/{id}", controllers.RevokeSession)
+
+			// Two-factor authentication
+			r.Post("/2fa/enroll", controllers.EnrollMFA)
+			r.Post("/2fa/confirm", controllers.ConfirmMFA)
+			r.Post("/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+			r.Post("/2fa/disable", controllers.DisableMFA)
+		})
 	})
 
 	r.Get("/sports", controllers.GetSports)
 
+	// GDPR data export download (public - the time-limited token is the credential)
+	r.Get("/exports/{token}", controllers.DownloadDataExport)
+
 	r.Route("/facilities", func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware)
 		r.Get("/", controllers.GetFacilities)
@@ -44,6 +69,8 @@ func RegisterRoutes(r chi.Router) {
 		// Current user
 		r.Get("/me", controllers.GetCurrentUser)
 		r.Get("/settings", controllers.GetCurrentUserSettings)
+		r.Get("/me/export", controllers.GetDataExport)
+		r.Post("/me/export", controllers.RequestDataExport)
 
 		// Friends
 		r.Get("/friends", controllers.GetFriends)
@@ -62,6 +89,8 @@ func RegisterRoutes(r chi.Router) {
 		// Updates / deletions
 		r.Put("/", controllers.UpdateUser)
 		r.Put("/settings", controllers.UpdateUserSettings)
+		r.Post("/me/email", controllers.ChangeEmail)
+		r.Post("/me/email/confirm", controllers.ConfirmEmailChange)
 		r.Post("/push-token", controllers.RegisterPushToken)
 		r.Delete("/{id}/remove", controllers.RemoveFriend)
 		r.Delete("/me", controllers.DeleteUser)
@@ -80,6 +109,7 @@ func RegisterRoutes(r chi.Router) {
 			r.Use(middleware.AuthMiddleware)
 			r.Use(middleware.EulaMiddleware)
 			r.Get("/", controllers.GetChallenges)
+			r.Get("/discover", controllers.DiscoverChallenges)
 			r.Get("/{id}", controllers.GetChallenge)
 			r.Post("/", controllers.CreateChallenge)
 			r.Put("/{id}", controllers.UpdateChallenge)
@@ -87,6 +117,7 @@ func RegisterRoutes(r chi.Router) {
 			r.Post("/{id}/leave", controllers.LeaveChallenge)
 			r.Delete("/{id}", controllers.DeleteChallenge)
 			r.Post("/{id}/confirm", controllers.ConfirmChallenge)
+			r.Post("/{id}/restore", controllers.RestoreChallenge)
 		})
 	})
 
@@ -97,8 +128,12 @@ func RegisterRoutes(r chi.Router) {
 		r.Get("/", controllers.GetTeams)
 		r.Get("/user/{id}", controllers.GetTeamsByUserId)
 		r.Get("/me", controllers.GetCurrentUserTeams)
+		r.Get("/{id}/invite-links", controllers.GetTeamInviteLinks)
 
 		r.Post("/", controllers.CreateTeam)
+		r.Post("/{id}/invite-links", controllers.CreateTeamInviteLink)
+		r.Post("/invite-links/{token}/redeem", controllers.RedeemTeamInviteLink)
+		r.Post("/{id}/restore", controllers.RestoreTeam)
 		//r.Post("/{id}/user", controllers.AddUserToTeam)
 
 		r.Put("/{id}", controllers.UpdateTeam)
@@ -106,6 +141,7 @@ func RegisterRoutes(r chi.Router) {
 		r.Delete("/{id}", controllers.SoftDeleteTeam)
 		r.Delete("/{id}/user/{rmvUserId}", controllers.RemoveUserFromTeam)
 		r.Delete("/{id}/leave", controllers.LeaveTeam)
+		r.Delete("/{id}/invite-links/{linkId}", controllers.RevokeTeamInviteLink)
 	})
 
 	r.Route("/invitations", func(r chi.Router) {
@@ -144,6 +180,43 @@ func RegisterRoutes(r chi.Router) {
 		})
 	})
 
+	// Moderation queue (moderators and admins)
+	r.Route("/moderation", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.ModeratorMiddleware)
+
+		r.Get("/reports", controllers.GetModerationQueue)
+		r.Get("/reports/{type}/{id}", controllers.GetReportsForTarget)
+		r.Post("/reports/{type}/{id}/resolve", controllers.ResolveReports)
+		r.Post("/reports/{type}/{id}/dismiss", controllers.DismissReports)
+
+		r.Post("/users/{id}/suspension", controllers.SuspendUser)
+		r.Delete("/users/{id}/suspension", controllers.UnsuspendUser)
+		r.Post("/users/{id}/ban", controllers.BanUser)
+		r.Delete("/users/{id}/ban", controllers.UnbanUser)
+	})
+
+	// Platform administration
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.AdminMiddleware)
+
+		r.Get("/users", controllers.AdminGetUsers)
+		r.Get("/users/{id}", controllers.AdminGetUser)
+		r.Put("/users/{id}/role", controllers.AdminUpdateUserRole)
+
+		r.Get("/reports", controllers.AdminGetReports)
+
+		r.Get("/eula", controllers.AdminGetEulaVersions)
+		r.Post("/eula", controllers.AdminCreateEulaVersion)
+		r.Post("/eula/{id}/activate", controllers.AdminActivateEulaVersion)
+
+		r.Post("/sports", controllers.AdminCreateSport)
+		r.Delete("/sports/{id}", controllers.AdminDeleteSport)
+
+		r.Get("/audit-logs", controllers.AdminGetAuditLogs)
+	})
+
 	r.Route("/weather", func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware)
 		r.Use(middleware.EulaMiddleware)
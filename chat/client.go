@@ -6,6 +6,9 @@ import (
 	"server/common/config"
 	"server/common/dto"
 	"server/common/services"
+	"server/common/validator"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -16,6 +19,12 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 512
+
+	// Missed messages are replayed in batches of this size
+	replayBatchSize = 100
+
+	// Deliveries are acknowledged at most this often, so a burst of messages costs one update per conversation
+	deliveryAckInterval = 2 * time.Second
 )
 
 var upgrader = websocket.Upgrader{
@@ -28,14 +37,46 @@ type Client struct {
 	conn           *websocket.Conn
 	send           chan []byte
 	userID         uint
+	sessionID      uint
 	teamIDs        map[uint]bool
 	blockedUserIDs map[uint]bool
+
+	// Set by the hub before it closes send, to tell the client why it was disconnected
+	closeMessage []byte
+
+	// Row of this connection in the presence table, 0 when it couldn't be recorded
+	presenceID uint
+
+	// Set whenever the app sends something, the hub's presence heartbeat resets it
+	active atomic.Bool
+
+	// Replay requests from the handshake or a "resume" event, handled by writePump
+	resume chan resumePoint
+
+	// Highest message ID that was replayed, live events for older messages are skipped.
+	// Only used by writePump.
+	replayedUpTo uint
+
+	// Messages written by writePump, acknowledged by acknowledgePump outside the write loop
+	acks deliveryAcks
+}
+
+// resumePoint is where the app lost track of its conversations.
+type resumePoint struct {
+	messageID uint
+	since     *time.Time
 }
 
 func (c *Client) readPump() {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
+
+		if c.presenceID != 0 {
+			if err := services.DisconnectPresence(c.presenceID, c.userID); err != nil {
+				log.Printf("Error removing presence connection: %v", err)
+			}
+		}
 	}()
 
 	c.conn.SetReadLimit(maxMessageSize)
@@ -62,6 +103,8 @@ func (c *Client) readPump() {
 			break
 		}
 
+		c.active.Store(true)
+
 		var req dto.IncomingMessage
 		if err := json.Unmarshal(messageBytes, &req); err != nil {
 			log.Printf("Invalid JSON: %v", err)
@@ -73,6 +116,65 @@ func (c *Client) readPump() {
 			req.Type = "message"
 		}
 
+		// ✅ Keeps the user online while the app is open, the activity was recorded above
+		if req.Type == "heartbeat" {
+			continue
+		}
+
+		// ✅ Replay missed messages, e.g. after the app was in the background
+		if req.Type == "resume" {
+			point := resumePoint{since: req.Since}
+			if req.SinceMessageID != nil {
+				point.messageID = *req.SinceMessageID
+			}
+
+			// A replay that is already pending covers this one
+			select {
+			case c.resume <- point:
+			default:
+			}
+			continue
+		}
+
+		// ✅ Edit or delete the user's own message, participants get the change through the backplane
+		if req.Type == "edit_message" || req.Type == "delete_message" {
+			if req.ConversationID == nil || req.MessageID == nil {
+				continue
+			}
+
+			var err error
+			if req.Type == "edit_message" {
+				edit := dto.EditMessageDto{Content: req.Content}
+				if err = validator.V.Struct(edit); err == nil {
+					_, err = services.EditMessage(*req.ConversationID, *req.MessageID, c.userID, edit.Content)
+				}
+			} else {
+				err = services.DeleteMessage(*req.ConversationID, *req.MessageID, c.userID)
+			}
+
+			if err != nil {
+				log.Printf("Error handling %s: %v", req.Type, err)
+			}
+			continue
+		}
+
+		// ✅ Toggle a reaction, participants get the updated counts through the backplane
+		if req.Type == "reaction" {
+			if req.ConversationID == nil || req.MessageID == nil {
+				continue
+			}
+
+			reaction := dto.ToggleReactionDto{Emoji: req.Emoji}
+			if err := validator.V.Struct(reaction); err != nil {
+				continue
+			}
+
+			if _, _, err := services.ToggleReaction(*req.ConversationID, *req.MessageID, c.userID, reaction.Emoji); err != nil {
+				log.Printf("Error toggling reaction: %v", err)
+			}
+			continue
+		}
+
 		// ✅ Handle typing events (no DB writes)
 		if req.Type == "typing_start" || req.Type == "typing_stop" {
 			evtType := dto.RealtimeEventTypingStart
@@ -92,19 +194,28 @@ func (c *Client) readPump() {
 
 			// Only broadcast if there is a routing target
 			if req.ConversationID != nil || req.TeamID != nil || req.RecipientID != nil {
-				c.hub.broadcast <- evt
+				services.PublishRealtimeEvent(evt, nil)
 			}
 			continue
 		}
 
 		// ✅ Normal message sending
-		if req.Content == "" {
+		if req.Content == "" && len(req.AttachmentIDs) == 0 {
 			continue
 		}
 
 		// New conversation-based messaging
 		if req.ConversationID != nil {
-			msg, err := services.SendMessage(*req.ConversationID, c.userID, req.Content)
+			send := dto.SendMessageDto{
+				Content:          req.Content,
+				ReplyToMessageID: req.ReplyToMessageID,
+				AttachmentIDs:    req.AttachmentIDs,
+			}
+			if err := validator.V.Struct(send); err != nil {
+				continue
+			}
+
+			msg, err := services.SendConversationMessage(*req.ConversationID, c.userID, send)
 			if err != nil {
 				log.Printf("Error sending message via conversation: %v", err)
 				continue
@@ -119,7 +230,7 @@ func (c *Client) readPump() {
 				Message:        &msgDto,
 			}
 
-			c.hub.broadcast <- evt
+			services.PublishRealtimeEvent(evt, nil)
 			continue
 		}
 
@@ -163,7 +274,7 @@ func (c *Client) readPump() {
 				Message:     &msg,
 			}
 
-			c.hub.broadcast <- evt
+			services.PublishRealtimeEvent(evt, nil)
 			continue
 		}
 
@@ -174,13 +285,33 @@ func (c *Client) readPump() {
 
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
+	stopAcks := make(chan struct{})
+	go c.acknowledgePump(stopAcks)
+
 	defer func() {
 		ticker.Stop()
+		close(stopAcks)
 		c.conn.Close()
 	}()
 
+	// A resume point from the handshake is replayed before any live event
+	select {
+	case point := <-c.resume:
+		if err := c.replay(point); err != nil {
+			log.Printf("Error replaying missed messages: %v", err)
+			return
+		}
+	default:
+	}
+
 	for {
 		select {
+		case point := <-c.resume:
+			if err := c.replay(point); err != nil {
+				log.Printf("Error replaying missed messages: %v", err)
+				return
+			}
+
 		case message, ok := <-c.send:
 			err := c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err != nil {
@@ -189,12 +320,20 @@ func (c *Client) writePump() {
 			}
 
 			if !ok {
-				if err := c.conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
+				closeMsg := c.closeMessage
+				if closeMsg == nil {
+					closeMsg = []byte{}
+				}
+				if err := c.conn.WriteMessage(websocket.CloseMessage, closeMsg); err != nil {
 					log.Printf("Error sending close message: %v", err)
 				}
 				return
 			}
 
+			if c.alreadyReplayed(message) {
+				continue
+			}
+
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
@@ -205,19 +344,30 @@ func (c *Client) writePump() {
 				return
 			}
 
+			delivered := deliveries{}
+			delivered.add(c.userID, message)
+
 			n := len(c.send)
 			for range n {
-				_, err := w.Write(<-c.send)
+				queued := <-c.send
+				if c.alreadyReplayed(queued) {
+					continue
+				}
+
+				_, err := w.Write(queued)
 				if err != nil {
 					log.Printf("Error writing message: %v", err)
 					return
 				}
+				delivered.add(c.userID, queued)
 			}
 
 			if err := w.Close(); err != nil {
 				return
 			}
 
+			c.acks.merge(delivered)
+
 		case <-ticker.C:
 			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
 				log.Printf("Error setting write deadline: %v", err)
@@ -230,3 +380,164 @@ func (c *Client) writePump() {
 		}
 	}
 }
+
+// replay streams the messages the user missed since the resume point, followed by a resume_complete event.
+// Live events wait in the send buffer meanwhile, those for replayed messages are skipped afterwards.
+func (c *Client) replay(point resumePoint) error {
+	afterID := point.messageID
+	delivered := deliveries{}
+
+	for {
+		messages, err := services.GetMessagesSince(c.userID, afterID, point.since, replayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			msgDto := dto.ToMessageResponseDtoForUser(msg, c.userID)
+			evt := dto.RealtimeEventDto{
+				Type:           dto.RealtimeEventMessage,
+				ConversationID: msg.ConversationID,
+				UserID:         msg.SenderID,
+				Timestamp:      msg.CreatedAt,
+				Message:        &msgDto,
+			}
+
+			if err := c.writeEvent(evt); err != nil {
+				return err
+			}
+			afterID = msg.ID
+
+			if msg.SenderID != c.userID {
+				delivered.set(*msg.ConversationID, msg.CreatedAt)
+			}
+		}
+
+		if len(messages) < replayBatchSize {
+			break
+		}
+	}
+
+	c.replayedUpTo = max(c.replayedUpTo, afterID)
+	c.acks.merge(delivered)
+
+	return c.writeEvent(dto.RealtimeEventDto{
+		Type:      dto.RealtimeEventResumeComplete,
+		UserID:    c.userID,
+		Timestamp: time.Now(),
+	})
+}
+
+// acknowledgePump marks the written messages as delivered every deliveryAckInterval,
+// so the database and the senders' events don't hold up writing to the app.
+// Whatever is left is acknowledged once stop is closed.
+func (c *Client) acknowledgePump(stop <-chan struct{}) {
+	ticker := time.NewTicker(deliveryAckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.acks.take().acknowledge(c.userID)
+		case <-stop:
+			c.acks.take().acknowledge(c.userID)
+			return
+		}
+	}
+}
+
+func (c *Client) writeEvent(evt dto.RealtimeEventDto) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+
+	return c.conn.WriteJSON(evt)
+}
+
+// alreadyReplayed reports whether the payload is a live event for a message that was already replayed.
+func (c *Client) alreadyReplayed(payload []byte) bool {
+	if c.replayedUpTo == 0 {
+		return false
+	}
+
+	var evt struct {
+		Type    dto.RealtimeEventType `json:"type"`
+		Message *struct {
+			ID uint `json:"id"`
+		} `json:"message"`
+	}
+
+	if err := json.Unmarshal(payload, &evt); err != nil {
+		return false
+	}
+
+	return evt.Type == dto.RealtimeEventMessage && evt.Message != nil && evt.Message.ID <= c.replayedUpTo
+}
+
+// deliveries collects, per conversation, the newest message written to the client.
+type deliveries map[uint]time.Time
+
+// add records the payload if it is a new message from someone else.
+func (d deliveries) add(userID uint, payload []byte) {
+	var evt struct {
+		Type           dto.RealtimeEventType `json:"type"`
+		ConversationID *uint                 `json:"conversation_id"`
+		UserID         uint                  `json:"user_id"`
+		Message        *struct {
+			CreatedAt time.Time `json:"created_at"`
+		} `json:"message"`
+	}
+
+	if err := json.Unmarshal(payload, &evt); err != nil {
+		return
+	}
+
+	if evt.Type != dto.RealtimeEventMessage || evt.ConversationID == nil || evt.Message == nil || evt.UserID == userID {
+		return
+	}
+
+	d.set(*evt.ConversationID, evt.Message.CreatedAt)
+}
+
+func (d deliveries) set(conversationID uint, createdAt time.Time) {
+	if createdAt.After(d[conversationID]) {
+		d[conversationID] = createdAt
+	}
+}
+
+// deliveryAcks collects deliveries between two acknowledgements, keeping the newest per conversation.
+type deliveryAcks struct {
+	mu      sync.Mutex
+	pending deliveries
+}
+
+func (a *deliveryAcks) merge(d deliveries) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.pending == nil {
+		a.pending = deliveries{}
+	}
+	for conversationID, deliveredAt := range d {
+		a.pending.set(conversationID, deliveredAt)
+	}
+}
+
+// take returns the collected deliveries and starts over.
+func (a *deliveryAcks) take() deliveries {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d := a.pending
+	a.pending = nil
+	return d
+}
+
+// acknowledge marks the messages as delivered to the user, the senders see the ticks change.
+func (d deliveries) acknowledge(userID uint) {
+	for conversationID, deliveredAt := range d {
+		if err := services.MarkConversationDelivered(conversationID, userID, deliveredAt); err != nil {
+			log.Printf("Error marking conversation %d delivered: %v", conversationID, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Room for the multipart boundaries and headers on top of the file itself
+const multipartOverhead = 1 << 20
+
+// UploadAttachment stores a file (multipart field "file") for a message the user is about to send.
+// The returned ID is passed in attachment_ids when sending the message.
+func UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	maxSize := services.MaxAttachmentSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			appError.HandleError(w, appError.ErrAttachmentTooLarge)
+			return
+		}
+		appError.HandleError(w, appError.ErrBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// One byte more than allowed, so the service can tell that the file is too large
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	attachment, err := services.UploadAttachment(uint(conversationID), user.ID, header.Filename, data)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.ToAttachmentResponseDto(*attachment))
+}
+
+// DownloadAttachment streams an attachment to a member of its conversation
+func DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	serveAttachment(w, r, false)
+}
+
+// DownloadAttachmentThumbnail streams the thumbnail of an image attachment
+func DownloadAttachmentThumbnail(w http.ResponseWriter, r *http.Request) {
+	serveAttachment(w, r, true)
+}
+
+func serveAttachment(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	attachmentID, err := strconv.ParseUint(chi.URLParam(r, "attachmentId"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	attachment, err := services.GetAttachment(uint(conversationID), uint(attachmentID), user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	file, err := services.OpenAttachment(attachment, thumbnail)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+	defer file.Close()
+
+	contentType := attachment.ContentType
+	if thumbnail && contentType != "image/jpeg" {
+		contentType = "image/png"
+	}
+
+	// Images are shown in the app, other files are saved
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	if !thumbnail {
+		w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	}
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Error streaming attachment %d: %v", attachment.ID, err)
+	}
+}
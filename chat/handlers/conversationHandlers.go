@@ -176,10 +176,26 @@ func GetConversationMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	receipts, err := services.GetReceiptParticipants(uint(conversationID), user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
 	// Convert to DTOs
 	messageDtos := make([]dto.MessageResponseDto, len(messages))
 	for i, msg := range messages {
-		messageDtos[i] = dto.ToMessageResponseDto(msg)
+		messageDtos[i] = dto.ToMessageResponseDtoForUser(msg, user.ID)
+		dto.ApplyMessageReceipts(&messageDtos[i], receipts, user.ID)
+	}
+
+	// Fetched messages reached this device
+	if len(messages) > 0 {
+		newest := messages[len(messages)-1].CreatedAt
+		if err := services.MarkConversationDelivered(uint(conversationID), user.ID, newest); err != nil {
+			appError.HandleError(w, err)
+			return
+		}
 	}
 
 	response := dto.MessagesPaginationDto{
@@ -216,16 +232,181 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	message, err := services.SendMessage(uint(conversationID), user.ID, req.Content)
+	message, err := services.SendConversationMessage(uint(conversationID), user.ID, req)
 	if err != nil {
 		appError.HandleError(w, err)
 		return
 	}
 
+	msgDto := dto.ToMessageResponseDto(*message)
+
+	// Deliver to connected participants, like messages sent over the WebSocket
+	convID := uint(conversationID)
+	services.PublishRealtimeEvent(dto.RealtimeEventDto{
+		Type:           dto.RealtimeEventMessage,
+		ConversationID: &convID,
+		UserID:         user.ID,
+		Timestamp:      time.Now(),
+		Message:        &msgDto,
+	}, nil)
+
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(msgDto)
+}
+
+// EditMessage changes the content of the user's own message
+func EditMessage(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, messageID, err := parseMessageParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	var req dto.EditMessageDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	message, err := services.EditMessage(conversationID, messageID, user.ID, req.Content)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
 	json.NewEncoder(w).Encode(dto.ToMessageResponseDto(*message))
 }
 
+// DeleteMessage deletes the user's own message for everyone
+func DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, messageID, err := parseMessageParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := services.DeleteMessage(conversationID, messageID, user.ID); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMessageEdits returns the previous versions of an edited message
+func GetMessageEdits(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, messageID, err := parseMessageParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	edits, err := services.GetMessageEdits(conversationID, messageID, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.MessageEditResponseDto, len(edits))
+	for i, edit := range edits {
+		response[i] = dto.ToMessageEditResponseDto(edit)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMessageThread returns a message with all replies below it
+func GetMessageThread(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, messageID, err := parseMessageParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	message, replies, err := services.GetMessageThread(conversationID, messageID, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	receipts, err := services.GetReceiptParticipants(conversationID, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := dto.ToMessageThreadResponseDto(*message, replies, user.ID)
+	dto.ApplyMessageReceipts(&response.Message, receipts, user.ID)
+	for i := range response.Replies {
+		dto.ApplyMessageReceipts(&response.Replies[i], receipts, user.ID)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// ToggleReaction adds or removes the user's reaction to a message
+func ToggleReaction(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, messageID, err := parseMessageParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	var req dto.ToggleReactionDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	message, _, err := services.ToggleReaction(conversationID, messageID, user.ID, req.Emoji)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.ToMessageResponseDtoForUser(*message, user.ID))
+}
+
 // MarkConversationRead marks a conversation as read
 func MarkConversationRead(w http.ResponseWriter, r *http.Request) {
 	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
@@ -246,8 +427,9 @@ func MarkConversationRead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Messages that weren't sent yet can't be read
 	readAt := time.Now()
-	if req.ReadAt != nil {
+	if req.ReadAt != nil && req.ReadAt.Before(readAt) {
 		readAt = *req.ReadAt
 	}
 
@@ -259,6 +441,40 @@ func MarkConversationRead(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// UpdateConversationNotifications mutes or unmutes push notifications for a conversation
+func UpdateConversationNotifications(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	var req dto.UpdateConversationNotificationsDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	participant, err := services.UpdateConversationNotifications(uint(conversationID), user.ID, req)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.ToConversationNotificationsResponseDto(*participant))
+}
+
 // GetTeamConversation returns the conversation for a team
 func GetTeamConversation(w http.ResponseWriter, r *http.Request) {
 	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
@@ -382,3 +598,18 @@ func SyncChallengeMembers(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// parseMessageParams reads the {id} and {messageId} URL parameters
+func parseMessageParams(r *http.Request) (uint, uint, error) {
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		return 0, 0, appError.ErrMissingIdParam
+	}
+
+	messageID, err := strconv.ParseUint(chi.URLParam(r, "messageId"), 10, 32)
+	if err != nil {
+		return 0, 0, appError.ErrMissingIdParam
+	}
+
+	return uint(conversationID), uint(messageID), nil
+}
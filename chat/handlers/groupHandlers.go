@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"server/common/validator"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RenameGroupConversation changes the title of a group
+func RenameGroupConversation(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	var req dto.RenameGroupConversationDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	conversation, err := services.RenameGroupConversation(uint(conversationID), user.ID, req.Title)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.ToConversationResponseDto(*conversation))
+}
+
+// AddGroupParticipants adds users to a group
+func AddGroupParticipants(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	var req dto.AddGroupParticipantsDto
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	if err := validator.V.Struct(req); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	conversation, err := services.AddGroupParticipants(uint(conversationID), user.ID, req.ParticipantIDs)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.ToConversationResponseDto(*conversation))
+}
+
+// RemoveGroupParticipant removes a participant from a group, removing yourself leaves it
+func RemoveGroupParticipant(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, participantID, err := parseParticipantParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	conversation, err := services.RemoveGroupParticipant(conversationID, user.ID, participantID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.ToConversationResponseDto(*conversation))
+}
+
+// LeaveGroupConversation removes the current user from a group
+func LeaveGroupConversation(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	if err := services.LeaveGroupConversation(uint(conversationID), user.ID); err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MakeGroupAdmin makes a participant an admin of a group
+func MakeGroupAdmin(w http.ResponseWriter, r *http.Request) {
+	setGroupAdmin(w, r, true)
+}
+
+// RevokeGroupAdmin removes the admin role from a participant of a group
+func RevokeGroupAdmin(w http.ResponseWriter, r *http.Request) {
+	setGroupAdmin(w, r, false)
+}
+
+func setGroupAdmin(w http.ResponseWriter, r *http.Request, admin bool) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	conversationID, participantID, err := parseParticipantParams(r)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	conversation, err := services.SetGroupAdmin(conversationID, user.ID, participantID, admin)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.ToConversationResponseDto(*conversation))
+}
+
+// parseParticipantParams reads the conversation and user IDs from the URL.
+func parseParticipantParams(r *http.Request) (uint, uint, error) {
+	conversationID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		return 0, 0, appError.ErrMissingIdParam
+	}
+
+	userID, err := strconv.ParseUint(chi.URLParam(r, "userId"), 10, 32)
+	if err != nil {
+		return 0, 0, appError.ErrMissingIdParam
+	}
+
+	return uint(conversationID), uint(userID), nil
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/common/appError"
+	"server/common/dto"
+	"server/common/middleware"
+	"server/common/models"
+	"server/common/services"
+	"strconv"
+	"strings"
+)
+
+// Most users one presence request can ask for
+const maxPresenceUsers = 100
+
+// GetPresence returns the presence of the users in the user_ids query parameter (comma separated).
+// Live changes arrive as "presence" events on the WebSocket.
+func GetPresence(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if !ok {
+		appError.HandleError(w, appError.ErrUnauthorized)
+		return
+	}
+
+	param := r.URL.Query().Get("user_ids")
+	if param == "" {
+		appError.HandleError(w, appError.ErrMissingIdParam)
+		return
+	}
+
+	parts := strings.Split(param, ",")
+	if len(parts) > maxPresenceUsers {
+		appError.HandleError(w, appError.ErrBadRequest)
+		return
+	}
+
+	userIDs := make([]uint, len(parts))
+	for i, part := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil {
+			appError.HandleError(w, appError.ErrMissingIdParam)
+			return
+		}
+		userIDs[i] = uint(id)
+	}
+
+	presences, err := services.GetPresence(userIDs, user.ID)
+	if err != nil {
+		appError.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.PresenceResponseDto, len(presences))
+	for i, p := range presences {
+		response[i] = dto.ToPresenceResponseDto(p)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -3,32 +3,69 @@ package main
 import (
 	"encoding/json"
 	"log"
+	"server/common/backplane"
 	"server/common/dto"
 	"server/common/services"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
+// How often connected users are checked for bans, suspensions and sessions that ended
+const restrictionCheckPeriod = time.Minute
+
 type Hub struct {
 	clients map[*Client]bool
 
-	// ✅ Now broadcast realtime events (message + typing)
-	broadcast chan dto.RealtimeEventDto
+	// ✅ Realtime events (message, typing, API events) from every chat instance
+	events <-chan backplane.Envelope
 
 	register   chan *Client
 	unregister chan *Client
+
+	// Results of checks that run outside the hub loop
+	disconnects chan disconnect
 }
 
-func newHub() *Hub {
+// disconnect closes the connections of the users or sessions, found by a check in the background.
+type disconnect struct {
+	userIDs    []uint
+	sessionIDs []uint
+	reason     string
+}
+
+func newHub(events <-chan backplane.Envelope) *Hub {
 	return &Hub{
-		broadcast:  make(chan dto.RealtimeEventDto),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		clients:    make(map[*Client]bool),
+		events:      events,
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		disconnects: make(chan disconnect),
+		clients:     make(map[*Client]bool),
 	}
 }
 
 func (h *Hub) run() {
+	restrictionTicker := time.NewTicker(restrictionCheckPeriod)
+	defer restrictionTicker.Stop()
+
+	presenceTicker := time.NewTicker(services.PresenceHeartbeatPeriod)
+	defer presenceTicker.Stop()
+
 	for {
 		select {
+		case <-restrictionTicker.C:
+			h.disconnectRestrictedUsers()
+			h.disconnectEndedSessions()
+
+		case d := <-h.disconnects:
+			for _, userID := range d.userIDs {
+				h.disconnectUser(userID, d.reason)
+			}
+			h.disconnectSessions(d.sessionIDs, d.reason)
+
+		case <-presenceTicker.C:
+			h.heartbeatPresence()
+
 		case client := <-h.register:
 			h.clients[client] = true
 			log.Printf("User %d connected", client.userID)
@@ -39,65 +76,291 @@ func (h *Hub) run() {
 				close(client.send)
 			}
 
-		case evt := <-h.broadcast:
-			payload, err := json.Marshal(evt)
-			if err != nil {
-				log.Println("Error marshaling realtime event:", err)
+		case env, ok := <-h.events:
+			if !ok {
+				log.Println("Backplane subscription closed, no more realtime events are delivered")
+				h.events = nil
 				continue
 			}
 
-			// ✅ Pre-fetch participant IDs for conversation routing (once per event)
-			var participantIDs map[uint]bool
+			h.route(env)
+		}
+	}
+}
+
+// route delivers an event from the backplane to the clients connected to this instance.
+func (h *Hub) route(env backplane.Envelope) {
+	evt := env.Event
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		log.Println("Error marshaling realtime event:", err)
+		return
+	}
+
+	if evt.Type == dto.RealtimeEventSessionRevoked {
+		h.closeRevokedSessions(env.UserIDs, evt.SessionID, payload)
+		return
+	}
+
+	if evt.Type == dto.RealtimeEventMembershipChanged {
+		h.applyMembershipChange(evt)
+	}
+
+	// Events for a fixed set of users, e.g. notifications from the API
+	var userIDs map[uint]bool
+	if len(env.UserIDs) > 0 {
+		userIDs = make(map[uint]bool, len(env.UserIDs))
+		for _, id := range env.UserIDs {
+			userIDs[id] = true
+		}
+	}
+
+	// ✅ Pre-fetch participant IDs for conversation routing (once per event)
+	var participantIDs map[uint]bool
+	if userIDs == nil && evt.ConversationID != nil {
+		ids, err := services.GetConversationParticipantIDs(*evt.ConversationID)
+		if err != nil {
+			log.Println("Error fetching conversation participants:", err)
+			return
+		}
+		participantIDs = make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			participantIDs[id] = true
+		}
+	}
+
+	var hiddenReplyPayload []byte
+
+	for client := range h.clients {
+		shouldSend := false
+
+		// If the receiving client has blocked the triggering user, skip
+		if client.blockedUserIDs[evt.UserID] {
+			continue
+		}
+
+		if userIDs != nil {
+			shouldSend = userIDs[client.userID]
+		} else {
+			// Conversation routing
 			if evt.ConversationID != nil {
-				ids, err := services.GetConversationParticipantIDs(*evt.ConversationID)
-				if err != nil {
-					log.Println("Error fetching conversation participants:", err)
-					continue
-				}
-				participantIDs = make(map[uint]bool, len(ids))
-				for _, id := range ids {
-					participantIDs[id] = true
+				if participantIDs != nil && participantIDs[client.userID] {
+					shouldSend = true
 				}
 			}
 
-			for client := range h.clients {
-				shouldSend := false
-
-				// If the receiving client has blocked the triggering user, skip
-				if client.blockedUserIDs[evt.UserID] {
-					continue
+			// Legacy team routing
+			if evt.TeamID != nil {
+				if _, isMember := client.teamIDs[*evt.TeamID]; isMember {
+					shouldSend = true
 				}
+			}
 
-				// Conversation routing
-				if evt.ConversationID != nil {
-					if participantIDs != nil && participantIDs[client.userID] {
-						shouldSend = true
-					}
+			// Legacy DM routing
+			if evt.RecipientID != nil {
+				if client.userID == *evt.RecipientID || client.userID == evt.UserID {
+					shouldSend = true
 				}
+			}
+		}
 
-				// Legacy team routing
-				if evt.TeamID != nil {
-					if _, isMember := client.teamIDs[*evt.TeamID]; isMember {
-						shouldSend = true
-					}
-				}
+		if shouldSend {
+			clientPayload := payload
 
-				// Legacy DM routing
-				if evt.RecipientID != nil {
-					if client.userID == *evt.RecipientID || client.userID == evt.UserID {
-						shouldSend = true
-					}
+			// Like GetMessages, the quoted parent is hidden from users who blocked its sender
+			if evt.Message != nil && evt.Message.ReplyTo != nil && client.blockedUserIDs[evt.Message.ReplyTo.SenderID] {
+				if hiddenReplyPayload == nil {
+					hiddenReplyPayload = withoutReplyPreview(evt)
 				}
+				clientPayload = hiddenReplyPayload
+			}
 
-				if shouldSend {
-					select {
-					case client.send <- payload:
-					default:
-						close(client.send)
-						delete(h.clients, client)
-					}
-				}
+			select {
+			case client.send <- clientPayload:
+			default:
+				close(client.send)
+				delete(h.clients, client)
 			}
 		}
 	}
+
+	// The event is queued before the connection closes, so the app can tell the user why
+	if evt.Type == dto.RealtimeEventAccountRestricted {
+		for _, id := range env.UserIDs {
+			h.disconnectUser(id, "account restricted")
+		}
+	}
+}
+
+// withoutReplyPreview marshals the event with the quoted parent of its message removed.
+func withoutReplyPreview(evt dto.RealtimeEventDto) []byte {
+	msg := *evt.Message
+	msg.ReplyTo = nil
+	evt.Message = &msg
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		log.Println("Error marshaling realtime event:", err)
+		return nil
+	}
+
+	return payload
+}
+
+// applyMembershipChange keeps the cached team memberships of connected clients up to date.
+func (h *Hub) applyMembershipChange(evt dto.RealtimeEventDto) {
+	if evt.TeamID == nil || evt.Membership == nil {
+		return
+	}
+
+	added := make(map[uint]bool, len(evt.Membership.Added))
+	for _, id := range evt.Membership.Added {
+		added[id] = true
+	}
+
+	removed := make(map[uint]bool, len(evt.Membership.Removed))
+	for _, id := range evt.Membership.Removed {
+		removed[id] = true
+	}
+
+	for client := range h.clients {
+		if added[client.userID] {
+			client.teamIDs[*evt.TeamID] = true
+		}
+		if removed[client.userID] {
+			delete(client.teamIDs, *evt.TeamID)
+		}
+	}
+}
+
+// disconnectRestrictedUsers closes the connections of users who were banned or suspended since they connected.
+// The database work runs outside the hub loop.
+func (h *Hub) disconnectRestrictedUsers() {
+	seen := make(map[uint]bool)
+	userIDs := make([]uint, 0, len(h.clients))
+	for client := range h.clients {
+		if !seen[client.userID] {
+			seen[client.userID] = true
+			userIDs = append(userIDs, client.userID)
+		}
+	}
+
+	go func() {
+		restricted, err := services.GetRestrictedUserIDs(userIDs)
+		if err != nil {
+			log.Println("Error fetching restricted users:", err)
+			return
+		}
+
+		if len(restricted) > 0 {
+			h.disconnects <- disconnect{userIDs: restricted, reason: "account restricted"}
+		}
+	}()
+}
+
+// disconnectEndedSessions closes the connections of sessions that expired, or were revoked
+// without the event reaching this instance. The database work runs outside the hub loop.
+func (h *Hub) disconnectEndedSessions() {
+	sessionIDs := make([]uint, 0, len(h.clients))
+	for client := range h.clients {
+		sessionIDs = append(sessionIDs, client.sessionID)
+	}
+
+	go func() {
+		ended, err := services.GetInvalidSessionIDs(sessionIDs)
+		if err != nil {
+			log.Println("Error fetching ended sessions:", err)
+			return
+		}
+
+		if len(ended) > 0 {
+			h.disconnects <- disconnect{sessionIDs: ended, reason: "session revoked"}
+		}
+	}()
+}
+
+// closeRevokedSessions sends the event to the connections of the revoked session, or of every
+// session of the users when sessionID is nil, and closes them.
+func (h *Hub) closeRevokedSessions(userIDs []uint, sessionID *uint, payload []byte) {
+	users := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		users[id] = true
+	}
+
+	for client := range h.clients {
+		if !users[client.userID] || (sessionID != nil && client.sessionID != *sessionID) {
+			continue
+		}
+
+		select {
+		case client.send <- payload:
+		default:
+		}
+		h.closeClient(client, "session revoked")
+	}
+}
+
+// heartbeatPresence confirms the open connections and updates the presence of their users,
+// e.g. users whose apps went quiet become away. The database work runs outside the hub loop.
+func (h *Hub) heartbeatPresence() {
+	var connectionIDs, activeIDs, userIDs []uint
+	for client := range h.clients {
+		if client.presenceID == 0 {
+			continue
+		}
+
+		connectionIDs = append(connectionIDs, client.presenceID)
+		userIDs = append(userIDs, client.userID)
+		if client.active.Swap(false) {
+			activeIDs = append(activeIDs, client.presenceID)
+		}
+	}
+
+	go func() {
+		if err := services.HeartbeatPresence(connectionIDs, activeIDs); err != nil {
+			log.Println("Error sending presence heartbeat:", err)
+			return
+		}
+
+		// Connections of crashed instances, their users may go offline
+		if err := services.ExpirePresenceConnections(); err != nil {
+			log.Println("Error expiring presence connections:", err)
+		}
+
+		if err := services.RefreshPresence(userIDs); err != nil {
+			log.Println("Error refreshing presence:", err)
+		}
+	}()
+}
+
+// disconnectUser closes every connection of the user with a policy violation.
+func (h *Hub) disconnectUser(userID uint, reason string) {
+	for client := range h.clients {
+		if client.userID == userID {
+			h.closeClient(client, reason)
+		}
+	}
+}
+
+// disconnectSessions closes the connections of the sessions with a policy violation.
+func (h *Hub) disconnectSessions(sessionIDs []uint, reason string) {
+	sessions := make(map[uint]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		sessions[id] = true
+	}
+
+	for client := range h.clients {
+		if sessions[client.sessionID] {
+			h.closeClient(client, reason)
+		}
+	}
+}
+
+// closeClient closes the connection with a policy violation, queued events are still sent first.
+func (h *Hub) closeClient(client *Client, reason string) {
+	client.closeMessage = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	delete(h.clients, client)
+	close(client.send)
+	log.Printf("User %d disconnected: %s", client.userID, reason)
 }
@@ -1,19 +1,23 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"os"
 	"server/chat/handlers"
+	"server/common/backplane"
 	"server/common/config"
 	"server/common/logger"
 	commonMiddleware "server/common/middleware"
 	"server/common/models"
+	"server/common/services"
+	"server/common/storage"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func main() {
@@ -22,13 +26,37 @@ func main() {
 	slog.Info("💬 Chat Service starting...")
 
 	config.LoadConfig()
+
+	// Only the verification keys are needed here
+	if _, err := services.JWTKeySet(); err != nil {
+		slog.Error("Failed to load JWT keys", "error", err)
+		os.Exit(1)
+	}
+
 	config.ConnectDatabase()
 
 	// Note: Database migrations and PostGIS extension are handled by the API service
 	// The chat service only needs to connect to the database
 	slog.Info("✅ Database connected")
 
-	hub := newHub()
+	// Fan realtime events out across chat instances, the API publishes through it too
+	bp := backplane.NewPostgres(config.DatabaseDSN(), config.DB)
+	services.SetBackplane(bp)
+
+	events, err := bp.Subscribe(context.Background())
+	if err != nil {
+		slog.Error("Failed to subscribe to the realtime backplane", "error", err)
+		os.Exit(1)
+	}
+
+	attachments, err := storage.NewLocal(config.AppConfig.AttachmentStorageDir)
+	if err != nil {
+		slog.Error("Failed to open attachment storage", "error", err)
+		os.Exit(1)
+	}
+	services.SetAttachmentStorage(attachments)
+
+	hub := newHub(events)
 	go hub.run()
 
 	// Setup Chi router
@@ -56,13 +84,36 @@ func main() {
 		r.Post("/group", handlers.CreateGroupConversation)
 		r.Get("/", handlers.ListConversations)
 		r.Get("/{id}", handlers.GetConversation)
+		r.Put("/{id}", handlers.RenameGroupConversation)
+		r.Post("/{id}/participants", handlers.AddGroupParticipants)
+		r.Delete("/{id}/participants/{userId}", handlers.RemoveGroupParticipant)
+		r.Put("/{id}/participants/{userId}/admin", handlers.MakeGroupAdmin)
+		r.Delete("/{id}/participants/{userId}/admin", handlers.RevokeGroupAdmin)
+		r.Post("/{id}/leave", handlers.LeaveGroupConversation)
 		r.Get("/{id}/messages", handlers.GetConversationMessages)
 		r.Post("/{id}/messages", handlers.SendMessage)
+		r.Put("/{id}/messages/{messageId}", handlers.EditMessage)
+		r.Delete("/{id}/messages/{messageId}", handlers.DeleteMessage)
+		r.Get("/{id}/messages/{messageId}/edits", handlers.GetMessageEdits)
+		r.Get("/{id}/messages/{messageId}/thread", handlers.GetMessageThread)
+		r.Post("/{id}/messages/{messageId}/reactions", handlers.ToggleReaction)
+		r.Post("/{id}/attachments", handlers.UploadAttachment)
+		r.Get("/{id}/attachments/{attachmentId}", handlers.DownloadAttachment)
+		r.Get("/{id}/attachments/{attachmentId}/thumbnail", handlers.DownloadAttachmentThumbnail)
 		r.Post("/{id}/read", handlers.MarkConversationRead)
+		r.Put("/{id}/notifications", handlers.UpdateConversationNotifications)
 		r.Get("/team/{teamId}", handlers.GetTeamConversation)
 		r.Get("/challenge/{challengeId}", handlers.GetChallengeConversation)
 	})
 
+	// Presence of friends and chat partners
+	r.Route("/api/presence", func(r chi.Router) {
+		r.Use(commonMiddleware.AuthMiddleware)
+		r.Use(commonMiddleware.EulaMiddleware)
+
+		r.Get("/", handlers.GetPresence)
+	})
+
 	// Internal endpoints for team/challenge sync (no auth for internal service calls)
 	r.Post("/internal/teams/{teamId}/sync", handlers.SyncTeamMembers)
 	r.Post("/internal/challenges/{challengeId}/sync", handlers.SyncChallengeMembers)
@@ -95,26 +146,35 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := &struct {
-		UserID uint `json:"user_id"`
-		jwt.RegisteredClaims
-	}{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
-
-	if err != nil || !token.Valid {
+	claims, err := services.ValidateJWTToken(tokenString)
+	if err != nil {
 		http.Error(w, "Invalid token", http.StatusUnauthorized)
 		return
 	}
 
+	if err := services.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+		http.Error(w, "Session revoked", http.StatusUnauthorized)
+		return
+	}
+
 	var user models.User
 	if err := config.DB.Preload("Teams.Team").Preload("BlockedUsers").First(&user, claims.UserID).Error; err != nil {
 		http.Error(w, "User not found", http.StatusUnauthorized)
 		return
 	}
 
+	if err := services.CheckAccountStatus(&user); err != nil {
+		http.Error(w, "Account restricted", http.StatusForbidden)
+		return
+	}
+
+	// Optional resume point, the messages missed since then are replayed first
+	point, resume, err := parseResumePoint(r)
+	if err != nil {
+		http.Error(w, "Invalid resume point", http.StatusBadRequest)
+		return
+	}
+
 	allowedTeams := make(map[uint]bool)
 	for _, team := range user.Teams {
 		allowedTeams[team.TeamID] = true
@@ -137,8 +197,20 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		conn:           conn,
 		send:           make(chan []byte, 256),
 		userID:         claims.UserID,
+		sessionID:      claims.SessionID,
 		teamIDs:        allowedTeams,
 		blockedUserIDs: blockedUsers,
+		resume:         make(chan resumePoint, 1),
+	}
+
+	if resume {
+		client.resume <- point
+	}
+
+	// Presence is best effort, the connection works without it
+	client.presenceID, err = services.ConnectPresence(client.userID)
+	if err != nil {
+		slog.Error("Failed to record presence", "error", err)
 	}
 
 	client.hub.register <- client
@@ -146,3 +218,32 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	go client.writePump()
 	go client.readPump()
 }
+
+// parseResumePoint reads the since_message_id and since (RFC 3339) query parameters of the handshake.
+func parseResumePoint(r *http.Request) (resumePoint, bool, error) {
+	var point resumePoint
+
+	sinceID := r.URL.Query().Get("since_message_id")
+	since := r.URL.Query().Get("since")
+	if sinceID == "" && since == "" {
+		return point, false, nil
+	}
+
+	if sinceID != "" {
+		id, err := strconv.ParseUint(sinceID, 10, 32)
+		if err != nil {
+			return point, false, err
+		}
+		point.messageID = uint(id)
+	}
+
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return point, false, err
+		}
+		point.since = &t
+	}
+
+	return point, true, nil
+}
@@ -10,34 +10,25 @@ import (
 	"server/common/models"
 	"server/common/services"
 	"strconv"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // Helper function to extract and validate claims from the JWT token
-func authenticateRequest(r *http.Request) (*struct {
-	UserID uint `json:"user_id"`
-	jwt.RegisteredClaims
-}, error) {
+func authenticateRequest(r *http.Request) (*services.Claims, error) {
 	tokenString := r.Header.Get("Authorization")
 	if tokenString == "" || len(tokenString) < 7 || tokenString[:6] != "Bearer" {
 		return nil, fmt.Errorf("missing or invalid Authorization header")
 	}
 	tokenString = tokenString[7:] // Remove "Bearer "
 
-	claims := &struct {
-		UserID uint `json:"user_id"`
-		jwt.RegisteredClaims
-	}{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
-
-	if err != nil || !token.Valid {
+	claims, err := services.ValidateJWTToken(tokenString)
+	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
+	if err := services.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
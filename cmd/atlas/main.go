@@ -28,12 +28,27 @@ func main() {
 		&models.Notification{},
 		&models.UserSettings{},
 		&models.Message{},
+		&models.MessageEdit{},
+		&models.MessageReaction{},
+		&models.MessageMention{},
+		&models.MessageAttachment{},
 		&models.Conversation{},
 		&models.ConversationParticipant{},
 		&models.EmergencyInfo{},
 		&models.Report{},
 		&models.EulaVersion{},
 		&models.EulaAcceptance{},
+		&models.TeamInviteLink{},
+		&models.UserSportProfile{},
+		&models.DataExport{},
+		&models.UserSession{},
+		&models.AuthThrottle{},
+		&models.MFARecoveryCode{},
+		&models.MFAChallenge{},
+		&models.AdminAuditLog{},
+		&models.BackplanePayload{},
+		&models.PresenceConnection{},
+		&models.UserPresence{},
 	)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load gorm schema: %v\n", err)
@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"server/common/models"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
@@ -17,6 +20,13 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// AccountRestrictedResponse tells suspended and banned users why and for how long
+type AccountRestrictedResponse struct {
+	Error          string     `json:"error"`
+	Reason         string     `json:"reason,omitempty"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
 // New struct for structured validation errors
 type ValidationErrorResponse struct {
 	Error   string            `json:"error"`
@@ -63,7 +73,31 @@ func HandleError(w http.ResponseWriter, err error) {
 		return
 	}
 
-	// 2. Iterate the error map
+	// 2. Tell rate limited clients when to retry
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		seconds := int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	}
+
+	// 3. Tell restricted users why
+	var restrictedErr *AccountRestrictedError
+	if errors.As(err, &restrictedErr) {
+		w.WriteHeader(http.StatusForbidden)
+
+		resp := AccountRestrictedResponse{
+			Error:          restrictedErr.Error(),
+			Reason:         restrictedErr.Reason,
+			SuspendedUntil: restrictedErr.Until,
+		}
+
+		if encodeErr := json.NewEncoder(w).Encode(resp); encodeErr != nil {
+			slog.Error("Failed to encode account restricted response", "error", encodeErr)
+		}
+		return
+	}
+
+	// 4. Iterate the error map
 	for statusCode, knownErrors := range errorMap {
 		for _, knownErr := range knownErrors {
 			if errors.Is(err, knownErr) {
@@ -82,7 +116,7 @@ func HandleError(w http.ResponseWriter, err error) {
 		}
 	}
 
-	// 3. Default to 500
+	// 5. Default to 500
 	w.WriteHeader(http.StatusInternalServerError)
 	if encodeErr := json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("An unexpected error occured: %s", err.Error())}); encodeErr != nil {
 		slog.Error("Failed to encode default error response", "error", encodeErr)
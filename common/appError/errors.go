@@ -3,6 +3,7 @@ package appError
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -12,12 +13,14 @@ var (
 	ErrUnknownResource = errors.New("unknown resource")
 	ErrServerError     = errors.New("internal server error")
 	ErrBadRequest      = errors.New("bad request")
+	ErrTooManyRequests = errors.New("too many requests, please try again later")
 )
 
 // Authentication and Authorization Errors
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrSessionRevoked     = errors.New("session has been revoked or expired")
 	ErrUnauthorized       = errors.New("unauthorized")
 	ErrAuthHeaderMissing  = errors.New("authorization header missing")
 	ErrInvalidAuthHeader  = errors.New("invalid authorization header format")
@@ -25,12 +28,85 @@ var (
 	ErrInvalidFriendship  = errors.New("invalid friendship")
 	ErrSameUser           = errors.New("same user")
 	ErrUserBlocked        = errors.New("you have been blocked by this user")
+	ErrAdminRequired      = errors.New("admin access required")
+	ErrAccountSuspended   = errors.New("account is suspended")
+	ErrAccountBanned      = errors.New("account is banned")
 )
 
+// AccountRestrictedError is returned for suspended and banned users.
+// Until is nil for bans. The error handler adds the reason and end of the suspension to the response.
+type AccountRestrictedError struct {
+	Until  *time.Time
+	Reason string
+}
+
+func (e *AccountRestrictedError) Error() string {
+	return e.Unwrap().Error()
+}
+
+func (e *AccountRestrictedError) Unwrap() error {
+	if e.Until == nil {
+		return ErrAccountBanned
+	}
+	return ErrAccountSuspended
+}
+
 var (
 	ErrUserExists    = errors.New("user with this email already exists")
 	ErrInvalidSport  = errors.New("invalid sport name")
 	ErrSportNotFound = errors.New("sport not found")
+	ErrSportExists   = errors.New("sport already exists")
+)
+
+// Rate Limit Errors
+var (
+	ErrTooManyAttempts = errors.New("too many failed attempts, please try again later")
+)
+
+// RateLimitError is returned while an account or IP address is locked out.
+// The error handler sends RetryAfter as the Retry-After header.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// Two-Factor Authentication Errors
+var (
+	ErrMFARequired       = errors.New("two-factor authentication code required")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrReauthRequired    = errors.New("please sign in again to continue")
+)
+
+// MFARequiredError is returned by a correct password login when 2FA is enabled.
+// The client completes the login by sending a code together with Token.
+type MFARequiredError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// Email Verification Errors
+var (
+	ErrEmailNotVerified        = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified    = errors.New("email address is already verified")
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+	ErrEmailManagedByProvider  = errors.New("the email of a Google or Apple account can't be changed")
 )
 
 // Invitation Errors
@@ -41,6 +117,28 @@ var (
 	ErrInvitationDeclined        = errors.New("user has already declined this invitation")
 	ErrInvitationProcessed       = errors.New("invitation already processed")
 	ErrUnhandledInvitationStatus = errors.New("unhandled invitation status")
+	ErrFriendRequestsNotAllowed  = errors.New("this user does not accept friend requests from you")
+)
+
+// Team Errors
+var (
+	ErrUserAlreadyInTeam   = errors.New("user is already a member of this team")
+	ErrInvalidTeamRole     = errors.New("invalid team role")
+	ErrInviteLinkExpired   = errors.New("invite link has expired")
+	ErrInviteLinkRevoked   = errors.New("invite link has been revoked")
+	ErrInviteLinkExhausted = errors.New("invite link has reached its maximum number of uses")
+)
+
+// Restore Errors
+var (
+	ErrNotDeleted           = errors.New("resource is not deleted")
+	ErrRestoreWindowExpired = errors.New("restore window has expired")
+)
+
+// Data Export Errors
+var (
+	ErrDataExportInProgress = errors.New("a data export is already in progress")
+	ErrDataExportExpired    = errors.New("data export download has expired")
 )
 
 // Conversation Errors
@@ -51,6 +149,16 @@ var (
 	ErrInvalidConversationType  = errors.New("invalid conversation type")
 	ErrTeamConversationExists   = errors.New("team conversation already exists")
 	ErrInsufficientParticipants = errors.New("group conversation requires at least 2 participants")
+	ErrMessageNotFound          = errors.New("message not found")
+	ErrNotMessageSender         = errors.New("only the sender can change this message")
+	ErrMessageDeleted           = errors.New("message has been deleted")
+	ErrAttachmentNotFound       = errors.New("attachment not found")
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrConversationNotEditable  = errors.New("only group conversations can be changed")
+	ErrNotConversationAdmin     = errors.New("only group admins can do this")
+	ErrParticipantNotFound      = errors.New("user is not a participant of this conversation")
+	ErrLastConversationAdmin    = errors.New("a group needs at least one admin")
 )
 
 // Challenge Errors
@@ -60,6 +168,12 @@ var (
 	ErrChallengeAlreadyConfirmed  = errors.New("challenge is already confirmed")
 )
 
+// Moderation Errors
+var (
+	ErrModeratorRequired = errors.New("moderator access required")
+	ErrNoPendingReports  = errors.New("no pending reports for this target")
+)
+
 // Facility Errors
 var (
 	ErrFacilityNotFound = errors.New("facility not found")
@@ -69,6 +183,7 @@ var (
 var (
 	ErrEulaNotAccepted = errors.New("EULA not accepted")
 	ErrEulaNotActive   = errors.New("this EULA version is not active")
+	ErrEulaExists      = errors.New("this EULA version already exists for the locale")
 )
 
 var (
@@ -89,17 +204,34 @@ var errorMap = map[int][]error{
 		ErrSportNotFound,
 		ErrFacilityNotFound,
 		ErrConversationNotFound,
+		ErrNoPendingReports,
+		ErrMessageNotFound,
+		ErrAttachmentNotFound,
+		ErrParticipantNotFound,
 	},
 	http.StatusUnauthorized: {
 		ErrInvalidCredentials,
 		ErrInvalidToken,
 		ErrUnauthorized,
 		ErrUserNotFound,
+		ErrSessionRevoked,
+		ErrMFARequired,
+		ErrInvalidMFACode,
 	},
 	http.StatusForbidden: {
 		ErrUserBlocked,
 		ErrNotConversationMember,
 		ErrEulaNotAccepted,
+		ErrFriendRequestsNotAllowed,
+		ErrEmailNotVerified,
+		ErrAdminRequired,
+		ErrModeratorRequired,
+		ErrAccountSuspended,
+		ErrAccountBanned,
+		ErrNotMessageSender,
+		ErrNotConversationAdmin,
+		ErrReauthRequired,
+		ErrEmailManagedByProvider,
 	},
 	http.StatusConflict: {
 		ErrUserExists,
@@ -112,6 +244,22 @@ var errorMap = map[int][]error{
 		ErrChallengeFullParticipation,
 		ErrUserAlreadyInChallenge,
 		ErrChallengeAlreadyConfirmed,
+		ErrUserAlreadyInTeam,
+		ErrNotDeleted,
+		ErrDataExportInProgress,
+		ErrEmailAlreadyVerified,
+		ErrMFAAlreadyEnabled,
+		ErrSportExists,
+		ErrEulaExists,
+		ErrLastConversationAdmin,
+	},
+	http.StatusGone: {
+		ErrInviteLinkExpired,
+		ErrInviteLinkRevoked,
+		ErrInviteLinkExhausted,
+		ErrRestoreWindowExpired,
+		ErrDataExportExpired,
+		ErrMessageDeleted,
 	},
 	http.StatusBadRequest: {
 		ErrInvalidSport,
@@ -124,10 +272,24 @@ var errorMap = map[int][]error{
 		ErrCannotMessageSelf,
 		ErrInvalidConversationType,
 		ErrInsufficientParticipants,
+		ErrConversationNotEditable,
 		ErrUnhandledInvitationStatus,
 		ErrBadRequest,
 		ErrEulaNotActive,
 		ErrInvalidPushToken,
+		ErrInvalidTeamRole,
+		ErrInvalidVerificationCode,
+		ErrMFANotEnabled,
+	},
+	http.StatusRequestEntityTooLarge: {
+		ErrAttachmentTooLarge,
+	},
+	http.StatusUnsupportedMediaType: {
+		ErrAttachmentTypeNotAllowed,
+	},
+	http.StatusTooManyRequests: {
+		ErrTooManyRequests,
+		ErrTooManyAttempts,
 	},
 	http.StatusInternalServerError: {
 		ErrUnknownResource,
@@ -0,0 +1,34 @@
+// Package backplane fans realtime events out to every chat instance.
+//
+// Each chat instance only holds the WebSocket clients connected to it, so events are
+// published to the backplane instead of straight to the local hub. Every instance
+// subscribes and delivers the events to its own clients. The API publishes through
+// the same backplane, e.g. new notifications or membership changes.
+package backplane
+
+import (
+	"context"
+
+	"server/common/dto"
+
+	"gorm.io/gorm"
+)
+
+// Envelope carries a realtime event between instances.
+// If UserIDs is set the event goes to exactly those users,
+// otherwise the hub routes it by the routing fields of the event.
+type Envelope struct {
+	Event   dto.RealtimeEventDto `json:"event"`
+	UserIDs []uint               `json:"user_ids,omitempty"`
+}
+
+type Backplane interface {
+	// Publish sends the envelope to every subscriber, including those of this instance.
+	// When db is a transaction, the envelope is only delivered once it commits.
+	// db may be nil outside of transactions.
+	Publish(env Envelope, db *gorm.DB) error
+
+	// Subscribe returns the published envelopes until ctx is done.
+	// Delivery is best effort, envelopes published while the connection is lost are dropped.
+	Subscribe(ctx context.Context) (<-chan Envelope, error)
+}
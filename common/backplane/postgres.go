@@ -0,0 +1,171 @@
+package backplane
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/common/models"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	postgresChannel = "realtime_events"
+
+	// NOTIFY payloads must be shorter than 8000 bytes, larger envelopes are passed by reference
+	maxNotifyPayload = 7000
+	payloadRefPrefix = "ref:"
+
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Postgres is a Backplane built on LISTEN/NOTIFY.
+// Notifications are transactional, so events published inside a transaction
+// are never delivered if it rolls back.
+type Postgres struct {
+	dsn string
+	db  *gorm.DB
+}
+
+// NewPostgres returns a backplane that publishes through db and listens on
+// its own connection to dsn, since a pooled connection can't stay in LISTEN.
+func NewPostgres(dsn string, db *gorm.DB) *Postgres {
+	return &Postgres{dsn: dsn, db: db}
+}
+
+func (p *Postgres) Publish(env Envelope, db *gorm.DB) error {
+	if db == nil {
+		db = p.db
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	payload := string(data)
+	if len(data) > maxNotifyPayload {
+		row := models.BackplanePayload{Payload: data}
+		if err := db.Create(&row).Error; err != nil {
+			return err
+		}
+		payload = payloadRefPrefix + strconv.FormatUint(uint64(row.ID), 10)
+	}
+
+	return db.Exec("SELECT pg_notify(?, ?)", postgresChannel, payload).Error
+}
+
+func (p *Postgres) Subscribe(ctx context.Context) (<-chan Envelope, error) {
+	conn, err := p.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Envelope, 256)
+	go p.receive(ctx, conn, out)
+
+	return out, nil
+}
+
+// Package private methods
+func (p *Postgres) listen(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, p.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Exec(ctx, "LISTEN "+postgresChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// receive forwards notifications to out and reconnects when the connection is lost.
+func (p *Postgres) receive(ctx context.Context, conn *pgx.Conn, out chan<- Envelope) {
+	defer close(out)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			conn.Close(context.Background())
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			slog.Warn("Backplane connection lost, reconnecting", "error", err)
+			conn = p.reconnect(ctx)
+			if conn == nil {
+				return
+			}
+			continue
+		}
+
+		env, err := p.decode(ctx, notification.Payload)
+		if err != nil {
+			slog.Error("Failed to decode backplane event", "error", err)
+			continue
+		}
+
+		select {
+		case out <- env:
+		case <-ctx.Done():
+			conn.Close(context.Background())
+			return
+		}
+	}
+}
+
+// reconnect retries with backoff until it is listening again. Returns nil once ctx is done.
+func (p *Postgres) reconnect(ctx context.Context) *pgx.Conn {
+	delay := time.Second
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+
+		conn, err := p.listen(ctx)
+		if err == nil {
+			slog.Info("Backplane reconnected")
+			return conn
+		}
+
+		slog.Warn("Backplane reconnect failed", "error", err, "retry_delay", delay)
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (p *Postgres) decode(ctx context.Context, payload string) (Envelope, error) {
+	data := []byte(payload)
+
+	if ref, ok := strings.CutPrefix(payload, payloadRefPrefix); ok {
+		id, err := strconv.ParseUint(ref, 10, 64)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("invalid payload reference %q: %w", ref, err)
+		}
+
+		var row models.BackplanePayload
+		if err := p.db.WithContext(ctx).First(&row, id).Error; err != nil {
+			return Envelope{}, err
+		}
+		data = row.Payload
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, err
+	}
+
+	return env, nil
+}
@@ -12,9 +12,14 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+// DatabaseDSN returns the connection string of the configured database.
+func DatabaseDSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		AppConfig.DBHost, AppConfig.DBUser, AppConfig.DBPassword, AppConfig.DBName, AppConfig.DBPort)
+}
+
+func ConnectDatabase() {
+	dsn := DatabaseDSN()
 
 	maxRetries := 10
 	retryDelay := 2 * time.Second
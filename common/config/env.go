@@ -19,15 +19,43 @@ type Config struct {
 	DBPassword string `env:"DB_PASSWORD,required"`
 	DBName     string `env:"DB_NAME,required"`
 
-	// JWT Secret
-	JWTSecret string `env:"JWT_SECRET,required"`
+	// JWT signing keys. JWT_SECRET is a single HS256 key, JWT_KEYS a comma separated
+	// list of "kid:ALG:value" keys (HS256 secret, or EdDSA/RS256 PEM file path).
+	// Tokens are signed with JWT_ACTIVE_KEY_ID, every other key is only used for verification,
+	// see the jwtkeys package. At least one key is required.
+	JWTSecret      string `env:"JWT_SECRET"`
+	JWTKeys        string `env:"JWT_KEYS"`
+	JWTActiveKeyID string `env:"JWT_ACTIVE_KEY_ID"`
 
-	// JWT Token Expiration (in hours, default 30 days = 720 hours)
-	JWTExpirationHours int `env:"JWT_EXPIRATION_HOURS" envDefault:"720"`
+	// Access tokens are short-lived JWTs (in minutes), the app renews them with
+	// a rotating refresh token that is valid for RefreshTokenExpirationDays.
+	AccessTokenExpirationMinutes int `env:"ACCESS_TOKEN_EXPIRATION_MINUTES" envDefault:"15"`
+	RefreshTokenExpirationDays   int `env:"REFRESH_TOKEN_EXPIRATION_DAYS" envDefault:"30"`
+
+	// Comma separated IPs or CIDR ranges of the reverse proxies in front of the API.
+	// X-Forwarded-For is only read on requests from these, others use the remote address.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
 
 	// Cron Settings
 	EnableCron bool `env:"ENABLE_CRON" envDefault:"true"`
 
+	// Soft delete lifecycle (in days). Owners can restore within the restore window,
+	// the cron job hard-deletes after the retention period.
+	SoftDeleteRestoreDays   int `env:"SOFT_DELETE_RESTORE_DAYS" envDefault:"14"`
+	SoftDeleteRetentionDays int `env:"SOFT_DELETE_RETENTION_DAYS" envDefault:"30"`
+
+	// Deleted accounts can be restored by logging in within the grace period (in days),
+	// afterwards the cron job anonymizes them.
+	AccountDeletionGraceDays int `env:"ACCOUNT_DELETION_GRACE_DAYS" envDefault:"30"`
+
+	// How long a GDPR data export can be downloaded (in hours)
+	DataExportExpirationHours int `env:"DATA_EXPORT_EXPIRATION_HOURS" envDefault:"48"`
+
+	// Chat attachments are stored in this directory, shared by the API and chat service.
+	// Uploads larger than AttachmentMaxSizeMB are rejected.
+	AttachmentStorageDir string `env:"ATTACHMENT_STORAGE_DIR" envDefault:"./data/attachments"`
+	AttachmentMaxSizeMB  int    `env:"ATTACHMENT_MAX_SIZE_MB" envDefault:"10"`
+
 	// Postmark API Key
 	PostmarkAPIKey string `env:"POSTMARK_API_KEY,required"`
 
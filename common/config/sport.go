@@ -12,8 +12,9 @@ func SeedSports() error {
 
 	for _, sportName := range allowedSports {
 		var sport models.Sport
-		// FirstOrCreate to avoid duplicates
-		err := DB.Where("name = ?", sportName).
+		// FirstOrCreate to avoid duplicates, sports retired by an admin stay retired
+		err := DB.Unscoped().
+			Where("name = ?", sportName).
 			FirstOrCreate(&sport, models.Sport{Name: sportName}).
 			Error
 
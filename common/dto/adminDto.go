@@ -0,0 +1,93 @@
+package dto
+
+import (
+	"encoding/json"
+	"server/common/models"
+	"time"
+)
+
+// Request DTOs
+
+type UpdateUserRoleDto struct {
+	Role string `json:"role" validate:"sanitize,required,oneof=user moderator admin"`
+}
+
+type SuspendUserDto struct {
+	Until  time.Time `json:"until"  validate:"required"`
+	Reason string    `json:"reason" validate:"sanitize,required,min=3,max=500"`
+}
+
+type BanUserDto struct {
+	Reason string `json:"reason" validate:"sanitize,required,min=3,max=500"`
+}
+
+// Response DTOs
+
+// AdminUserResponseDto shows account details regardless of the user's privacy settings.
+type AdminUserResponseDto struct {
+	ID               uint            `json:"id"`
+	Email            string          `json:"email"`
+	FirstName        string          `json:"first_name"`
+	LastName         string          `json:"last_name"`
+	Role             models.UserRole `json:"role"`
+	AuthProvider     string          `json:"auth_provider,omitempty"`
+	EmailVerified    bool            `json:"email_verified"`
+	TwoFactorEnabled bool            `json:"two_factor_enabled"`
+	SuspendedUntil   *time.Time      `json:"suspended_until,omitempty"`
+	SuspensionReason string          `json:"suspension_reason,omitempty"`
+	BannedAt         *time.Time      `json:"banned_at,omitempty"`
+	BanReason        string          `json:"ban_reason,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+	DeletedAt        *time.Time      `json:"deleted_at,omitempty"`
+	AnonymizedAt     *time.Time      `json:"anonymized_at,omitempty"`
+}
+
+type AdminAuditLogResponseDto struct {
+	ID         uint                  `json:"id"`
+	Admin      PublicUserDtoResponse `json:"admin"`
+	Action     models.AdminAction    `json:"action"`
+	TargetType string                `json:"target_type"`
+	TargetID   uint                  `json:"target_id"`
+	Details    json.RawMessage       `json:"details"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+func ToAdminUserResponseDto(user models.User) AdminUserResponseDto {
+	response := AdminUserResponseDto{
+		ID:               user.ID,
+		Email:            user.Email,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		Role:             user.Role,
+		AuthProvider:     user.AuthProvider,
+		EmailVerified:    user.EmailVerified,
+		TwoFactorEnabled: user.TOTPEnabledAt != nil,
+		BannedAt:         user.BannedAt,
+		BanReason:        user.BanReason,
+		CreatedAt:        user.CreatedAt,
+		AnonymizedAt:     user.AnonymizedAt,
+	}
+
+	if user.IsSuspended(time.Now()) {
+		response.SuspendedUntil = user.SuspendedUntil
+		response.SuspensionReason = user.SuspensionReason
+	}
+
+	if user.DeletedAt.Valid {
+		response.DeletedAt = &user.DeletedAt.Time
+	}
+
+	return response
+}
+
+func ToAdminAuditLogResponseDto(log models.AdminAuditLog) AdminAuditLogResponseDto {
+	return AdminAuditLogResponseDto{
+		ID:         log.ID,
+		Admin:      ToPublicUserDtoResponse(log.Admin),
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		Details:    json.RawMessage(log.Details),
+		CreatedAt:  log.CreatedAt,
+	}
+}
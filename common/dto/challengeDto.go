@@ -18,8 +18,8 @@ func tagsToJSON(tags []string) datatypes.JSON {
 	return b
 }
 
-// tagsFromJSON unmarshals tags from JSONB
-func tagsFromJSON(data datatypes.JSON) []string {
+// StringsFromJSON unmarshals a list of strings from JSONB, e.g. tags or preferred days
+func StringsFromJSON(data datatypes.JSON) []string {
 	var tags []string
 	if len(data) > 0 {
 		_ = json.Unmarshal(data, &tags)
@@ -191,7 +191,7 @@ func ToChallengeResponseDto(t models.Challenge) ChallengeResponseDto {
 		TeamSize:     t.TeamSize,
 		Distance:     t.Distance,
 		Participants: t.Participants,
-		Tags:         tagsFromJSON(t.Tags),
+		Tags:         StringsFromJSON(t.Tags),
 		Date:         t.Date,
 		StartTime:    t.StartTime,
 		EndTime:      endTime,
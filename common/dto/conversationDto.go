@@ -16,10 +16,43 @@ type CreateGroupConversationDto struct {
 	ParticipantIDs []uint `json:"participant_ids" validate:"required,min=1,dive,min=1"`
 }
 
+type RenameGroupConversationDto struct {
+	Title string `json:"title" validate:"sanitize,required,min=1,max=255"`
+}
+
+type AddGroupParticipantsDto struct {
+	ParticipantIDs []uint `json:"participant_ids" validate:"required,min=1,max=50,dive,min=1"`
+}
+
+// Content may be empty when the message has attachments
 type SendMessageDto struct {
+	Content          string `json:"content" validate:"sanitize,required_without=AttachmentIDs,max=2000"`
+	ReplyToMessageID *uint  `json:"reply_to_message_id,omitempty" validate:"omitempty,min=1"`
+	AttachmentIDs    []uint `json:"attachment_ids,omitempty" validate:"omitempty,max=10,dive,min=1"`
+}
+
+// Muted without MutedUntil mutes the conversation until it is unmuted
+type UpdateConversationNotificationsDto struct {
+	Muted        bool       `json:"muted"`
+	MutedUntil   *time.Time `json:"muted_until,omitempty" validate:"omitempty,gt"`
+	MentionsOnly bool       `json:"mentions_only"`
+}
+
+// ConversationNotificationsResponseDto are the current user's push notification settings for a conversation.
+type ConversationNotificationsResponseDto struct {
+	Muted        bool       `json:"muted"`
+	MutedUntil   *time.Time `json:"muted_until,omitempty"`
+	MentionsOnly bool       `json:"mentions_only"`
+}
+
+type EditMessageDto struct {
 	Content string `json:"content" validate:"sanitize,required,min=1,max=2000"`
 }
 
+type ToggleReactionDto struct {
+	Emoji string `json:"emoji" validate:"sanitize,required,max=32,single-emoji"`
+}
+
 type MarkReadDto struct {
 	ReadAt *time.Time `json:"read_at,omitempty"`
 }
@@ -31,11 +64,13 @@ type SyncTeamMembersDto struct {
 // --- Response DTOs ---
 
 type ConversationParticipantDto struct {
-	UserID     uint                  `json:"user_id"`
-	User       PublicUserDtoResponse `json:"user"`
-	JoinedAt   time.Time             `json:"joined_at"`
-	LastReadAt *time.Time            `json:"last_read_at,omitempty"`
-	LeftAt     *time.Time            `json:"left_at,omitempty"`
+	UserID          uint                  `json:"user_id"`
+	User            PublicUserDtoResponse `json:"user"`
+	JoinedAt        time.Time             `json:"joined_at"`
+	LastReadAt      *time.Time            `json:"last_read_at,omitempty"`
+	LastDeliveredAt *time.Time            `json:"last_delivered_at,omitempty"`
+	LeftAt          *time.Time            `json:"left_at,omitempty"`
+	IsAdmin         bool                  `json:"is_admin"`
 }
 
 type ConversationResponseDto struct {
@@ -59,6 +94,8 @@ type ConversationListItemDto struct {
 	UnreadCount      int64                   `json:"unread_count"`
 	LastMessage      *MessageResponseDto     `json:"last_message,omitempty"`
 	UpdatedAt        time.Time               `json:"updated_at"`
+
+	Notifications ConversationNotificationsResponseDto `json:"notifications"`
 }
 
 type MessagesPaginationDto struct {
@@ -71,11 +108,13 @@ type MessagesPaginationDto struct {
 
 func ToConversationParticipantDto(p models.ConversationParticipant) ConversationParticipantDto {
 	return ConversationParticipantDto{
-		UserID:     p.UserID,
-		User:       ToPublicUserDtoResponse(p.User),
-		JoinedAt:   p.JoinedAt,
-		LastReadAt: p.LastReadAt,
-		LeftAt:     p.LeftAt,
+		UserID:          p.UserID,
+		User:            ToPublicUserDtoResponse(userOrDeleted(p.User, p.UserID)),
+		JoinedAt:        p.JoinedAt,
+		LastReadAt:      p.LastReadAt,
+		LastDeliveredAt: p.LastDeliveredAt,
+		LeftAt:          p.LeftAt,
+		IsAdmin:         p.IsAdmin,
 	}
 }
 
@@ -118,7 +157,7 @@ func ToConversationListItemDto(c models.Conversation, unreadCount int64, lastMsg
 	if c.Type == models.ConversationTypeDirect && len(c.Participants) > 0 {
 		for _, p := range c.Participants {
 			if p.UserID != currentUserID {
-				otherUser := ToPublicUserDtoResponse(p.User)
+				otherUser := ToPublicUserDtoResponse(userOrDeleted(p.User, p.UserID))
 				dto.OtherUser = &otherUser
 				break
 			}
@@ -136,5 +175,26 @@ func ToConversationListItemDto(c models.Conversation, unreadCount int64, lastMsg
 		dto.LastMessage = &msgDto
 	}
 
+	for _, p := range c.Participants {
+		if p.UserID == currentUserID {
+			dto.Notifications = ToConversationNotificationsResponseDto(p)
+			break
+		}
+	}
+
 	return dto
 }
+
+// ToConversationNotificationsResponseDto maps the participant's settings, a mute that ran out counts as unmuted.
+func ToConversationNotificationsResponseDto(p models.ConversationParticipant) ConversationNotificationsResponseDto {
+	res := ConversationNotificationsResponseDto{
+		MentionsOnly: p.MentionsOnly,
+	}
+
+	if p.IsMuted(time.Now()) {
+		res.Muted = true
+		res.MutedUntil = p.MutedUntil
+	}
+
+	return res
+}
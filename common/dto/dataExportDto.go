@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"server/common/models"
+	"time"
+)
+
+type DataExportResponseDto struct {
+	ID          uint                    `json:"id"`
+	Status      models.DataExportStatus `json:"status"`
+	Token       *string                 `json:"token,omitempty"` // Only set when the export is ready
+	ExpiresAt   *time.Time              `json:"expires_at,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	CompletedAt *time.Time              `json:"completed_at,omitempty"`
+}
+
+// The export DTOs below only contain IDs for other users, so the export doesn't leak their data.
+
+type InvitationExportResponseDto struct {
+	ID           uint                    `json:"id"`
+	InviterID    uint                    `json:"inviter_id"`
+	InviteeID    uint                    `json:"invitee_id"`
+	Note         string                  `json:"note,omitempty"`
+	ResourceType models.ResourceType     `json:"resource_type"`
+	ResourceID   uint                    `json:"resource_id"`
+	Status       models.InvitationStatus `json:"status"`
+	CreatedAt    time.Time               `json:"created_at"`
+}
+
+type MessageExportResponseDto struct {
+	ID             uint      `json:"id"`
+	ConversationID *uint     `json:"conversation_id,omitempty"`
+	TeamID         *uint     `json:"team_id,omitempty"`
+	RecipientID    *uint     `json:"recipient_id,omitempty"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ReportExportResponseDto struct {
+	ID         uint                    `json:"id"`
+	TargetID   uint                    `json:"target_id"`
+	TargetType models.ReportTargetType `json:"target_type"`
+	Reason     string                  `json:"reason"`
+	Comment    string                  `json:"comment,omitempty"`
+	Status     string                  `json:"status"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+type EulaAcceptanceExportResponseDto struct {
+	EulaVersionID uint      `json:"eula_version_id"`
+	AcceptedAt    time.Time `json:"accepted_at"`
+}
+
+func ToDataExportResponseDto(e models.DataExport) DataExportResponseDto {
+	return DataExportResponseDto{
+		ID:          e.ID,
+		Status:      e.Status,
+		Token:       e.Token,
+		ExpiresAt:   e.ExpiresAt,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+	}
+}
+
+func ToInvitationExportResponseDto(inv models.Invitation) InvitationExportResponseDto {
+	return InvitationExportResponseDto{
+		ID:           inv.ID,
+		InviterID:    inv.InviterId,
+		InviteeID:    inv.InviteeId,
+		Note:         inv.Note,
+		ResourceType: inv.ResourceType,
+		ResourceID:   inv.ResourceID,
+		Status:       inv.Status,
+		CreatedAt:    inv.CreatedAt,
+	}
+}
+
+func ToMessageExportResponseDto(msg models.Message) MessageExportResponseDto {
+	return MessageExportResponseDto{
+		ID:             msg.ID,
+		ConversationID: msg.ConversationID,
+		TeamID:         msg.TeamID,
+		RecipientID:    msg.RecipientID,
+		Content:        msg.Content,
+		CreatedAt:      msg.CreatedAt,
+	}
+}
+
+func ToReportExportResponseDto(r models.Report) ReportExportResponseDto {
+	return ReportExportResponseDto{
+		ID:         r.ID,
+		TargetID:   r.TargetID,
+		TargetType: r.TargetType,
+		Reason:     r.Reason,
+		Comment:    r.Comment,
+		Status:     r.Status,
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+func ToEulaAcceptanceExportResponseDto(a models.EulaAcceptance) EulaAcceptanceExportResponseDto {
+	return EulaAcceptanceExportResponseDto{
+		EulaVersionID: a.EulaVersionID,
+		AcceptedAt:    a.AcceptedAt,
+	}
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "time"
+import (
+	"server/common/models"
+	"time"
+)
 
 // Request DTOs
 
@@ -8,6 +11,13 @@ type EulaAcceptDto struct {
 	EulaVersionID uint `json:"eula_version_id" validate:"required"`
 }
 
+type EulaVersionCreateDto struct {
+	Version  string `json:"version"  validate:"sanitize,required,max=50"`
+	Locale   string `json:"locale"   validate:"sanitize,required,max=10"`
+	Content  string `json:"content"  validate:"sanitize,required"`
+	Activate bool   `json:"activate"`
+}
+
 // Response DTOs
 
 type EulaVersionDto struct {
@@ -25,3 +35,25 @@ type EulaStatusDto struct {
 	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
 	RequiresAction bool       `json:"requires_action"` // true if user needs to accept
 }
+
+type EulaVersionResponseDto struct {
+	ID          uint      `json:"id"`
+	Version     string    `json:"version"`
+	Locale      string    `json:"locale"`
+	Content     string    `json:"content"`
+	ContentHash string    `json:"content_hash"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ToEulaVersionResponseDto(version models.EulaVersion) EulaVersionResponseDto {
+	return EulaVersionResponseDto{
+		ID:          version.ID,
+		Version:     version.Version,
+		Locale:      version.Locale,
+		Content:     version.Content,
+		ContentHash: version.ContentHash,
+		IsActive:    version.IsActive,
+		CreatedAt:   version.CreatedAt,
+	}
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"fmt"
 	"server/common/models"
 	"time"
 )
@@ -12,8 +13,24 @@ type IncomingMessage struct {
 	TeamID         *uint `json:"team_id,omitempty"`      // Legacy team messaging
 	RecipientID    *uint `json:"recipient_id,omitempty"` // Legacy direct messaging
 
-	// Only used for Type == "message"
+	// Only used for Type == "message" and "edit_message"
 	Content string `json:"content" validate:"sanitize"`
+
+	// Only used for Type == "message", quotes a message of the same conversation
+	ReplyToMessageID *uint `json:"reply_to_message_id,omitempty"`
+
+	// Only used for Type == "message", files uploaded to the conversation beforehand
+	AttachmentIDs []uint `json:"attachment_ids,omitempty"`
+
+	// Only used for Type == "edit_message", "delete_message" and "reaction"
+	MessageID *uint `json:"message_id,omitempty"`
+
+	// Only used for Type == "reaction", toggles the current user's reaction
+	Emoji string `json:"emoji,omitempty" validate:"sanitize"`
+
+	// Only used for Type == "resume", replays the messages after this message or time
+	SinceMessageID *uint      `json:"since_message_id,omitempty"`
+	Since          *time.Time `json:"since,omitempty"`
 }
 
 type MessageResponseDto struct {
@@ -25,17 +42,268 @@ type MessageResponseDto struct {
 	RecipientID    *uint           `json:"recipient_id,omitempty"`
 	Content        string          `json:"content"`
 	CreatedAt      time.Time       `json:"created_at"`
+	EditedAt       *time.Time      `json:"edited_at,omitempty"`
+	DeletedAt      *time.Time      `json:"deleted_at,omitempty"` // Tombstone, Content is empty
+
+	// Only set on messages the server posted about a change to the group
+	SystemEvent  *models.SystemEvent `json:"system_event,omitempty"`
+	TargetUserID *uint               `json:"target_user_id,omitempty"`
+
+	Reactions []ReactionCountResponseDto `json:"reactions,omitempty"`
+
+	// ReplyTo is nil while ReplyToMessageID is set when the parent's sender is blocked
+	ReplyToMessageID *uint                      `json:"reply_to_message_id,omitempty"`
+	ReplyTo          *MessagePreviewResponseDto `json:"reply_to,omitempty"`
+
+	Attachments []AttachmentResponseDto `json:"attachments,omitempty"`
+
+	// IDs of the participants mentioned in Content
+	Mentions []uint `json:"mentions,omitempty"`
+
+	// Only set in direct and group conversations, see ApplyMessageReceipts
+	SeenBy []uint        `json:"seen_by,omitempty"`
+	Status MessageStatus `json:"status,omitempty"` // Only set on the current user's own messages
+}
+
+// MessageStatus drives the ticks on the sender's own messages.
+type MessageStatus string
+
+const (
+	MessageStatusSent      MessageStatus = "sent"
+	MessageStatusDelivered MessageStatus = "delivered" // Reached a device of every other participant
+	MessageStatusRead      MessageStatus = "read"      // Read by every other participant
+)
+
+// ReceiptResponseDto tells how far a participant has received and read a conversation.
+type ReceiptResponseDto struct {
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+	LastReadAt      *time.Time `json:"last_read_at,omitempty"`
+}
+
+// AttachmentResponseDto describes an uploaded file, the URLs are relative to the chat service.
+type AttachmentResponseDto struct {
+	ID           uint   `json:"id"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// MessagePreviewResponseDto is the quoted parent of a reply.
+type MessagePreviewResponseDto struct {
+	ID        uint            `json:"id"`
+	SenderID  uint            `json:"sender_id"`
+	Sender    UserResponseDto `json:"sender"`
+	Content   string          `json:"content"` // Shortened to maxPreviewLength characters
+	CreatedAt time.Time       `json:"created_at"`
+	DeletedAt *time.Time      `json:"deleted_at,omitempty"` // Tombstone, Content is empty
+}
+
+// MessageThreadResponseDto is a message with all replies below it, oldest first.
+type MessageThreadResponseDto struct {
+	Message MessageResponseDto   `json:"message"`
+	Replies []MessageResponseDto `json:"replies"`
+}
+
+// ReactionCountResponseDto is the number of users who reacted with an emoji.
+type ReactionCountResponseDto struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
 }
 
+// ReactionResponseDto describes the reaction that was toggled in a "reaction" event.
+type ReactionResponseDto struct {
+	MessageID uint   `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	Added     bool   `json:"added"` // False when the reaction was removed
+}
+
+type MessageEditResponseDto struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// ToMessageResponseDto converts a message without the current user's reactions,
+// e.g. for events that are broadcast to every member.
 func ToMessageResponseDto(msg models.Message) MessageResponseDto {
+	return ToMessageResponseDtoForUser(msg, 0)
+}
+
+// ToMessageResponseDtoForUser converts a message and marks the reactions of userID.
+func ToMessageResponseDtoForUser(msg models.Message, userID uint) MessageResponseDto {
 	return MessageResponseDto{
-		ID:             msg.ID,
-		ConversationID: msg.ConversationID,
-		SenderID:       msg.SenderID,
-		Sender:         ToUserResponseDto(msg.Sender),
-		TeamID:         msg.TeamID,
-		RecipientID:    msg.RecipientID,
-		Content:        msg.Content,
-		CreatedAt:      msg.CreatedAt,
+		ID:               msg.ID,
+		ConversationID:   msg.ConversationID,
+		SenderID:         msg.SenderID,
+		Sender:           ToUserResponseDto(userOrDeleted(msg.Sender, msg.SenderID)),
+		TeamID:           msg.TeamID,
+		RecipientID:      msg.RecipientID,
+		Content:          msg.Content,
+		CreatedAt:        msg.CreatedAt,
+		EditedAt:         msg.EditedAt,
+		DeletedAt:        msg.DeletedAt,
+		SystemEvent:      msg.SystemEvent,
+		TargetUserID:     msg.TargetUserID,
+		Reactions:        toReactionCounts(msg.Reactions, userID),
+		ReplyToMessageID: msg.ReplyToMessageID,
+		ReplyTo:          toMessagePreview(msg.ReplyTo),
+		Attachments:      toAttachmentResponseDtos(msg.Attachments),
+		Mentions:         toMentionIDs(msg.Mentions),
+	}
+}
+
+func ToAttachmentResponseDto(a models.MessageAttachment) AttachmentResponseDto {
+	url := fmt.Sprintf("/api/conversations/%d/attachments/%d", a.ConversationID, a.ID)
+
+	res := AttachmentResponseDto{
+		ID:          a.ID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Width:       a.Width,
+		Height:      a.Height,
+		URL:         url,
+	}
+
+	if a.IsImage() {
+		res.ThumbnailURL = url + "/thumbnail"
+	}
+
+	return res
+}
+
+// ApplyMessageReceipts fills SeenBy and, for userID's own messages, Status.
+// participants are the other participants of the conversation the user may see.
+func ApplyMessageReceipts(msg *MessageResponseDto, participants []models.ConversationParticipant, userID uint) {
+	if msg.DeletedAt != nil {
+		return
+	}
+
+	recipients, delivered, read := 0, 0, 0
+	for _, p := range participants {
+		if p.UserID == msg.SenderID {
+			continue
+		}
+		recipients++
+
+		hasRead := p.LastReadAt != nil && !p.LastReadAt.Before(msg.CreatedAt)
+		if hasRead {
+			msg.SeenBy = append(msg.SeenBy, p.UserID)
+			read++
+		}
+
+		if hasRead || (p.LastDeliveredAt != nil && !p.LastDeliveredAt.Before(msg.CreatedAt)) {
+			delivered++
+		}
+	}
+
+	if msg.SenderID != userID {
+		return
+	}
+
+	switch {
+	case recipients > 0 && read == recipients:
+		msg.Status = MessageStatusRead
+	case recipients > 0 && delivered == recipients:
+		msg.Status = MessageStatusDelivered
+	default:
+		msg.Status = MessageStatusSent
+	}
+}
+
+func ToMessageThreadResponseDto(msg models.Message, replies []models.Message, userID uint) MessageThreadResponseDto {
+	replyDtos := make([]MessageResponseDto, len(replies))
+	for i, reply := range replies {
+		replyDtos[i] = ToMessageResponseDtoForUser(reply, userID)
+	}
+
+	return MessageThreadResponseDto{
+		Message: ToMessageResponseDtoForUser(msg, userID),
+		Replies: replyDtos,
+	}
+}
+
+func ToMessageEditResponseDto(edit models.MessageEdit) MessageEditResponseDto {
+	return MessageEditResponseDto{
+		Content:  edit.Content,
+		EditedAt: edit.CreatedAt,
+	}
+}
+
+// toReactionCounts aggregates the reactions per emoji, in the order they were first used.
+func toReactionCounts(reactions []models.MessageReaction, userID uint) []ReactionCountResponseDto {
+	if len(reactions) == 0 {
+		return nil
+	}
+
+	counts := []ReactionCountResponseDto{}
+	index := map[string]int{}
+
+	for _, r := range reactions {
+		i, ok := index[r.Emoji]
+		if !ok {
+			i = len(counts)
+			index[r.Emoji] = i
+			counts = append(counts, ReactionCountResponseDto{Emoji: r.Emoji})
+		}
+
+		counts[i].Count++
+		if userID != 0 && r.UserID == userID {
+			counts[i].ReactedByMe = true
+		}
 	}
+
+	return counts
+}
+
+const maxPreviewLength = 200
+
+func toMessagePreview(msg *models.Message) *MessagePreviewResponseDto {
+	if msg == nil {
+		return nil
+	}
+
+	content := msg.Content
+	if runes := []rune(content); len(runes) > maxPreviewLength {
+		content = string(runes[:maxPreviewLength-3]) + "..."
+	}
+
+	return &MessagePreviewResponseDto{
+		ID:        msg.ID,
+		SenderID:  msg.SenderID,
+		Sender:    ToUserResponseDto(userOrDeleted(msg.Sender, msg.SenderID)),
+		Content:   content,
+		CreatedAt: msg.CreatedAt,
+		DeletedAt: msg.DeletedAt,
+	}
+}
+
+func toMentionIDs(mentions []models.MessageMention) []uint {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(mentions))
+	for i, m := range mentions {
+		ids[i] = m.UserID
+	}
+
+	return ids
+}
+
+func toAttachmentResponseDtos(attachments []models.MessageAttachment) []AttachmentResponseDto {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	res := make([]AttachmentResponseDto, len(attachments))
+	for i, a := range attachments {
+		res[i] = ToAttachmentResponseDto(a)
+	}
+
+	return res
 }
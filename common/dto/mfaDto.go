@@ -0,0 +1,23 @@
+package dto
+
+type MFACodeDto struct {
+	Code string `json:"code" validate:"sanitize,required,max=16"` // TOTP or recovery code
+}
+
+type MFAEnrollDto struct {
+	Password string `json:"password" validate:"sanitize"` // Not required for OAuth users
+}
+
+type MFAVerifyDto struct {
+	MFAToken string `json:"mfa_token" validate:"sanitize,required"`
+	Code     string `json:"code"      validate:"sanitize,required,max=16"`
+}
+
+type MFAEnrollmentResponseDto struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponseDto struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"server/common/models"
+	"time"
+)
+
+// PresenceResponseDto tells whether a user is connected to the chat.
+// LastSeenAt is only set for users who went offline.
+type PresenceResponseDto struct {
+	UserID     uint                  `json:"user_id"`
+	Status     models.PresenceStatus `json:"status"`
+	LastSeenAt *time.Time            `json:"last_seen_at,omitempty"`
+}
+
+func ToPresenceResponseDto(p models.UserPresence) PresenceResponseDto {
+	res := PresenceResponseDto{
+		UserID: p.UserID,
+		Status: p.Status,
+	}
+
+	if p.Status == models.PresenceOffline {
+		res.LastSeenAt = p.LastSeenAt
+	}
+
+	return res
+}
@@ -8,6 +8,32 @@ const (
 	RealtimeEventMessage     RealtimeEventType = "message"
 	RealtimeEventTypingStart RealtimeEventType = "typing_start"
 	RealtimeEventTypingStop  RealtimeEventType = "typing_stop"
+
+	// Carry the updated message, a tombstone for deleted messages
+	RealtimeEventMessageEdited  RealtimeEventType = "message_edited"
+	RealtimeEventMessageDeleted RealtimeEventType = "message_deleted"
+
+	// Carries the toggled reaction and the message with the updated counts
+	RealtimeEventReaction RealtimeEventType = "reaction"
+
+	// A participant received or read the conversation up to the time in Receipt
+	RealtimeEventDelivered RealtimeEventType = "delivered"
+	RealtimeEventRead      RealtimeEventType = "read"
+
+	// A group was renamed or its participants or admins changed, carries the updated conversation
+	RealtimeEventConversationUpdated RealtimeEventType = "conversation_updated"
+
+	// A friend or chat partner went online, away or offline
+	RealtimeEventPresence RealtimeEventType = "presence"
+
+	// Sent after the missed messages have been replayed, live events follow
+	RealtimeEventResumeComplete RealtimeEventType = "resume_complete"
+
+	// Published by the API
+	RealtimeEventNotification      RealtimeEventType = "notification"
+	RealtimeEventMembershipChanged RealtimeEventType = "membership_changed"
+	RealtimeEventAccountRestricted RealtimeEventType = "account_restricted" // The hub disconnects the user after sending it
+	RealtimeEventSessionRevoked    RealtimeEventType = "session_revoked"    // Only sent to the revoked sessions, which the hub then disconnects
 )
 
 // RealtimeEventDto is the payload sent over the WebSocket.
@@ -26,6 +52,33 @@ type RealtimeEventDto struct {
 	// When the event happened (server time)
 	Timestamp time.Time `json:"timestamp"`
 
-	// Only set when Type == "message"
+	// Only set when Type == "message", "message_edited", "message_deleted" or "reaction"
 	Message *MessageResponseDto `json:"message,omitempty"`
+
+	// Only set when Type == "reaction"
+	Reaction *ReactionResponseDto `json:"reaction,omitempty"`
+
+	// Only set when Type == "delivered" or "read"
+	Receipt *ReceiptResponseDto `json:"receipt,omitempty"`
+
+	// Only set when Type == "conversation_updated"
+	Conversation *ConversationResponseDto `json:"conversation,omitempty"`
+
+	// Only set when Type == "presence"
+	Presence *PresenceResponseDto `json:"presence,omitempty"`
+
+	// Only set when Type == "notification"
+	Notification *NotificationResponseDto `json:"notification,omitempty"`
+
+	// Only set when Type == "membership_changed"
+	Membership *MembershipChangeDto `json:"membership,omitempty"`
+
+	// Only set when Type == "session_revoked" and a single session was revoked, otherwise all of the user's sessions were
+	SessionID *uint `json:"session_id,omitempty"`
+}
+
+// MembershipChangeDto lists the users who joined or left a conversation or team.
+type MembershipChangeDto struct {
+	Added   []uint `json:"added,omitempty"`
+	Removed []uint `json:"removed,omitempty"`
 }
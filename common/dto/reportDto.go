@@ -1,8 +1,83 @@
 package dto
 
+import (
+	"server/common/models"
+	"strings"
+	"time"
+)
+
 type ReportCreateDto struct {
 	TargetID   uint   `json:"target_id"   validate:"required"`
 	TargetType string `json:"target_type" validate:"sanitize,required,oneof=USER TEAM CHALLENGE MESSAGE"`
 	Reason     string `json:"reason"      validate:"sanitize,required,min=3"`
 	Comment    string `json:"comment"     validate:"sanitize"`
 }
+
+type ResolveReportsDto struct {
+	Note string `json:"note" validate:"sanitize,max=1000"` // Internal, not shown to reporters
+}
+
+type ReportResponseDto struct {
+	ID             uint                    `json:"id"`
+	Reporter       PublicUserDtoResponse   `json:"reporter"`
+	TargetID       uint                    `json:"target_id"`
+	TargetType     models.ReportTargetType `json:"target_type"`
+	TargetSnapshot *string                 `json:"target_snapshot,omitempty"`
+	TargetOwnerID  *uint                   `json:"target_owner_id,omitempty"`
+	Reason         string                  `json:"reason"`
+	Severity       int                     `json:"severity"`
+	Comment        string                  `json:"comment,omitempty"`
+	Status         string                  `json:"status"`
+	CreatedAt      time.Time               `json:"created_at"`
+	ResolvedBy     *PublicUserDtoResponse  `json:"resolved_by,omitempty"`
+	ResolutionNote string                  `json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time              `json:"resolved_at,omitempty"`
+}
+
+// ReportGroupResponseDto is an entry in the moderation queue
+type ReportGroupResponseDto struct {
+	TargetType      models.ReportTargetType `json:"target_type"`
+	TargetID        uint                    `json:"target_id"`
+	ReportCount     int64                   `json:"report_count"`
+	Severity        int                     `json:"severity"`
+	Reasons         []string                `json:"reasons"`
+	FirstReportedAt time.Time               `json:"first_reported_at"`
+	LastReportedAt  time.Time               `json:"last_reported_at"`
+}
+
+func ToReportResponseDto(report models.Report) ReportResponseDto {
+	response := ReportResponseDto{
+		ID:             report.ID,
+		Reporter:       ToPublicUserDtoResponse(report.Reporter),
+		TargetID:       report.TargetID,
+		TargetType:     report.TargetType,
+		TargetSnapshot: report.TargetSnapshot,
+		TargetOwnerID:  report.TargetOwnerID,
+		Reason:         report.Reason,
+		Severity:       report.Severity,
+		Comment:        report.Comment,
+		Status:         report.Status,
+		CreatedAt:      report.CreatedAt,
+		ResolutionNote: report.ResolutionNote,
+		ResolvedAt:     report.ResolvedAt,
+	}
+
+	if report.ResolvedBy != nil {
+		resolvedBy := ToPublicUserDtoResponse(*report.ResolvedBy)
+		response.ResolvedBy = &resolvedBy
+	}
+
+	return response
+}
+
+func ToReportGroupResponseDto(group models.ReportGroup) ReportGroupResponseDto {
+	return ReportGroupResponseDto{
+		TargetType:      group.TargetType,
+		TargetID:        group.TargetID,
+		ReportCount:     group.ReportCount,
+		Severity:        group.Severity,
+		Reasons:         strings.Split(group.Reasons, ","),
+		FirstReportedAt: group.FirstReportedAt,
+		LastReportedAt:  group.LastReportedAt,
+	}
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"server/common/models"
+	"time"
+)
+
+type RefreshTokenDto struct {
+	RefreshToken string `json:"refresh_token" validate:"sanitize,required"`
+}
+
+type SessionResponseDto struct {
+	ID         uint      `json:"id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	Current    bool      `json:"current"` // The session making the request
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func ToSessionResponseDto(session models.UserSession, currentSessionID uint) SessionResponseDto {
+	return SessionResponseDto{
+		ID:         session.ID,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		Current:    session.ID == currentSessionID,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"server/common/models"
 )
 
+type SportCreateDto struct {
+	Name string `json:"name" validate:"sanitize,required,min=2,max=50"`
+}
+
 type SportDto struct {
 	ID   uint   `json:"id"`
 	Name string `json:"name"`
@@ -73,7 +73,7 @@ func ToTeamResponseDto(t models.Team) TeamResponseDto {
 	var users []TeamMemberResponseDto
 	for _, u := range t.Users {
 		users = append(users, TeamMemberResponseDto{
-			User: ToPublicUserDtoResponse(u.User),
+			User: ToPublicUserDtoResponse(userOrDeleted(u.User, u.UserID)),
 			Role: u.Role,
 		})
 	}
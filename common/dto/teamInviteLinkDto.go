@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+
+	"server/common/models"
+)
+
+type TeamInviteLinkCreateDto struct {
+	MaxUses   *int       `json:"max_uses,omitempty"   validate:"omitempty,min=1"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Role      string     `json:"role,omitempty"       validate:"sanitize,omitempty,oneof=member admin"`
+}
+
+type TeamInviteLinkResponseDto struct {
+	ID        uint                  `json:"id"`
+	TeamID    uint                  `json:"team_id"`
+	Token     string                `json:"token"`
+	Role      models.TeamRole       `json:"role"`
+	MaxUses   *int                  `json:"max_uses,omitempty"`
+	Uses      int                   `json:"uses"`
+	ExpiresAt *time.Time            `json:"expires_at,omitempty"`
+	RevokedAt *time.Time            `json:"revoked_at,omitempty"`
+	Creator   PublicUserDtoResponse `json:"creator"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+func TeamInviteLinkCreateDtoToModel(l TeamInviteLinkCreateDto) models.TeamInviteLink {
+	return models.TeamInviteLink{
+		MaxUses:   l.MaxUses,
+		ExpiresAt: l.ExpiresAt,
+		Role:      models.TeamRole(l.Role),
+	}
+}
+
+func ToTeamInviteLinkResponseDto(l models.TeamInviteLink) TeamInviteLinkResponseDto {
+	return TeamInviteLinkResponseDto{
+		ID:        l.ID,
+		TeamID:    l.TeamID,
+		Token:     l.Token,
+		Role:      l.Role,
+		MaxUses:   l.MaxUses,
+		Uses:      l.Uses,
+		ExpiresAt: l.ExpiresAt,
+		RevokedAt: l.RevokedAt,
+		Creator:   ToPublicUserDtoResponse(l.Creator),
+		CreatedAt: l.CreatedAt,
+	}
+}
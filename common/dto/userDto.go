@@ -28,32 +28,48 @@ type UserUpdateDto struct {
 	BirthDate      time.Time `json:"birth_date"      validate:"required"`
 	City           string    `json:"city"            validate:"sanitize"`
 	FavoriteSports []string  `json:"favorite_sports,omitempty"`
+
+	// Replaces the user's sport profiles. Sports are added to favorites if missing.
+	SportProfiles []UserSportProfileDto `json:"sport_profiles,omitempty" validate:"omitempty,dive"`
 }
 
 type DeleteUserDto struct {
 	Email string `json:"email" validate:"sanitize,required,email"`
 }
 
+type ChangeEmailDto struct {
+	NewEmail string `json:"new_email" validate:"sanitize,required,email"`
+	Password string `json:"password"  validate:"sanitize,required"`
+}
+
+type ConfirmEmailChangeDto struct {
+	Code string `json:"code" validate:"sanitize,required,len=6"`
+}
+
 type RegisterPushTokenDto struct {
 	PushToken string `json:"push_token" validate:"sanitize,required"`
 }
 
 type UserResponseDto struct {
-	ID                  uint                       `json:"id"`
-	Email               string                     `json:"email"`
-	FirstName           string                     `json:"first_name"`
-	LastName            string                     `json:"last_name"`
-	ProfilePicture      string                     `json:"profile_picture,omitempty"`
-	Bio                 string                     `json:"bio,omitempty"`
-	BirthDate           time.Time                  `json:"birth_date"`
-	City                string                     `json:"city"`
-	FavoriteSports      []SportResponseDto         `json:"favorite_sports,omitempty"`
-	Friends             []PublicUserDtoResponse    `json:"friends,omitempty"`
-	CompletedChallenges uint                       `json:"completed_challenges"`
-	NextChallenges      []ChallengeResponseDto     `json:"next_challenges,omitempty"`
-	Settings            UserSettingsResponseDto    `json:"settings"`
-	EmergencyContacts   []EmergencyInfoResponseDto `json:"emergency_contacts,omitempty"`
-	Teams               []TeamResponseDto          `json:"teams,omitempty"`
+	ID                  uint                          `json:"id"`
+	Email               string                        `json:"email"`
+	EmailVerified       bool                          `json:"email_verified"`
+	TwoFactorEnabled    bool                          `json:"two_factor_enabled"`
+	Role                models.UserRole               `json:"role"`
+	FirstName           string                        `json:"first_name"`
+	LastName            string                        `json:"last_name"`
+	ProfilePicture      string                        `json:"profile_picture,omitempty"`
+	Bio                 string                        `json:"bio,omitempty"`
+	BirthDate           *time.Time                    `json:"birth_date,omitempty"`
+	City                string                        `json:"city,omitempty"`
+	FavoriteSports      []SportResponseDto            `json:"favorite_sports,omitempty"`
+	SportProfiles       []UserSportProfileResponseDto `json:"sport_profiles,omitempty"`
+	Friends             []PublicUserDtoResponse       `json:"friends,omitempty"`
+	CompletedChallenges uint                          `json:"completed_challenges"`
+	NextChallenges      []ChallengeResponseDto        `json:"next_challenges,omitempty"`
+	Settings            UserSettingsResponseDto       `json:"settings"`
+	EmergencyContacts   []EmergencyInfoResponseDto    `json:"emergency_contacts,omitempty"`
+	Teams               []TeamResponseDto             `json:"teams,omitempty"`
 }
 
 type UserSettingsResponseDto struct {
@@ -64,6 +80,14 @@ type UserSettingsResponseDto struct {
 	NotifyChallengeInvites   bool `json:"notify_challenge_invites"`
 	NotifyChallengeUpdates   bool `json:"notify_challenge_updates"`
 	NotifyChallengeReminders bool `json:"notify_challenge_reminders"`
+
+	PrivacyAge                models.PrivacyLevel `json:"privacy_age"`
+	PrivacyCity               models.PrivacyLevel `json:"privacy_city"`
+	PrivacyFriends            models.PrivacyLevel `json:"privacy_friends"`
+	PrivacyTeamsAndChallenges models.PrivacyLevel `json:"privacy_teams_and_challenges"`
+	PrivacyFriendRequests     models.PrivacyLevel `json:"privacy_friend_requests"`
+
+	ShowPresence bool `json:"show_presence"`
 }
 
 type UserSettingsUpdateDto struct {
@@ -74,6 +98,14 @@ type UserSettingsUpdateDto struct {
 	NotifyChallengeInvites   *bool `json:"notify_challenge_invites"`
 	NotifyChallengeUpdates   *bool `json:"notify_challenge_updates"`
 	NotifyChallengeReminders *bool `json:"notify_challenge_reminders"`
+
+	PrivacyAge                *models.PrivacyLevel `json:"privacy_age"                  validate:"omitempty,oneof=everyone friends nobody"`
+	PrivacyCity               *models.PrivacyLevel `json:"privacy_city"                 validate:"omitempty,oneof=everyone friends nobody"`
+	PrivacyFriends            *models.PrivacyLevel `json:"privacy_friends"              validate:"omitempty,oneof=everyone friends nobody"`
+	PrivacyTeamsAndChallenges *models.PrivacyLevel `json:"privacy_teams_and_challenges" validate:"omitempty,oneof=everyone friends nobody"`
+	PrivacyFriendRequests     *models.PrivacyLevel `json:"privacy_friend_requests"      validate:"omitempty,oneof=everyone friends nobody"`
+
+	ShowPresence *bool `json:"show_presence"`
 }
 
 type UsersSearchResponse struct {
@@ -83,18 +115,19 @@ type UsersSearchResponse struct {
 
 // Used for anyone but the current user
 type PublicUserDtoResponse struct {
-	ID                  uint                   `json:"id"`
-	FirstName           string                 `json:"first_name"`
-	LastName            string                 `json:"last_name"`
-	ProfilePicture      string                 `json:"profile_picture,omitempty"`
-	Bio                 string                 `json:"bio,omitempty"`
-	BirthDate           time.Time              `json:"birth_date"`
-	City                string                 `json:"city"`
-	FavoriteSports      []SportResponseDto     `json:"favorite_sports,omitempty"`
-	FriendsCount        uint                   `json:"friends_count,omitempty"`
-	TeamsCount          uint                   `json:"teams_count,omitempty"`
-	CompletedChallenges uint                   `json:"completed_challenges,omitempty"`
-	NextChallenges      []ChallengeResponseDto `json:"next_challenges,omitempty"`
+	ID                  uint                          `json:"id"`
+	FirstName           string                        `json:"first_name"`
+	LastName            string                        `json:"last_name"`
+	ProfilePicture      string                        `json:"profile_picture,omitempty"`
+	Bio                 string                        `json:"bio,omitempty"`
+	BirthDate           *time.Time                    `json:"birth_date,omitempty"`
+	City                string                        `json:"city,omitempty"`
+	FavoriteSports      []SportResponseDto            `json:"favorite_sports,omitempty"`
+	SportProfiles       []UserSportProfileResponseDto `json:"sport_profiles,omitempty"`
+	FriendsCount        uint                          `json:"friends_count,omitempty"`
+	TeamsCount          uint                          `json:"teams_count,omitempty"`
+	CompletedChallenges uint                          `json:"completed_challenges,omitempty"`
+	NextChallenges      []ChallengeResponseDto        `json:"next_challenges,omitempty"`
 }
 
 type Login struct {
@@ -112,6 +145,10 @@ type ResetPasswordDto struct {
 	NewPassword string `json:"new_password" validate:"sanitize,required,min=8"`
 }
 
+type VerifyEmailDto struct {
+	Code string `json:"code" validate:"sanitize,required,len=6"`
+}
+
 type GoogleAuthDto struct {
 	IDToken string `json:"idToken" validate:"sanitize,required"`
 }
@@ -123,18 +160,60 @@ type AppleAuthDto struct {
 	LastName  *string `json:"lastName,omitempty"`
 }
 
+// ProfileViewer describes who is looking at a user, so privacy settings can be applied.
+type ProfileViewer struct {
+	IsSelf   bool
+	IsFriend bool
+}
+
+// Privacy settings of the user.
+// When settings aren't loaded everything protected is hidden, so a query that forgets them doesn't leak data.
+func privacySettings(user models.User) models.UserSettings {
+	if user.Settings == nil {
+		return models.UserSettings{
+			PrivacyAge:                models.PrivacyNobody,
+			PrivacyCity:               models.PrivacyNobody,
+			PrivacyFriends:            models.PrivacyNobody,
+			PrivacyTeamsAndChallenges: models.PrivacyNobody,
+			PrivacyFriendRequests:     models.PrivacyNobody,
+		}
+	}
+	return *user.Settings
+}
+
 type CommonStatsDto struct {
 	CommonFriendsCount int64      `json:"common_friends_count"`
 	CommonTeamsCount   int64      `json:"common_teams_count"`
 	CommonSports       []SportDto `json:"common_sports"`
 }
 
+// userOrDeleted returns a placeholder for users that weren't loaded because their account is deleted.
+func userOrDeleted(user models.User, userID uint) models.User {
+	if user.ID == 0 {
+		return models.User{ID: userID, FirstName: models.DeletedUserName}
+	}
+	return user
+}
+
+// ToPublicUserDtoResponse maps a user as seen by someone who isn't their friend.
 func ToPublicUserDtoResponse(user models.User) PublicUserDtoResponse {
+	return ToPublicUserDtoResponseForViewer(user, ProfileViewer{})
+}
+
+// ToPublicUserDtoResponseForViewer maps a user and hides the fields their privacy settings don't allow the viewer to see.
+func ToPublicUserDtoResponseForViewer(user models.User, viewer ProfileViewer) PublicUserDtoResponse {
+	privacy := privacySettings(user)
+
 	favoriteSports := make([]SportResponseDto, len(user.FavoriteSports))
 	for i, sport := range user.FavoriteSports {
 		favoriteSports[i] = ToSportResponseDto(sport)
 	}
 
+	sportProfiles := make([]UserSportProfileResponseDto, len(user.SportProfiles))
+	for i, profile := range user.SportProfiles {
+		sportProfiles[i] = ToUserSportProfileResponseDto(profile)
+	}
+
 	friendsCount := uint(len(user.Friends))
 	teamsCount := uint(len(user.Teams))
 
@@ -161,31 +240,58 @@ func ToPublicUserDtoResponse(user models.User) PublicUserDtoResponse {
 		nextChallenges = append(nextChallenges, ToChallengeResponseDto(ch))
 	}
 
-	return PublicUserDtoResponse{
-		ID:                  user.ID,
-		FirstName:           user.FirstName,
-		LastName:            user.LastName,
-		ProfilePicture:      user.ProfilePicture,
-		Bio:                 user.Bio,
-		FavoriteSports:      favoriteSports,
-		BirthDate:           user.BirthDate,
-		City:                user.City,
-		FriendsCount:        friendsCount,
-		TeamsCount:          teamsCount,
-		CompletedChallenges: completedChallengesCount,
-		NextChallenges:      nextChallenges,
+	response := PublicUserDtoResponse{
+		ID:             user.ID,
+		FirstName:      user.FirstName,
+		LastName:       user.LastName,
+		ProfilePicture: user.ProfilePicture,
+		Bio:            user.Bio,
+		FavoriteSports: favoriteSports,
+		SportProfiles:  sportProfiles,
+	}
+
+	if privacy.PrivacyAge.Allows(viewer.IsSelf, viewer.IsFriend) {
+		birthDate := user.BirthDate
+		response.BirthDate = &birthDate
+	}
+	if privacy.PrivacyCity.Allows(viewer.IsSelf, viewer.IsFriend) {
+		response.City = user.City
 	}
+	if privacy.PrivacyFriends.Allows(viewer.IsSelf, viewer.IsFriend) {
+		response.FriendsCount = friendsCount
+	}
+	if privacy.PrivacyTeamsAndChallenges.Allows(viewer.IsSelf, viewer.IsFriend) {
+		response.TeamsCount = teamsCount
+		response.CompletedChallenges = completedChallengesCount
+		response.NextChallenges = nextChallenges
+	}
+
+	return response
 }
 
+// ToUserResponseDto maps the current user's own profile.
 func ToUserResponseDto(user models.User) UserResponseDto {
+	return ToUserResponseDtoForViewer(user, ProfileViewer{IsSelf: true})
+}
+
+// ToUserResponseDtoForViewer maps a user and hides the fields their privacy settings don't allow the viewer to see.
+func ToUserResponseDtoForViewer(user models.User, viewer ProfileViewer) UserResponseDto {
+	privacy := privacySettings(user)
+
 	favoriteSports := make([]SportResponseDto, len(user.FavoriteSports))
 	for i, sport := range user.FavoriteSports {
 		favoriteSports[i] = ToSportResponseDto(sport)
 	}
 
+	sportProfiles := make([]UserSportProfileResponseDto, len(user.SportProfiles))
+	for i, profile := range user.SportProfiles {
+		sportProfiles[i] = ToUserSportProfileResponseDto(profile)
+	}
+
+	// Friends of the current user are also friends of the viewer
 	friends := make([]PublicUserDtoResponse, len(user.Friends))
 	for i, friend := range user.Friends {
-		friends[i] = ToPublicUserDtoResponse(friend)
+		friends[i] = ToPublicUserDtoResponseForViewer(friend, ProfileViewer{IsFriend: viewer.IsSelf})
 	}
 
 	var settings UserSettingsResponseDto
@@ -194,13 +300,19 @@ func ToUserResponseDto(user models.User) UserResponseDto {
 	} else {
 		// Default settings when Settings is nil (all notifications enabled)
 		settings = UserSettingsResponseDto{
-			NotifyTeamInvites:        true,
-			NotifyTeamMembership:     true,
-			NotifyFriendRequests:     true,
-			NotifyFriendUpdates:      true,
-			NotifyChallengeInvites:   true,
-			NotifyChallengeUpdates:   true,
-			NotifyChallengeReminders: true,
+			NotifyTeamInvites:         true,
+			NotifyTeamMembership:      true,
+			NotifyFriendRequests:      true,
+			NotifyFriendUpdates:       true,
+			NotifyChallengeInvites:    true,
+			NotifyChallengeUpdates:    true,
+			NotifyChallengeReminders:  true,
+			PrivacyAge:                models.PrivacyEveryone,
+			PrivacyCity:               models.PrivacyEveryone,
+			PrivacyFriends:            models.PrivacyEveryone,
+			PrivacyTeamsAndChallenges: models.PrivacyEveryone,
+			PrivacyFriendRequests:     models.PrivacyEveryone,
+			ShowPresence:              true,
 		}
 	}
 
@@ -237,23 +349,39 @@ func ToUserResponseDto(user models.User) UserResponseDto {
 		teams[i] = ToTeamResponseDto(team.Team)
 	}
 
-	return UserResponseDto{
-		ID:                  user.ID,
-		Email:               user.Email,
-		FirstName:           user.FirstName,
-		LastName:            user.LastName,
-		ProfilePicture:      user.ProfilePicture,
-		Bio:                 user.Bio,
-		BirthDate:           user.BirthDate,
-		City:                user.City,
-		FavoriteSports:      favoriteSports,
-		Friends:             friends,
-		Settings:            settings,
-		CompletedChallenges: completedChallengesCount,
-		NextChallenges:      nextChallenges,
-		EmergencyContacts:   emergencyContacts,
-		Teams:               teams,
+	response := UserResponseDto{
+		ID:                user.ID,
+		Email:             user.Email,
+		EmailVerified:     user.EmailVerified,
+		TwoFactorEnabled:  user.TOTPEnabledAt != nil,
+		Role:              user.Role,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		ProfilePicture:    user.ProfilePicture,
+		Bio:               user.Bio,
+		FavoriteSports:    favoriteSports,
+		SportProfiles:     sportProfiles,
+		Settings:          settings,
+		EmergencyContacts: emergencyContacts,
 	}
+
+	if privacy.PrivacyAge.Allows(viewer.IsSelf, viewer.IsFriend) {
+		birthDate := user.BirthDate
+		response.BirthDate = &birthDate
+	}
+	if privacy.PrivacyCity.Allows(viewer.IsSelf, viewer.IsFriend) {
+		response.City = user.City
+	}
+	if privacy.PrivacyFriends.Allows(viewer.IsSelf, viewer.IsFriend) {
+		response.Friends = friends
+	}
+	if privacy.PrivacyTeamsAndChallenges.Allows(viewer.IsSelf, viewer.IsFriend) {
+		response.CompletedChallenges = completedChallengesCount
+		response.NextChallenges = nextChallenges
+		response.Teams = teams
+	}
+
+	return response
 }
 
 func ToUserSettingsResponseDto(s models.UserSettings) UserSettingsResponseDto {
@@ -265,6 +393,14 @@ func ToUserSettingsResponseDto(s models.UserSettings) UserSettingsResponseDto {
 		NotifyChallengeInvites:   s.NotifyChallengeInvites,
 		NotifyChallengeUpdates:   s.NotifyChallengeUpdates,
 		NotifyChallengeReminders: s.NotifyChallengeReminders,
+
+		PrivacyAge:                s.PrivacyAge,
+		PrivacyCity:               s.PrivacyCity,
+		PrivacyFriends:            s.PrivacyFriends,
+		PrivacyTeamsAndChallenges: s.PrivacyTeamsAndChallenges,
+		PrivacyFriendRequests:     s.PrivacyFriendRequests,
+
+		ShowPresence: s.ShowPresence,
 	}
 }
 
@@ -293,6 +429,24 @@ func UserSettingsUpdateDtoToModel(s UserSettingsUpdateDto) models.UserSettings {
 	if s.NotifyChallengeReminders != nil {
 		m.NotifyChallengeReminders = *s.NotifyChallengeReminders
 	}
+	if s.PrivacyAge != nil {
+		m.PrivacyAge = *s.PrivacyAge
+	}
+	if s.PrivacyCity != nil {
+		m.PrivacyCity = *s.PrivacyCity
+	}
+	if s.PrivacyFriends != nil {
+		m.PrivacyFriends = *s.PrivacyFriends
+	}
+	if s.PrivacyTeamsAndChallenges != nil {
+		m.PrivacyTeamsAndChallenges = *s.PrivacyTeamsAndChallenges
+	}
+	if s.PrivacyFriendRequests != nil {
+		m.PrivacyFriendRequests = *s.PrivacyFriendRequests
+	}
+	if s.ShowPresence != nil {
+		m.ShowPresence = *s.ShowPresence
+	}
 	return m
 }
 
@@ -327,6 +481,7 @@ func UserCreateDtoToModel(u UserCreateDto) models.User {
 			NotifyChallengeInvites:   true,
 			NotifyChallengeUpdates:   true,
 			NotifyChallengeReminders: true,
+			ShowPresence:             true,
 		},
 	}
 }
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"server/common/models"
+)
+
+type UserSportProfileDto struct {
+	Sport               string   `json:"sport"                            validate:"sanitize,required,is-valid-sport"`
+	Level               string   `json:"level,omitempty"                  validate:"sanitize,omitempty,oneof=beginner intermediate advanced elite"`
+	Position            string   `json:"position,omitempty"               validate:"sanitize,max=50"`
+	PreferredDays       []string `json:"preferred_days,omitempty"         validate:"omitempty,dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	PreferredTimes      []string `json:"preferred_times,omitempty"        validate:"omitempty,dive,oneof=morning afternoon evening"`
+	MaxTravelDistanceKm *int     `json:"max_travel_distance_km,omitempty" validate:"omitempty,min=1,max=500"`
+}
+
+type UserSportProfileResponseDto struct {
+	Sport               SportResponseDto   `json:"sport"`
+	Level               *models.SportLevel `json:"level,omitempty"`
+	Position            *string            `json:"position,omitempty"`
+	PreferredDays       []string           `json:"preferred_days"`
+	PreferredTimes      []string           `json:"preferred_times"`
+	MaxTravelDistanceKm *int               `json:"max_travel_distance_km,omitempty"`
+}
+
+// UserSportProfileDtoToModel converts the DTO to a model.
+// SportID is resolved from Sport.Name by the service.
+func UserSportProfileDtoToModel(p UserSportProfileDto) models.UserSportProfile {
+	profile := models.UserSportProfile{
+		Sport:               models.Sport{Name: p.Sport},
+		PreferredDays:       tagsToJSON(p.PreferredDays),
+		PreferredTimes:      tagsToJSON(p.PreferredTimes),
+		MaxTravelDistanceKm: p.MaxTravelDistanceKm,
+	}
+
+	if p.Level != "" {
+		level := models.SportLevel(p.Level)
+		profile.Level = &level
+	}
+
+	if p.Position != "" {
+		position := p.Position
+		profile.Position = &position
+	}
+
+	return profile
+}
+
+func ToUserSportProfileResponseDto(p models.UserSportProfile) UserSportProfileResponseDto {
+	days := StringsFromJSON(p.PreferredDays)
+	if days == nil {
+		days = []string{}
+	}
+
+	times := StringsFromJSON(p.PreferredTimes)
+	if times == nil {
+		times = []string{}
+	}
+
+	return UserSportProfileResponseDto{
+		Sport:               ToSportResponseDto(p.Sport),
+		Level:               p.Level,
+		Position:            p.Position,
+		PreferredDays:       days,
+		PreferredTimes:      times,
+		MaxTravelDistanceKm: p.MaxTravelDistanceKm,
+	}
+}
@@ -0,0 +1,230 @@
+// Package jwtkeys manages the keys used to sign and verify access tokens.
+//
+// A keyset holds several keys identified by their kid. Tokens are signed with the
+// active key and carry its kid in the header, so old keys can keep verifying tokens
+// while a new key is rolled out. Keys are either HMAC secrets (HS256) or asymmetric
+// keys (EdDSA, RS256). Asymmetric keys can be loaded from a public key only, which
+// lets services that just verify tokens run without the private key.
+package jwtkeys
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// LegacyKeyID is the kid of the key built from a plain shared secret.
+// Tokens without a kid header are verified with it.
+const LegacyKeyID = "default"
+
+var (
+	ErrNoKeys         = errors.New("jwtkeys: no keys configured")
+	ErrUnknownKey     = errors.New("jwtkeys: unknown key id")
+	ErrNoSigningKey   = errors.New("jwtkeys: active key can't sign")
+	ErrMethodMismatch = errors.New("jwtkeys: signing method doesn't match key")
+)
+
+// Key is a single signing or verification key.
+// SignKey is nil for asymmetric keys loaded from a public key.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// Parse builds a keyset from a comma separated list of "kid:ALG:value" entries.
+// For HS256 the value is the secret, for EdDSA and RS256 it is the path to a PEM file
+// holding either the private or the public key.
+// A non-empty legacySecret is added as an HS256 key with LegacyKeyID.
+// activeID selects the signing key and may be empty if the keyset only verifies tokens
+// or has a single key.
+func Parse(spec, activeID, legacySecret string) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*Key{}}
+
+	if legacySecret != "" {
+		ks.keys[LegacyKeyID] = &Key{
+			ID:        LegacyKeyID,
+			Method:    jwt.SigningMethodHS256,
+			SignKey:   []byte(legacySecret),
+			VerifyKey: []byte(legacySecret),
+		}
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, err := parseKey(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := ks.keys[key.ID]; exists {
+			return nil, fmt.Errorf("jwtkeys: duplicate key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	if len(ks.keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	switch {
+	case activeID != "":
+		active, ok := ks.keys[activeID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, activeID)
+		}
+		ks.active = active
+	case len(ks.keys) == 1:
+		for _, key := range ks.keys {
+			ks.active = key
+		}
+	}
+
+	return ks, nil
+}
+
+// CanSign reports whether the keyset has an active key with its private part,
+// services that issue tokens should check it at startup.
+func (ks *KeySet) CanSign() bool {
+	return ks.active != nil && ks.active.SignKey != nil
+}
+
+// Sign signs the claims with the active key and sets its kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if !ks.CanSign() {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+
+	return token.SignedString(ks.active.SignKey)
+}
+
+// Keyfunc looks up the verification key for a token, to be passed to jwt.Parse.
+// The token's algorithm must match the key, so a public key can't be used as an HMAC secret.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = LegacyKeyID
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrMethodMismatch
+	}
+
+	return key.VerifyKey, nil
+}
+
+// Methods returns the algorithms used by the keyset, for jwt.WithValidMethods.
+func (ks *KeySet) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range ks.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+// Package private methods
+func parseKey(entry string) (*Key, error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("jwtkeys: invalid key entry, expected kid:ALG:value")
+	}
+
+	id, alg, value := parts[0], strings.ToUpper(parts[1]), parts[2]
+
+	switch alg {
+	case "HS256":
+		return &Key{
+			ID:        id,
+			Method:    jwt.SigningMethodHS256,
+			SignKey:   []byte(value),
+			VerifyKey: []byte(value),
+		}, nil
+	case "EDDSA", "RS256":
+		pemData, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: reading key %q: %w", id, err)
+		}
+
+		key, err := parsePEMKey(alg, pemData)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: parsing key %q: %w", id, err)
+		}
+
+		key.ID = id
+		return key, nil
+	default:
+		return nil, fmt.Errorf("jwtkeys: unsupported algorithm %q for key %q", parts[1], id)
+	}
+}
+
+// parsePEMKey loads a private key, or a public key if the PEM doesn't hold a private one.
+func parsePEMKey(alg string, pemData []byte) (*Key, error) {
+	isPrivate := strings.Contains(string(pemData), "PRIVATE KEY")
+
+	if alg == "EDDSA" {
+		if isPrivate {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pemData)
+			if err != nil {
+				return nil, err
+			}
+
+			signer, ok := private.(crypto.Signer)
+			if !ok {
+				return nil, errors.New("not an Ed25519 private key")
+			}
+
+			return &Key{Method: jwt.SigningMethodEdDSA, SignKey: private, VerifyKey: signer.Public()}, nil
+		}
+
+		public, err := jwt.ParseEdPublicKeyFromPEM(pemData)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Key{Method: jwt.SigningMethodEdDSA, VerifyKey: public}, nil
+	}
+
+	if isPrivate {
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Key{Method: jwt.SigningMethodRS256, SignKey: private, VerifyKey: &private.PublicKey}, nil
+	}
+
+	public, err := jwt.ParseRSAPublicKeyFromPEM(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{Method: jwt.SigningMethodRS256, VerifyKey: public}, nil
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"server/common/appError"
+	"server/common/models"
+)
+
+// AdminMiddleware only lets platform admins through
+// This middleware should be applied AFTER AuthMiddleware
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(UserContextKey).(*models.User)
+		if !ok {
+			appError.HandleError(w, appError.ErrUnauthorized)
+			return
+		}
+
+		if user.Role != models.UserRoleAdmin {
+			appError.HandleError(w, appError.ErrAdminRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -15,6 +15,9 @@ type contextKey string
 // UserContextKey is the key used to store the authenticated user in request context.
 const UserContextKey contextKey = "user"
 
+// SessionContextKey is the key used to store the ID of the current session in request context.
+const SessionContextKey contextKey = "session"
+
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -36,13 +39,26 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Reject tokens of sessions that were logged out
+		if err := services.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+			appError.HandleError(w, err)
+			return
+		}
+
 		user, err := services.GetUserByID(claims.UserID)
 		if err != nil {
 			appError.HandleError(w, appError.ErrUserNotFound)
 			return
 		}
 
+		// Sessions are revoked on suspension, this catches suspensions and bans while a token is still valid
+		if err := services.CheckAccountStatus(user); err != nil {
+			appError.HandleError(w, err)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), UserContextKey, user)
+		ctx = context.WithValue(ctx, SessionContextKey, claims.SessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -12,5 +12,25 @@ type ConversationParticipant struct {
 	JoinedAt       time.Time    `gorm:"autoCreateTime" json:"joined_at"`
 	LastReadAt     *time.Time   `json:"last_read_at,omitempty"`
 	LeftAt         *time.Time   `json:"left_at,omitempty"`
+
+	// Messages up to this time reached at least one of the user's devices.
+	// Only tracked in direct and group conversations.
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+
+	// Admins manage a group conversation, the creator is the first one
+	IsAdmin bool `gorm:"not null;default:false" json:"is_admin"`
+
+	// Push notifications are muted from MutedAt until MutedUntil, or until unmuted when MutedUntil is nil.
+	// Mentions still notify, see IsMuted. Only the participant sees these settings.
+	MutedAt    *time.Time `json:"-"`
+	MutedUntil *time.Time `json:"-"`
+
+	// Only messages that mention the participant send push notifications
+	MentionsOnly bool `gorm:"not null;default:false" json:"-"`
+}
+
+// IsMuted reports whether push notifications for the conversation are muted at the given time.
+func (p ConversationParticipant) IsMuted(now time.Time) bool {
+	return p.MutedAt != nil && (p.MutedUntil == nil || p.MutedUntil.After(now))
 }
 
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type AdminAction string
+
+const (
+	AdminActionUserRoleUpdated     AdminAction = "user_role_updated"
+	AdminActionUserSuspended       AdminAction = "user_suspended"
+	AdminActionUserUnsuspended     AdminAction = "user_unsuspended"
+	AdminActionUserBanned          AdminAction = "user_banned"
+	AdminActionUserUnbanned        AdminAction = "user_unbanned"
+	AdminActionEulaVersionCreated  AdminAction = "eula_version_created"
+	AdminActionEulaVersionActivate AdminAction = "eula_version_activated"
+	AdminActionSportCreated        AdminAction = "sport_created"
+	AdminActionSportDeleted        AdminAction = "sport_deleted"
+	AdminActionReportsResolved     AdminAction = "reports_resolved"
+	AdminActionReportsDismissed    AdminAction = "reports_dismissed"
+)
+
+// AdminAuditLog records every change made through the admin and moderation APIs.
+// Rows are never updated or deleted.
+type AdminAuditLog struct {
+	ID      uint `gorm:"primaryKey"`
+	AdminID uint `gorm:"not null;index"`
+	Admin   User `gorm:"foreignKey:AdminID"`
+
+	Action     AdminAction    `gorm:"type:varchar(50);not null;index"`
+	TargetType string         `gorm:"type:varchar(50);not null"` // "user", "eula_version", "sport", or a report target type
+	TargetID   uint           `gorm:"not null"`
+	Details    datatypes.JSON `gorm:"type:jsonb;default:'{}'"` // Action specific, e.g. the suspension reason
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
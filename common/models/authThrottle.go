@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AuthThrottle counts failed authentication attempts for an account or IP address.
+// The state lives in Postgres, so the limits hold across API instances.
+type AuthThrottle struct {
+	// Scope and subject, e.g. "login:account:jane@example.com" or "login:ip:10.0.0.1"
+	Key string `gorm:"type:varchar(255);primaryKey"`
+
+	Failures      int        `gorm:"not null;default:0"`
+	LastFailureAt time.Time  `gorm:"not null;index"`
+	LockedUntil   *time.Time // Set once Failures reaches the limit of the scope
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// BackplanePayload holds a realtime event that is too large for a Postgres NOTIFY.
+// The notification only carries the ID, listeners load the payload from here.
+// Rows are only needed for a moment, the cron job deletes old ones.
+type BackplanePayload struct {
+	ID        uint      `gorm:"primaryKey"`
+	Payload   []byte    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+)
+
+// DataExport is a user's request for a copy of their data (GDPR).
+// The ZIP is built by a cron task and downloaded with a time-limited token.
+type DataExport struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Status DataExportStatus `gorm:"type:VARCHAR(20);not null;default:'pending';check:status IN ('pending','processing','ready','failed')"`
+
+	Token     *string    `gorm:"type:varchar(64);uniqueIndex"` // Set when the export is ready
+	Data      []byte     `gorm:"type:bytea"`                   // The ZIP archive
+	ExpiresAt *time.Time // Download token expiry
+
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	StartedAt   *time.Time // Set when processing starts, to detect exports stuck in processing
+	CompletedAt *time.Time
+}
@@ -18,5 +18,100 @@ type Message struct {
 
 	Content   string    `gorm:"not null" json:"content"`
 	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_conversation_created" json:"created_at"`
+
+	// Set when the message replies to another message of the same conversation
+	ReplyToMessageID *uint    `gorm:"index" json:"reply_to_message_id,omitempty"`
+	ReplyTo          *Message `gorm:"foreignKey:ReplyToMessageID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"reply_to,omitempty"`
+
+	// Set when the sender edits the message, the previous versions are kept in Edits
+	EditedAt *time.Time    `json:"edited_at,omitempty"`
+	Edits    []MessageEdit `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Reactions []MessageReaction `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	// Participants mentioned in Content, kept in sync when the message is edited
+	Mentions []MessageMention `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	// Hard deleting a message orphans its attachments, the cron job removes their files
+	Attachments []MessageAttachment `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+
+	// Set when the sender deletes the message for everyone.
+	// The row stays as a tombstone without content, so the conversation keeps its shape.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// Set on messages the server posts when a group changes, nobody can edit or delete them.
+	// SenderID is the user who made the change, TargetUserID the user it was made to.
+	SystemEvent  *SystemEvent `gorm:"type:VARCHAR(30)" json:"system_event,omitempty"`
+	TargetUserID *uint        `json:"target_user_id,omitempty"`
+}
+
+type SystemEvent string
+
+const (
+	SystemEventGroupRenamed       SystemEvent = "group_renamed"
+	SystemEventParticipantAdded   SystemEvent = "participant_added"
+	SystemEventParticipantRemoved SystemEvent = "participant_removed"
+	SystemEventParticipantLeft    SystemEvent = "participant_left"
+	SystemEventAdminGranted       SystemEvent = "admin_granted"
+	SystemEventAdminRevoked       SystemEvent = "admin_revoked"
+)
+
+// MessageEdit is a previous version of an edited message.
+type MessageEdit struct {
+	ID        uint      `gorm:"primaryKey"`
+	MessageID uint      `gorm:"not null;index"`
+	Content   string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"` // When this version was replaced
+}
+
+// MessageReaction is one emoji reaction of a user, a user can react with several emojis.
+type MessageReaction struct {
+	ID        uint      `gorm:"primaryKey"`
+	MessageID uint      `gorm:"not null;uniqueIndex:idx_message_reactions_unique"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_message_reactions_unique"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Emoji     string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_message_reactions_unique"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// MessageMention is a participant mentioned with a @[Name](userID) token in the content of a message.
+type MessageMention struct {
+	ID        uint `gorm:"primaryKey"`
+	MessageID uint `gorm:"not null;uniqueIndex:idx_message_mentions_unique"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_message_mentions_unique;index"`
+	User      User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// MessageAttachment is a file uploaded to a conversation. It is attached to a message
+// when the message is sent, uploads that are never sent are removed by the cron job.
+type MessageAttachment struct {
+	ID             uint      `gorm:"primaryKey"`
+	ConversationID uint      `gorm:"not null;index"`
+	MessageID      *uint     `gorm:"index"`
+	UploaderID     uint      `gorm:"not null;index"`
+	Uploader       User      `gorm:"foreignKey:UploaderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	FileName       string    `gorm:"not null"`
+	ContentType    string    `gorm:"not null"`
+	Size           int64     `gorm:"not null"`
+	StorageKey     string    `gorm:"not null"`
+	ThumbnailKey   string    // Empty unless the attachment is an image
+	Width          int       // Images only
+	Height         int       // Images only
+	CreatedAt      time.Time `gorm:"autoCreateTime;index"`
+}
+
+// IsImage reports whether the attachment is an image with a thumbnail.
+func (a MessageAttachment) IsImage() bool {
+	return a.ThumbnailKey != ""
+}
+
+// IsSystem reports whether the server posted the message about a change to the group.
+func (m Message) IsSystem() bool {
+	return m.SystemEvent != nil
+}
+
+// IsDeleted reports whether the message was deleted for everyone.
+func (m Message) IsDeleted() bool {
+	return m.DeletedAt != nil
 }
 
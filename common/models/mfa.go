@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// MFARecoveryCode is a one-time code that replaces a TOTP code when the authenticator is lost.
+type MFARecoveryCode struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	CodeHash string `gorm:"type:varchar(64);not null"` // SHA-256, the code is only shown once
+	UsedAt   *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// MFAChallenge is the second step of a login with 2FA enabled.
+// The password was correct, a session is started once a valid code is provided.
+type MFAChallenge struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -16,6 +16,7 @@ const (
 	NotifTypeTeamRemovedUser NotificationType = "team_removed_user"
 	NotifTypeTeamUserLeft    NotificationType = "team_user_left"
 	NotifTypeTeamDeleted     NotificationType = "team_deleted"
+	NotifTypeTeamJoinedLink  NotificationType = "team_joined_link"
 
 	// Friend
 	NotifTypeFriendReq     NotificationType = "friend_request"
@@ -34,6 +35,10 @@ const (
 	NotifTypeChallengeFullParticipation   NotificationType = "challenge_full_participation"
 	NotifTypeChallengeNotAnswered24H      NotificationType = "challenge_invitation_not_answered_24h"
 	NotifTypeChallengeMissingParticipants NotificationType = "challenge_missing_participants"
+
+	// Moderation
+	NotifTypeReportResolved  NotificationType = "report_resolved"
+	NotifTypeReportDismissed NotificationType = "report_dismissed"
 )
 
 type Notification struct {
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type PresenceStatus string
+
+const (
+	PresenceOnline  PresenceStatus = "online"
+	PresenceAway    PresenceStatus = "away"
+	PresenceOffline PresenceStatus = "offline"
+)
+
+// PresenceConnection is an open WebSocket connection on one of the chat instances.
+// The instance refreshes LastHeartbeatAt while the connection is open,
+// rows of instances that stopped doing so are expired by the others.
+type PresenceConnection struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	LastActiveAt    time.Time `gorm:"not null"`       // Last time the app sent something
+	LastHeartbeatAt time.Time `gorm:"not null;index"` // Last time the instance confirmed the connection
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+}
+
+// UserPresence is the last published presence of a user, combined from all of their connections.
+type UserPresence struct {
+	UserID uint `gorm:"primaryKey"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Status     PresenceStatus `gorm:"type:VARCHAR(20);not null;default:'offline';check:status IN ('online','away','offline')"`
+	LastSeenAt *time.Time     // Set when the last connection closed
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime"`
+}
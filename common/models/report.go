@@ -11,17 +11,47 @@ const (
 	ReportTargetMessage   ReportTargetType = "MESSAGE"
 )
 
+const (
+	ReportStatusPending   = "PENDING"
+	ReportStatusResolved  = "RESOLVED"  // Action was taken against the target
+	ReportStatusDismissed = "DISMISSED" // No violation found
+)
+
 type Report struct {
 	ID         uint `gorm:"primaryKey"`
-	ReporterID uint `gorm:"not null"` // Who sent the report
+	ReporterID uint `gorm:"not null;uniqueIndex:idx_reports_pending_reporter_target,where:status = 'PENDING'"` // Who sent the report
 	Reporter   User `gorm:"foreignKey:ReporterID"`
 
-	TargetID   uint             `gorm:"not null"` // ID of the thing being reported
-	TargetType ReportTargetType `gorm:"not null"` // "USER", "TEAM", etc.
+	TargetID   uint             `gorm:"not null;uniqueIndex:idx_reports_pending_reporter_target;index:idx_reports_target"` // ID of the thing being reported
+	TargetType ReportTargetType `gorm:"not null;uniqueIndex:idx_reports_pending_reporter_target;index:idx_reports_target"` // "USER", "TEAM", etc.
+
+	Reason   string `gorm:"not null"`           // "RACISM", "SPAM", etc.
+	Severity int    `gorm:"not null;default:1"` // Derived from the reason, used to prioritize the moderation queue
+	Comment  string
 
-	Reason  string `gorm:"not null"` // "RACISM", "SPAM", etc.
-	Comment string
+	// Copy of the reported content at report time, so deleting it doesn't destroy the evidence.
+	// Only set for messages.
+	TargetSnapshot *string `gorm:"type:text"`
+	TargetOwnerID  *uint   // Author of the reported content
 
-	Status    string `gorm:"default:'PENDING'"` // PENDING, RESOLVED, DISMISSED
+	Status    string `gorm:"default:'PENDING';index:idx_reports_target"` // PENDING, RESOLVED, DISMISSED
 	CreatedAt time.Time
+
+	// Moderation
+	ResolvedByID   *uint
+	ResolvedBy     *User `gorm:"foreignKey:ResolvedByID"`
+	ResolutionNote string
+	ResolvedAt     *time.Time
+}
+
+// ReportGroup is an entry in the moderation queue: all pending reports about one target.
+// It is the result of an aggregate query, not a table.
+type ReportGroup struct {
+	TargetType      ReportTargetType
+	TargetID        uint
+	ReportCount     int64
+	Severity        int    // Highest severity among the reports
+	Reasons         string // Comma separated, distinct
+	FirstReportedAt time.Time
+	LastReportedAt  time.Time
 }
@@ -2,13 +2,16 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Sport struct {
-	ID        uint      `gorm:"primaryKey"`
-	Name      string    `gorm:"not null;unique"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID        uint           `gorm:"primaryKey"`
+	Name      string         `gorm:"not null;unique"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"` // Retired by an admin, hidden from the sports list
 }
 
 func GetAllowedSports() []string {
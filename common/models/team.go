@@ -42,3 +42,22 @@ type TeamMember struct {
 	User      User      `gorm:"foreignKey:UserID"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
+
+// TeamInviteLink is a shareable link that lets users join a team without a personal invitation.
+type TeamInviteLink struct {
+	ID uint `gorm:"primaryKey"`
+
+	TeamID    uint     `gorm:"not null;index"`
+	Team      Team     `gorm:"foreignKey:TeamID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatorID uint     `gorm:"not null"`
+	Creator   User     `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Token     string   `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Role      TeamRole `gorm:"type:varchar(20);not null;default:'member'"` // Role given to users joining through the link
+
+	MaxUses   *int       // nil = unlimited
+	Uses      int        `gorm:"not null;default:0"`
+	ExpiresAt *time.Time // nil = never expires
+	RevokedAt *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -6,6 +6,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// Shown instead of the name of deactivated and anonymized users
+const DeletedUserName = "Deleted user"
+
+type UserRole string
+
+const (
+	UserRoleUser      UserRole = "user"
+	UserRoleModerator UserRole = "moderator" // Works the moderation queue
+	UserRoleAdmin     UserRole = "admin"     // Platform administrator, has access to the /admin API
+)
+
 type User struct {
 	ID             uint    `gorm:"primaryKey"`
 	Email          string  `gorm:"not null;unique"`
@@ -17,21 +28,49 @@ type User struct {
 	Bio            string
 	BirthDate      time.Time
 	City           string
+	Role           UserRole `gorm:"type:varchar(20);not null;default:'user';index"`
 
 	// Password Reset
 	PasswordResetCode          string     `gorm:"index"`
 	PasswordResetCodeExpiresAt *time.Time `gorm:"index"`
 
+	// Email Verification
+	// Existing and OAuth accounts count as verified, password registrations start unverified.
+	EmailVerified                  bool       `gorm:"not null;default:true"`
+	EmailVerificationCode          string     `gorm:"index"`
+	EmailVerificationCodeExpiresAt *time.Time `gorm:"index"`
+	EmailVerificationSentAt        *time.Time // Used to throttle resends
+
+	// Email Change
+	// The new address only replaces Email once the code sent to it is confirmed.
+	PendingEmail              *string `gorm:"default:null"`
+	PendingEmailCode          string  `gorm:"index"`
+	PendingEmailCodeExpiresAt *time.Time
+
+	// Two-Factor Authentication (TOTP)
+	// The secret is set on enrollment, 2FA is only active once the first code is confirmed.
+	TOTPSecret       *string    `gorm:"column:totp_secret;default:null"`
+	TOTPEnabledAt    *time.Time `gorm:"column:totp_enabled_at"`
+	TOTPLastUsedStep int64      `gorm:"column:totp_last_used_step;not null;default:0"` // Prevents code replay
+
+	// Suspension and ban
+	// A suspended user can't use the app until SuspendedUntil has passed, a ban lasts until lifted.
+	SuspendedUntil   *time.Time
+	SuspensionReason string
+	BannedAt         *time.Time `gorm:"index"`
+	BanReason        string
+
 	// Push Notification Expo Token
 	ExpoToken string `gorm:"default::null"`
 
 	// Relationships
-	FavoriteSports    []Sport      `gorm:"many2many:user_favorite_sports;"`
-	Teams             []TeamMember `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	CreatedChallenges []Challenge  `gorm:"foreignKey:CreatorID"`
-	JoinedChallenges  []Challenge  `gorm:"many2many:user_challenges;"`
-	Friends           []User       `gorm:"many2many:user_friends;joinForeignKey:UserID;JoinReferences:FriendID"`
-	BlockedUsers      []User       `gorm:"many2many:user_blocked_users;joinForeignKey:UserID;JoinReferences:BlockedUserID"`
+	FavoriteSports    []Sport            `gorm:"many2many:user_favorite_sports;"`
+	SportProfiles     []UserSportProfile `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Teams             []TeamMember       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatedChallenges []Challenge        `gorm:"foreignKey:CreatorID"`
+	JoinedChallenges  []Challenge        `gorm:"many2many:user_challenges;"`
+	Friends           []User             `gorm:"many2many:user_friends;joinForeignKey:UserID;JoinReferences:FriendID"`
+	BlockedUsers      []User             `gorm:"many2many:user_blocked_users;joinForeignKey:UserID;JoinReferences:BlockedUserID"`
 
 	Settings          *UserSettings   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	EmergencyContacts []EmergencyInfo `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
@@ -39,5 +78,23 @@ type User struct {
 	//Other
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	DeletedAt gorm.DeletedAt `gorm:"index"` // Set when the user deletes their account, starts the grace period
+
+	// Set when personal data has been scrubbed after the deletion grace period
+	AnonymizedAt *time.Time
+}
+
+// IsSuspended reports whether the user is suspended at the given time.
+func (u User) IsSuspended(now time.Time) bool {
+	return u.SuspendedUntil != nil && u.SuspendedUntil.After(now)
+}
+
+// IsBanned reports whether the user is permanently banned.
+func (u User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+// IsModerator reports whether the user can work the moderation queue. Admins are moderators too.
+func (u User) IsModerator() bool {
+	return u.Role == UserRoleModerator || u.Role == UserRoleAdmin
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// UserSession is a logged in device.
+// Access tokens carry the session ID, so revoking the session logs the device out.
+type UserSession struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	// Only SHA-256 hashes of refresh tokens are stored.
+	// The previous hash is kept to detect reuse of a rotated token.
+	RefreshTokenHash         string  `gorm:"type:varchar(64);not null;uniqueIndex"`
+	PreviousRefreshTokenHash *string `gorm:"type:varchar(64);index"`
+
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	LastUsedAt time.Time
+	ExpiresAt  time.Time `gorm:"not null;index"` // Refresh token expiry
+	RevokedAt  *time.Time
+}
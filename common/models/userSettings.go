@@ -2,6 +2,32 @@ package models
 
 import "time"
 
+// PrivacyLevel decides who can see a part of a user's profile.
+type PrivacyLevel string
+
+const (
+	PrivacyEveryone PrivacyLevel = "everyone"
+	PrivacyFriends  PrivacyLevel = "friends"
+	PrivacyNobody   PrivacyLevel = "nobody"
+)
+
+// Allows reports whether a viewer may see data protected by this level.
+// Users can always see their own data, and an unset level counts as everyone.
+func (p PrivacyLevel) Allows(isSelf bool, isFriend bool) bool {
+	if isSelf {
+		return true
+	}
+
+	switch p {
+	case PrivacyNobody:
+		return false
+	case PrivacyFriends:
+		return isFriend
+	default:
+		return true
+	}
+}
+
 type UserSettings struct {
 	UserID uint `gorm:"primaryKey"`
 
@@ -17,6 +43,7 @@ type UserSettings struct {
 	// - team_removed_user
 	// - team_user_left
 	// - team_deleted
+	// - team_joined_link
 	NotifyTeamMembership bool `gorm:"default:true"`
 
 	// --------- Friend notifications --------- \\
@@ -52,6 +79,28 @@ type UserSettings struct {
 	// - challenge_invitation_not_answered_24h
 	NotifyChallengeReminders bool `gorm:"default:true"`
 
+	// --------- Privacy --------- \\
+
+	// Who can see the birth date (age)
+	PrivacyAge PrivacyLevel `gorm:"type:VARCHAR(20);not null;default:'everyone';check:privacy_age IN ('everyone','friends','nobody')"`
+
+	// Who can see the city
+	PrivacyCity PrivacyLevel `gorm:"type:VARCHAR(20);not null;default:'everyone';check:privacy_city IN ('everyone','friends','nobody')"`
+
+	// Who can see the friends list and friend count
+	PrivacyFriends PrivacyLevel `gorm:"type:VARCHAR(20);not null;default:'everyone';check:privacy_friends IN ('everyone','friends','nobody')"`
+
+	// Who can see teams and joined challenges
+	PrivacyTeamsAndChallenges PrivacyLevel `gorm:"type:VARCHAR(20);not null;default:'everyone';check:privacy_teams_and_challenges IN ('everyone','friends','nobody')"`
+
+	// Who can send friend requests.
+	// "friends" means friends of friends, since existing friends can't be requested again.
+	PrivacyFriendRequests PrivacyLevel `gorm:"type:VARCHAR(20);not null;default:'everyone';check:privacy_friend_requests IN ('everyone','friends','nobody')"`
+
+	// Whether friends and chat partners see when the user is online.
+	// When off, the user always appears offline without a last seen time.
+	ShowPresence bool `gorm:"default:true"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
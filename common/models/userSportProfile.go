@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type SportLevel string
+
+// Ordered from lowest to highest
+const (
+	SportLevelBeginner     SportLevel = "beginner"
+	SportLevelIntermediate SportLevel = "intermediate"
+	SportLevelAdvanced     SportLevel = "advanced"
+	SportLevelElite        SportLevel = "elite"
+)
+
+// Preferred time slots for playing
+const (
+	TimeSlotMorning   = "morning"   // 06-12
+	TimeSlotAfternoon = "afternoon" // 12-17
+	TimeSlotEvening   = "evening"   // 17-23
+)
+
+// UserSportProfile holds a user's playing preferences for one of their favorite sports.
+type UserSportProfile struct {
+	UserID  uint  `gorm:"primaryKey;autoIncrement:false"`
+	SportID uint  `gorm:"primaryKey;autoIncrement:false"`
+	Sport   Sport `gorm:"foreignKey:SportID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Level               *SportLevel    `gorm:"type:VARCHAR(20);check:level IN ('beginner','intermediate','advanced','elite')"`
+	Position            *string        `gorm:"type:VARCHAR(50)"`
+	PreferredDays       datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // e.g. ["monday","saturday"]
+	PreferredTimes      datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // e.g. ["morning","evening"]
+	MaxTravelDistanceKm *int           `gorm:"default:null"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// Rank returns the position of the level in the ordering, or -1 if unknown.
+func (l SportLevel) Rank() int {
+	switch l {
+	case SportLevelBeginner:
+		return 0
+	case SportLevelIntermediate:
+		return 1
+	case SportLevelAdvanced:
+		return 2
+	case SportLevelElite:
+		return 3
+	default:
+		return -1
+	}
+}
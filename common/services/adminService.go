@@ -0,0 +1,415 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+)
+
+// AdminUserFilters filters the admin user list.
+// Unlike GetUsers, deleted and suspended users are included.
+type AdminUserFilters struct {
+	Query     string // Matches email, first, last or full name
+	Role      models.UserRole
+	Suspended *bool
+	Limit     int
+	Offset    int
+}
+
+type AdminReportFilters struct {
+	Status     string
+	TargetType models.ReportTargetType
+	Limit      int
+	Offset     int
+}
+
+type AdminAuditLogFilters struct {
+	AdminID *uint
+	Action  models.AdminAction
+	Limit   int
+	Offset  int
+}
+
+// --- GET ---
+func GetAdminUsers(filters AdminUserFilters) ([]models.User, error) {
+	query := config.DB.Unscoped().Model(&models.User{})
+
+	if q := strings.TrimSpace(filters.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where(`(
+			email ILIKE ? OR
+			first_name ILIKE ? OR
+			last_name ILIKE ? OR
+			(first_name || ' ' || last_name) ILIKE ?
+		)`, like, like, like, like)
+	}
+
+	if filters.Role != "" {
+		query = query.Where("role = ?", filters.Role)
+	}
+
+	if filters.Suspended != nil {
+		if *filters.Suspended {
+			query = query.Where("suspended_until > ?", time.Now())
+		} else {
+			query = query.Where("suspended_until IS NULL OR suspended_until <= ?", time.Now())
+		}
+	}
+
+	var users []models.User
+	err := paginate(query, filters.Limit, filters.Offset).
+		Order("id desc").
+		Find(&users).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func GetAdminUserByID(userID uint) (*models.User, error) {
+	var user models.User
+	err := config.DB.Unscoped().
+		Preload("Settings").
+		First(&user, userID).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func GetAdminReports(filters AdminReportFilters) ([]models.Report, error) {
+	query := config.DB.
+		Preload("Reporter", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("ResolvedBy", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		})
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	if filters.TargetType != "" {
+		query = query.Where("target_type = ?", filters.TargetType)
+	}
+
+	var reports []models.Report
+	err := paginate(query, filters.Limit, filters.Offset).
+		Order("created_at desc").
+		Find(&reports).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func GetEulaVersions(locale string) ([]models.EulaVersion, error) {
+	query := config.DB.Model(&models.EulaVersion{})
+	if locale != "" {
+		query = query.Where("locale = ?", locale)
+	}
+
+	var versions []models.EulaVersion
+	err := query.Order("locale asc").
+		Order("created_at desc").
+		Find(&versions).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func GetAdminAuditLogs(filters AdminAuditLogFilters) ([]models.AdminAuditLog, error) {
+	query := config.DB.Preload("Admin", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+
+	if filters.AdminID != nil {
+		query = query.Where("admin_id = ?", *filters.AdminID)
+	}
+
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+
+	var logs []models.AdminAuditLog
+	err := paginate(query, filters.Limit, filters.Offset).
+		Order("created_at desc").
+		Order("id desc").
+		Find(&logs).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// --- POST ---
+
+// CreateEulaVersion adds a new EULA version. If Activate is set it replaces
+// the active version of its locale, so users have to accept it again.
+func CreateEulaVersion(adminID uint, req dto.EulaVersionCreateDto) (models.EulaVersion, error) {
+	sum := sha256.Sum256([]byte(req.Content))
+	version := models.EulaVersion{
+		Version:     req.Version,
+		Locale:      req.Locale,
+		Content:     req.Content,
+		ContentHash: hex.EncodeToString(sum[:]),
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&version).Error; err != nil {
+			if isDuplicateKeyError(tx, err) {
+				return appError.ErrEulaExists
+			}
+			return err
+		}
+
+		err := writeAuditLog(adminID, models.AdminActionEulaVersionCreated, "eula_version", version.ID, map[string]any{
+			"version": version.Version,
+			"locale":  version.Locale,
+		}, tx)
+
+		if err != nil {
+			return err
+		}
+
+		if !req.Activate {
+			return nil
+		}
+
+		return activateEulaVersion(adminID, &version, tx)
+	})
+
+	if err != nil {
+		return models.EulaVersion{}, err
+	}
+
+	return version, nil
+}
+
+func ActivateEulaVersion(adminID uint, versionID uint) (models.EulaVersion, error) {
+	var version models.EulaVersion
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&version, versionID).Error; err != nil {
+			return err
+		}
+
+		// Activating twice is a no-op
+		if version.IsActive {
+			return nil
+		}
+
+		return activateEulaVersion(adminID, &version, tx)
+	})
+
+	if err != nil {
+		return models.EulaVersion{}, err
+	}
+
+	return version, nil
+}
+
+// CreateSport adds a sport to the list users can choose from.
+// A sport that was deleted before is restored.
+func CreateSport(adminID uint, name string) (models.Sport, error) {
+	var sport models.Sport
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("LOWER(name) = LOWER(?)", name).
+			First(&sport).
+			Error
+
+		switch {
+		case err == nil && !sport.DeletedAt.Valid:
+			return appError.ErrSportExists
+		case err == nil:
+			if err := tx.Unscoped().Model(&sport).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			sport.DeletedAt = gorm.DeletedAt{}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			sport = models.Sport{Name: name}
+			if err := tx.Create(&sport).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return writeAuditLog(adminID, models.AdminActionSportCreated, "sport", sport.ID, map[string]any{
+			"name": sport.Name,
+		}, tx)
+	})
+
+	if err != nil {
+		return models.Sport{}, err
+	}
+
+	reloadSportsCache()
+
+	return sport, nil
+}
+
+// UpdateUserRole promotes or demotes a user. Admins can't demote themselves,
+// so there is always at least one admin left.
+func UpdateUserRole(adminID uint, userID uint, role models.UserRole) (*models.User, error) {
+	if role != models.UserRoleUser && role != models.UserRoleModerator && role != models.UserRoleAdmin {
+		return nil, appError.ErrBadRequest
+	}
+
+	if adminID == userID {
+		return nil, appError.ErrSameUser
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		previous := user.Role
+		if previous == role {
+			return nil
+		}
+
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		return writeAuditLog(adminID, models.AdminActionUserRoleUpdated, "user", userID, map[string]any{
+			"from": previous,
+			"to":   role,
+		}, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAdminUserByID(userID)
+}
+
+// --- DELETE ---
+// DeleteSport retires a sport. Existing favorites and profiles keep their rows,
+// but the sport is hidden and can no longer be picked.
+func DeleteSport(adminID uint, sportID uint) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var sport models.Sport
+		if err := tx.First(&sport, sportID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appError.ErrSportNotFound
+			}
+			return err
+		}
+
+		if err := tx.Delete(&sport).Error; err != nil {
+			return err
+		}
+
+		return writeAuditLog(adminID, models.AdminActionSportDeleted, "sport", sport.ID, map[string]any{
+			"name": sport.Name,
+		}, tx)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	reloadSportsCache()
+
+	return nil
+}
+
+// Package private methods
+
+// writeAuditLog records an admin action in the same transaction as the change itself.
+func writeAuditLog(adminID uint, action models.AdminAction, targetType string, targetID uint, details map[string]any, db *gorm.DB) error {
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&models.AdminAuditLog{
+		AdminID:    adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    data,
+	}).Error
+}
+
+// activateEulaVersion makes the version the only active one of its locale.
+func activateEulaVersion(adminID uint, version *models.EulaVersion, db *gorm.DB) error {
+	err := db.Model(&models.EulaVersion{}).
+		Where("locale = ? AND id <> ? AND is_active = ?", version.Locale, version.ID, true).
+		Update("is_active", false).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(version).Update("is_active", true).Error; err != nil {
+		return err
+	}
+
+	return writeAuditLog(adminID, models.AdminActionEulaVersionActivate, "eula_version", version.ID, map[string]any{
+		"version": version.Version,
+		"locale":  version.Locale,
+	}, db)
+}
+
+// reloadSportsCache refreshes the sport names used for validation.
+// Other instances pick up the change on their next restart.
+func reloadSportsCache() {
+	if err := config.LoadSportsCache(); err != nil {
+		slog.Error("Failed to reload sports cache", slog.Any("error", err))
+	}
+}
+
+// paginate applies limit (default 50, max 100) and offset.
+func paginate(query *gorm.DB, limit, offset int) *gorm.DB {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	query = query.Limit(limit)
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	return query
+}
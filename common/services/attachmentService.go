@@ -0,0 +1,343 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder for thumbnails
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+	"server/common/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"golang.org/x/image/draw"
+	"gorm.io/gorm"
+)
+
+const (
+	// Thumbnails fit in a square of this size
+	attachmentThumbnailSize = 320
+
+	// Larger images are stored without a thumbnail, decoding them takes too much memory
+	maxThumbnailSourcePixels = 16_000_000
+
+	// Each decoded image takes up to 4 bytes per pixel, so only a few are made at once
+	maxConcurrentThumbnails = 2
+
+	maxAttachmentFileNameLength = 255
+)
+
+// Uploads that aren't sent within this time can't be attached anymore, the cron job removes them
+const AttachmentUploadTTL = 24 * time.Hour
+
+// allowedAttachmentTypes are detected from the content, the name and header of the upload are ignored.
+var allowedAttachmentTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"application/pdf",
+}
+
+// thumbnailSlots limits how many thumbnails are made at the same time, uploads wait for a free slot.
+var thumbnailSlots = make(chan struct{}, maxConcurrentThumbnails)
+
+// attachmentStorage keeps the attachment files, it must be set before attachments are used.
+var attachmentStorage storage.Storage
+
+func SetAttachmentStorage(s storage.Storage) {
+	attachmentStorage = s
+}
+
+// MaxAttachmentSize returns the upload limit in bytes.
+func MaxAttachmentSize() int64 {
+	return int64(config.AppConfig.AttachmentMaxSizeMB) << 20
+}
+
+// --- GET ---
+
+// GetAttachment returns an attachment the user may download. Members see the attachments
+// of sent messages, except those of blocked users. Uploads that weren't sent are only visible to the uploader.
+func GetAttachment(conversationID, attachmentID, userID uint) (*models.MessageAttachment, error) {
+	isMember, err := IsConversationMember(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, appError.ErrNotConversationMember
+	}
+
+	var attachment models.MessageAttachment
+	err = config.DB.
+		Scopes(ExcludeBlockedUsersOn(userID, "uploader_id")).
+		Where("id = ? AND conversation_id = ?", attachmentID, conversationID).
+		Where("message_id IS NOT NULL OR uploader_id = ?", userID).
+		First(&attachment).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// OpenAttachment opens the file of the attachment, or its thumbnail.
+func OpenAttachment(attachment *models.MessageAttachment, thumbnail bool) (io.ReadCloser, error) {
+	key := attachment.StorageKey
+	if thumbnail {
+		if !attachment.IsImage() {
+			return nil, appError.ErrAttachmentNotFound
+		}
+		key = attachment.ThumbnailKey
+	}
+
+	r, err := attachmentStorage.Get(context.Background(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, appError.ErrAttachmentNotFound
+	}
+
+	return r, err
+}
+
+// --- POST ---
+
+// UploadAttachment stores a file for a message the user is about to send in the conversation.
+// Images get a thumbnail.
+func UploadAttachment(conversationID, userID uint, fileName string, data []byte) (*models.MessageAttachment, error) {
+	isMember, err := IsConversationMember(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, appError.ErrNotConversationMember
+	}
+
+	if int64(len(data)) > MaxAttachmentSize() {
+		return nil, appError.ErrAttachmentTooLarge
+	}
+
+	mime := mimetype.Detect(data)
+	if !mimetype.EqualsAny(mime.String(), allowedAttachmentTypes...) {
+		return nil, appError.ErrAttachmentTypeNotAllowed
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	keyPrefix := fmt.Sprintf("conversations/%d/%s", conversationID, token)
+	attachment := models.MessageAttachment{
+		ConversationID: conversationID,
+		UploaderID:     userID,
+		FileName:       attachmentFileName(fileName, mime.Extension()),
+		ContentType:    mime.String(),
+		Size:           int64(len(data)),
+		StorageKey:     keyPrefix + mime.Extension(),
+	}
+
+	ctx := context.Background()
+	if err := attachmentStorage.Put(ctx, attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		thumbnail, width, height, err := makeThumbnail(data, attachment.ContentType)
+		if err != nil {
+			slog.Warn("Failed to create attachment thumbnail", slog.Any("error", err))
+		} else {
+			attachment.Width = width
+			attachment.Height = height
+			attachment.ThumbnailKey = keyPrefix + "_thumb" + thumbnailExtension(attachment.ContentType)
+
+			if err := attachmentStorage.Put(ctx, attachment.ThumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+				deleteAttachmentFiles([]models.MessageAttachment{attachment})
+				return nil, err
+			}
+		}
+	}
+
+	if err := config.DB.Create(&attachment).Error; err != nil {
+		deleteAttachmentFiles([]models.MessageAttachment{attachment})
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// --- DELETE ---
+
+// DeleteOrphanedAttachments removes uploads created before the given time that were never sent,
+// and attachments of hard deleted messages, including their files. Returns how many were removed.
+func DeleteOrphanedAttachments(createdBefore time.Time) (int64, error) {
+	var attachments []models.MessageAttachment
+	err := config.DB.
+		Where("message_id IS NULL AND created_at < ?", createdBefore).
+		Find(&attachments).
+		Error
+
+	if err != nil || len(attachments) == 0 {
+		return 0, err
+	}
+
+	if err := config.DB.Delete(&attachments).Error; err != nil {
+		return 0, err
+	}
+
+	deleteAttachmentFiles(attachments)
+
+	return int64(len(attachments)), nil
+}
+
+// Package private methods
+
+// attachToMessage attaches the user's recent uploads to the message.
+// Fails with ErrAttachmentNotFound if any of them is unknown, sent already, or from another conversation.
+func attachToMessage(message *models.Message, attachmentIDs []uint, db *gorm.DB) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+
+	result := db.Model(&models.MessageAttachment{}).
+		Where("id IN ?", attachmentIDs).
+		Where("conversation_id = ? AND uploader_id = ?", *message.ConversationID, message.SenderID).
+		Where("message_id IS NULL AND created_at > ?", time.Now().Add(-AttachmentUploadTTL)).
+		Update("message_id", message.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	unique := make(map[uint]bool, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		unique[id] = true
+	}
+
+	if result.RowsAffected != int64(len(unique)) {
+		return appError.ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+// deleteAttachmentFiles removes the stored files. Failures are logged, the cron job can't retry them.
+func deleteAttachmentFiles(attachments []models.MessageAttachment) {
+	ctx := context.Background()
+
+	for _, a := range attachments {
+		for _, key := range []string{a.StorageKey, a.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+
+			if err := attachmentStorage.Delete(ctx, key); err != nil {
+				slog.Error("Failed to delete attachment file",
+					slog.Uint64("attachment_id", uint64(a.ID)),
+					slog.String("key", key),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}
+}
+
+// attachmentFileName keeps the base name of the uploaded file without control characters, or makes one up.
+func attachmentFileName(name, extension string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment" + extension
+	}
+
+	if runes := []rune(name); len(runes) > maxAttachmentFileNameLength {
+		name = string(runes[:maxAttachmentFileNameLength])
+	}
+
+	return name
+}
+
+// makeThumbnail scales the image down to fit attachmentThumbnailSize.
+// JPEGs stay JPEGs, other images become PNGs to keep their transparency.
+func makeThumbnail(data []byte, contentType string) ([]byte, int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if cfg.Width*cfg.Height > maxThumbnailSourcePixels {
+		return nil, 0, 0, errors.New("image is too large for a thumbnail")
+	}
+
+	thumbnailSlots <- struct{}{}
+	defer func() { <-thumbnailSlots }()
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	thumbnail := scaleDown(img, attachmentThumbnailSize)
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(&buf, thumbnail)
+	}
+
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return buf.Bytes(), cfg.Width, cfg.Height, nil
+}
+
+func thumbnailExtension(contentType string) string {
+	if contentType == "image/jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// scaleDown resizes the image to fit a square of maxSize, keeping its aspect ratio.
+// Images that already fit are returned as is.
+func scaleDown(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	dstWidth, dstHeight := maxSize, maxSize
+	if width > height {
+		dstHeight = max(1, height*maxSize/width)
+	} else {
+		dstWidth = max(1, width*maxSize/height)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
@@ -3,12 +3,17 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
+	"strings"
+	"sync"
 	"time"
 
 	"server/common/appError"
 	"server/common/config"
+	"server/common/jwtkeys"
 	"server/common/models"
 
 	firebase "firebase.google.com/go/v4"
@@ -19,67 +24,128 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// How long an email verification code is valid
+	emailVerificationCodeTTL = 24 * time.Hour
+	// Minimum time between two verification emails to the same user
+	emailVerificationResendInterval = 1 * time.Minute
+)
+
+// The keyset is rebuilt whenever the JWT config changes
+var (
+	jwtKeySetMu     sync.Mutex
+	jwtKeySet       *jwtkeys.KeySet
+	jwtKeySetConfig [3]string
+)
+
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	SessionID uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func Login(email, password string) (*models.User, string, error) {
+func Login(email, password string, client SessionClient) (*models.User, AuthTokens, error) {
+	subjects := loginThrottleSubjects(email, client.IPAddress)
+	if err := checkThrottle(subjects...); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
 	var user models.User
 
-	err := config.DB.Where("email = ?", email).
+	// Include deleted accounts, logging in within the grace period restores them
+	err := config.DB.Unscoped().
+		Where("email = ?", email).
 		Preload("Settings").
 		First(&user).
 		Error
 
 	if err != nil {
-		return nil, "", appError.ErrInvalidCredentials
+		return nil, AuthTokens{}, failedAttempt(subjects...)
 	}
 
 	// OAuth users don't have passwords
 	if user.AuthProvider != "" {
-		return nil, "", appError.ErrInvalidCredentials
+		return nil, AuthTokens{}, failedAttempt(subjects...)
 	}
 
 	// Regular users must have a password
 	if user.Password == nil {
-		return nil, "", appError.ErrInvalidCredentials
+		return nil, AuthTokens{}, failedAttempt(subjects...)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password))
 	if err != nil {
-		return nil, "", appError.ErrInvalidCredentials
+		return nil, AuthTokens{}, failedAttempt(subjects...)
+	}
+
+	// Only tell the user about a ban or suspension once the password is known to be right
+	if err := CheckAccountStatus(&user); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	// The second step is required before a session is started.
+	// The throttle is kept, so wrong codes keep counting towards the lockout.
+	// A deleted account is only restored once the code is verified as well.
+	if user.TOTPEnabledAt != nil {
+		mfaErr, err := createMFAChallenge(user.ID)
+		if err != nil {
+			return nil, AuthTokens{}, err
+		}
+		return nil, AuthTokens{}, mfaErr
 	}
 
-	token, err := GenerateJWTToken(&user)
+	if err := restoreDeletedAccount(&user); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	// Only the account is cleared, a valid login must not reset the IP limit
+	if err := clearThrottle(subjects[:2]...); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	tokens, err := CreateSession(&user, client)
 	if err != nil {
-		return nil, "", err
+		return nil, AuthTokens{}, err
 	}
 
-	return &user, token, nil
+	return &user, tokens, nil
 }
 
-func GenerateJWTToken(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(config.AppConfig.JWTExpirationHours) * time.Hour)
+// GenerateJWTToken issues a short-lived access token for the given session.
+func GenerateJWTToken(user *models.User, sessionID uint) (string, time.Time, error) {
+	expirationTime := time.Now().Add(time.Duration(config.AppConfig.AccessTokenExpirationMinutes) * time.Minute)
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
+		UserID:    user.ID,
+		Email:     user.Email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+	keys, err := JWTKeySet()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signed, err := keys.Sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expirationTime, nil
 }
 
 func ValidateJWTToken(tokenString string) (*Claims, error) {
+	keys, err := JWTKeySet()
+	if err != nil {
+		return nil, err
+	}
+
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
 
 	if err != nil {
 		return nil, err
@@ -92,8 +158,29 @@ func ValidateJWTToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// generateResetCode generates a random 6-digit code for password reset
-func generateResetCode() (string, error) {
+// JWTKeySet returns the keys configured for signing and verifying access tokens.
+func JWTKeySet() (*jwtkeys.KeySet, error) {
+	jwtKeySetMu.Lock()
+	defer jwtKeySetMu.Unlock()
+
+	cfg := [3]string{config.AppConfig.JWTKeys, config.AppConfig.JWTActiveKeyID, config.AppConfig.JWTSecret}
+	if jwtKeySet != nil && cfg == jwtKeySetConfig {
+		return jwtKeySet, nil
+	}
+
+	keys, err := jwtkeys.Parse(cfg[0], cfg[1], cfg[2])
+	if err != nil {
+		return nil, err
+	}
+
+	jwtKeySet = keys
+	jwtKeySetConfig = cfg
+
+	return keys, nil
+}
+
+// generateCode generates a random 6-digit code for password reset and email verification
+func generateCode() (string, error) {
 	code := ""
 	for i := 0; i < 6; i++ {
 		num, err := rand.Int(rand.Reader, big.NewInt(10))
@@ -105,8 +192,18 @@ func generateResetCode() (string, error) {
 	return code, nil
 }
 
-// RequestPasswordReset generates a reset code, stores it in the database, and sends an email
-func RequestPasswordReset(email string) error {
+// RequestPasswordReset generates a reset code, stores it in the database, and sends an email.
+// Every request counts towards the limit, whether the account exists or not.
+func RequestPasswordReset(email, ip string) error {
+	subjects := throttleSubjects(resetRequestAccountPolicy, resetRequestIPPolicy, email, ip)
+	if err := checkThrottle(subjects...); err != nil {
+		return err
+	}
+
+	if _, err := recordFailure(subjects...); err != nil {
+		return err
+	}
+
 	var user models.User
 
 	// Disable prepared statements to avoid cached plan issues after schema changes
@@ -119,7 +216,7 @@ func RequestPasswordReset(email string) error {
 	}
 
 	// Generate 6-digit reset code
-	resetCode, err := generateResetCode()
+	resetCode, err := generateCode()
 	if err != nil {
 		return fmt.Errorf("failed to generate reset code: %w", err)
 	}
@@ -136,6 +233,12 @@ func RequestPasswordReset(email string) error {
 		return fmt.Errorf("failed to save reset code: %w", err)
 	}
 
+	// A new code gets a fresh set of guesses
+	err = clearThrottle(throttleSubject{policy: resetCodeAccountPolicy, subject: email})
+	if err != nil {
+		return err
+	}
+
 	// Send email with reset code
 	err = SendPasswordResetEmail(email, resetCode)
 	if err != nil {
@@ -145,30 +248,36 @@ func RequestPasswordReset(email string) error {
 	return nil
 }
 
-// ResetPassword validates the reset code and updates the user's password
-func ResetPassword(email, resetCode, newPassword string) error {
+// ResetPassword validates the reset code and updates the user's password.
+// Guesses are capped, once the limit is reached the code is invalidated and a new one must be requested.
+func ResetPassword(email, resetCode, newPassword, ip string) error {
+	subjects := throttleSubjects(resetCodeAccountPolicy, resetCodeIPPolicy, email, ip)
+	if err := checkThrottle(subjects...); err != nil {
+		return err
+	}
+
 	var user models.User
 
 	// Disable prepared statements to avoid cached plan issues after schema changes
 	err := config.DB.Session(&gorm.Session{PrepareStmt: false}).
 		Where("email = ?", email).First(&user).Error
 	if err != nil {
-		return appError.ErrInvalidCredentials
+		return failedAttempt(subjects...)
 	}
 
 	// Check if reset code exists
 	if user.PasswordResetCode == "" {
-		return appError.ErrInvalidCredentials
+		return failedAttempt(subjects...)
 	}
 
 	// Check if reset code matches
 	if user.PasswordResetCode != resetCode {
-		return appError.ErrInvalidCredentials
+		return failedResetCode(&user, subjects)
 	}
 
 	// Check if reset code has expired
 	if user.PasswordResetCodeExpiresAt == nil || time.Now().After(*user.PasswordResetCodeExpiresAt) {
-		return appError.ErrInvalidCredentials
+		return failedAttempt(subjects...)
 	}
 
 	// Hash new password
@@ -188,6 +297,294 @@ func ResetPassword(email, resetCode, newPassword string) error {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	// Log out everywhere, the old password may have been compromised
+	if err := RevokeAllSessions(user.ID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	// The user proved ownership of the account, so its lockouts are lifted
+	lifted := append(loginThrottleSubjects(email, ip)[:2],
+		throttleSubject{policy: resetRequestAccountPolicy, subject: email},
+		throttleSubject{policy: resetCodeAccountPolicy, subject: email},
+	)
+	return clearThrottle(lifted...)
+}
+
+// failedResetCode records a wrong reset code guess and invalidates the code once the guesses are used up.
+func failedResetCode(user *models.User, subjects []throttleSubject) error {
+	return failedCodeGuess(user, subjects, appError.ErrInvalidCredentials, "password_reset_code", "password_reset_code_expires_at")
+}
+
+// failedCodeGuess records a wrong code guess and returns codeErr.
+// Once a subject is locked the code and its expiry are cleared, so a new code must be requested.
+func failedCodeGuess(user *models.User, subjects []throttleSubject, codeErr error, codeColumn, expiresColumn string) error {
+	locked, err := recordFailure(subjects...)
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		err := config.DB.Model(user).Updates(map[string]any{
+			codeColumn:    "",
+			expiresColumn: nil,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return codeErr
+}
+
+// RegisterUser creates a password account and sends it an email verification code.
+// The account is usable right away, but restricted until the email is verified.
+func RegisterUser(newUser models.User, password string) (*models.User, error) {
+	user, err := createUser(newUser, password, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user can request a new code, so a failed email doesn't fail the registration
+	if err := sendEmailVerificationCode(user); err != nil {
+		slog.Error("Failed to send email verification code",
+			slog.Uint64("user_id", uint64(user.ID)),
+			slog.Any("error", err),
+		)
+	}
+
+	return user, nil
+}
+
+// RequestEmailVerification sends a new verification code to the user.
+// Resends are throttled by emailVerificationResendInterval.
+func RequestEmailVerification(userID uint) error {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return appError.ErrEmailAlreadyVerified
+	}
+
+	if user.EmailVerificationSentAt != nil &&
+		time.Since(*user.EmailVerificationSentAt) < emailVerificationResendInterval {
+		return appError.ErrTooManyRequests
+	}
+
+	return sendEmailVerificationCode(&user)
+}
+
+// VerifyEmail validates the verification code and marks the user's email as verified
+func VerifyEmail(userID uint, code string) error {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return appError.ErrEmailAlreadyVerified
+	}
+
+	subject := userThrottleSubject(verifyEmailCodePolicy, user.ID)
+	if err := checkThrottle(subject); err != nil {
+		return err
+	}
+
+	if user.EmailVerificationCode == "" {
+		return appError.ErrInvalidVerificationCode
+	}
+
+	if user.EmailVerificationCode != code {
+		return failedCodeGuess(&user, []throttleSubject{subject}, appError.ErrInvalidVerificationCode,
+			"email_verification_code", "email_verification_code_expires_at")
+	}
+
+	if user.EmailVerificationCodeExpiresAt == nil || time.Now().After(*user.EmailVerificationCodeExpiresAt) {
+		return appError.ErrInvalidVerificationCode
+	}
+
+	err := config.DB.Model(&user).Updates(map[string]any{
+		"email_verified":                     true,
+		"email_verification_code":            "",
+		"email_verification_code_expires_at": nil,
+	}).Error
+
+	if err != nil {
+		return err
+	}
+
+	return clearThrottle(subject)
+}
+
+// RequestEmailChange sends a code to the new address and a security notice to the old one.
+// The user must confirm their password.
+// OAuth accounts are signed in by the email their provider reports, so only password accounts can change it.
+func RequestEmailChange(userID uint, newEmail, password string) error {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	if user.AuthProvider != "" {
+		return appError.ErrEmailManagedByProvider
+	}
+
+	if user.Password == nil ||
+		bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)) != nil {
+		return appError.ErrInvalidCredentials
+	}
+
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if strings.EqualFold(newEmail, user.Email) {
+		return appError.ErrBadRequest
+	}
+
+	// Deleted accounts keep their email during the grace period
+	var count int64
+	err := config.DB.Unscoped().
+		Model(&models.User{}).
+		Where("LOWER(email) = ?", newEmail).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return appError.ErrUserExists
+	}
+
+	// Shares the throttle with email verification
+	if user.EmailVerificationSentAt != nil &&
+		time.Since(*user.EmailVerificationSentAt) < emailVerificationResendInterval {
+		return appError.ErrTooManyRequests
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(emailVerificationCodeTTL)
+
+	err = config.DB.Model(&user).Updates(map[string]any{
+		"pending_email":                 newEmail,
+		"pending_email_code":            code,
+		"pending_email_code_expires_at": expiresAt,
+		"email_verification_sent_at":    now,
+	}).Error
+
+	if err != nil {
+		return fmt.Errorf("failed to save email change: %w", err)
+	}
+
+	if err := SendEmailChangeVerificationEmail(newEmail, code); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	// The code is already on its way, so a failed notice doesn't fail the request
+	if err := SendEmailChangeNoticeEmail(user.Email, newEmail); err != nil {
+		slog.Error("Failed to send email change notice",
+			slog.Uint64("user_id", uint64(user.ID)),
+			slog.Any("error", err),
+		)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange validates the code sent to the pending address and makes it the user's email.
+// The address may have been taken since the request, which the unique constraint on email catches.
+func ConfirmEmailChange(userID uint, code string) error {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	if user.AuthProvider != "" {
+		return appError.ErrEmailManagedByProvider
+	}
+
+	subject := userThrottleSubject(emailChangeCodePolicy, user.ID)
+	if err := checkThrottle(subject); err != nil {
+		return err
+	}
+
+	if user.PendingEmail == nil || user.PendingEmailCode == "" {
+		return appError.ErrInvalidVerificationCode
+	}
+
+	if user.PendingEmailCode != code {
+		return failedCodeGuess(&user, []throttleSubject{subject}, appError.ErrInvalidVerificationCode,
+			"pending_email_code", "pending_email_code_expires_at")
+	}
+
+	if user.PendingEmailCodeExpiresAt == nil || time.Now().After(*user.PendingEmailCodeExpiresAt) {
+		return appError.ErrInvalidVerificationCode
+	}
+
+	err := config.DB.Model(&user).Updates(map[string]any{
+		"email":                              *user.PendingEmail,
+		"email_verified":                     true,
+		"email_verification_code":            "",
+		"email_verification_code_expires_at": nil,
+		"pending_email":                      nil,
+		"pending_email_code":                 "",
+		"pending_email_code_expires_at":      nil,
+	}).Error
+
+	if isDuplicateKeyError(config.DB, err) {
+		return appError.ErrUserExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return clearThrottle(subject)
+}
+
+// isDuplicateKeyError reports whether err is a unique constraint violation
+func isDuplicateKeyError(db *gorm.DB, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// sendEmailVerificationCode stores a new verification code and emails it to the user
+func sendEmailVerificationCode(user *models.User) error {
+	code, err := generateCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(emailVerificationCodeTTL)
+
+	err = config.DB.Model(user).Updates(map[string]any{
+		"email_verification_code":            code,
+		"email_verification_code_expires_at": expiresAt,
+		"email_verification_sent_at":         now,
+	}).Error
+
+	if err != nil {
+		return fmt.Errorf("failed to save verification code: %w", err)
+	}
+
+	if err := SendEmailVerificationEmail(user.Email, code); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
 	return nil
 }
 
@@ -212,11 +609,11 @@ func getFirebaseAuthClient() (*auth.Client, error) {
 	return authClient, nil
 }
 
-// AuthenticateWithGoogle verifies a Google Firebase ID token and returns user with JWT token
-func AuthenticateWithGoogle(idToken string) (*models.User, string, error) {
+// AuthenticateWithGoogle verifies a Google Firebase ID token and returns user with session tokens
+func AuthenticateWithGoogle(idToken string, client SessionClient) (*models.User, AuthTokens, error) {
 	authClient, err := getFirebaseAuthClient()
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get Firebase Auth client: %w", err)
+		return nil, AuthTokens{}, fmt.Errorf("failed to get Firebase Auth client: %w", err)
 	}
 
 	ctx := context.Background()
@@ -224,7 +621,7 @@ func AuthenticateWithGoogle(idToken string) (*models.User, string, error) {
 	// Verify the Firebase ID token
 	token, err := authClient.VerifyIDToken(ctx, idToken)
 	if err != nil {
-		return nil, "", appError.ErrInvalidToken
+		return nil, AuthTokens{}, appError.ErrInvalidToken
 	}
 
 	// Extract user information from the token
@@ -232,29 +629,29 @@ func AuthenticateWithGoogle(idToken string) (*models.User, string, error) {
 
 	email, ok := claims["email"].(string)
 	if !ok || email == "" {
-		return nil, "", fmt.Errorf("email not found in token")
+		return nil, AuthTokens{}, fmt.Errorf("email not found in token")
 	}
 
 	// Get or create user
 	user, err := getOrCreateOAuthUser(email, "google", claims)
 	if err != nil {
-		return nil, "", err
+		return nil, AuthTokens{}, err
 	}
 
-	// Generate JWT token
-	jwtToken, err := GenerateJWTToken(user)
+	// Start a session for this device
+	tokens, err := CreateSession(user, client)
 	if err != nil {
-		return nil, "", err
+		return nil, AuthTokens{}, err
 	}
 
-	return user, jwtToken, nil
+	return user, tokens, nil
 }
 
-// AuthenticateWithApple verifies an Apple Firebase ID token and returns user with JWT token
-func AuthenticateWithApple(idToken string, email, firstName, lastName *string) (*models.User, string, error) {
+// AuthenticateWithApple verifies an Apple Firebase ID token and returns user with session tokens
+func AuthenticateWithApple(idToken string, email, firstName, lastName *string, client SessionClient) (*models.User, AuthTokens, error) {
 	authClient, err := getFirebaseAuthClient()
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get Firebase Auth client: %w", err)
+		return nil, AuthTokens{}, fmt.Errorf("failed to get Firebase Auth client: %w", err)
 	}
 
 	ctx := context.Background()
@@ -262,7 +659,7 @@ func AuthenticateWithApple(idToken string, email, firstName, lastName *string) (
 	// Verify the Firebase ID token
 	token, err := authClient.VerifyIDToken(ctx, idToken)
 	if err != nil {
-		return nil, "", appError.ErrInvalidToken
+		return nil, AuthTokens{}, appError.ErrInvalidToken
 	}
 
 	// Extract user information from the token
@@ -272,7 +669,7 @@ func AuthenticateWithApple(idToken string, email, firstName, lastName *string) (
 	if !ok || tokenEmail == "" {
 		// If email is not in token, use the provided email (Apple only provides email on first sign-in)
 		if email == nil || *email == "" {
-			return nil, "", fmt.Errorf("email not found in token or request")
+			return nil, AuthTokens{}, fmt.Errorf("email not found in token or request")
 		}
 		tokenEmail = *email
 	}
@@ -280,7 +677,7 @@ func AuthenticateWithApple(idToken string, email, firstName, lastName *string) (
 	// Get or create user
 	user, err := getOrCreateOAuthUser(tokenEmail, "apple", claims)
 	if err != nil {
-		return nil, "", err
+		return nil, AuthTokens{}, err
 	}
 
 	// Update user with provided name information (Apple only provides this on first sign-in)
@@ -297,36 +694,48 @@ func AuthenticateWithApple(idToken string, email, firstName, lastName *string) (
 		if updateNeeded {
 			err = config.DB.Save(user).Error
 			if err != nil {
-				return nil, "", fmt.Errorf("failed to update user: %w", err)
+				return nil, AuthTokens{}, fmt.Errorf("failed to update user: %w", err)
 			}
 		}
 	}
 
-	// Generate JWT token
-	jwtToken, err := GenerateJWTToken(user)
+	// Start a session for this device
+	tokens, err := CreateSession(user, client)
 	if err != nil {
-		return nil, "", err
+		return nil, AuthTokens{}, err
 	}
 
-	return user, jwtToken, nil
+	return user, tokens, nil
 }
 
 // getOrCreateOAuthUser gets an existing OAuth user or creates a new one
 func getOrCreateOAuthUser(email, provider string, claims map[string]interface{}) (*models.User, error) {
 	var user models.User
 
-	// Try to find existing user
-	err := config.DB.Where("email = ?", email).
+	// Try to find existing user, including deleted accounts within the grace period
+	err := config.DB.Unscoped().
+		Where("email = ?", email).
 		Preload("Settings").
 		First(&user).
 		Error
 
 	if err == nil {
+		// Banned and suspended users can't sign in, nor restore a deleted account
+		if err := CheckAccountStatus(&user); err != nil {
+			return nil, err
+		}
+
 		// User exists
 		// If user is OAuth user with different provider, that's an error
+		// Checked before restoring, so a sign-in that fails doesn't restore a deleted account
 		if user.AuthProvider != "" && user.AuthProvider != provider {
 			return nil, fmt.Errorf("email already registered with %s", user.AuthProvider)
 		}
+
+		if err := restoreDeletedAccount(&user); err != nil {
+			return nil, err
+		}
+
 		// If user exists but doesn't have auth provider set, update it
 		if user.AuthProvider == "" {
 			user.AuthProvider = provider
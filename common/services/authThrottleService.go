@@ -0,0 +1,212 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// throttlePolicy limits failed attempts within a scope.
+// Once MaxFailures is reached the subject is locked for BaseLockout,
+// and every further failure doubles the lockout up to maxLockout.
+type throttlePolicy struct {
+	Scope       string
+	MaxFailures int
+	BaseLockout time.Duration
+}
+
+var (
+	loginSourcePolicy         = throttlePolicy{Scope: "login:source", MaxFailures: 5, BaseLockout: 1 * time.Minute}
+	loginAccountPolicy        = throttlePolicy{Scope: "login:account", MaxFailures: 50, BaseLockout: 15 * time.Minute}
+	loginIPPolicy             = throttlePolicy{Scope: "login:ip", MaxFailures: 20, BaseLockout: 1 * time.Minute}
+	resetRequestAccountPolicy = throttlePolicy{Scope: "reset-request:account", MaxFailures: 3, BaseLockout: 5 * time.Minute}
+	resetRequestIPPolicy      = throttlePolicy{Scope: "reset-request:ip", MaxFailures: 10, BaseLockout: 5 * time.Minute}
+	resetCodeAccountPolicy    = throttlePolicy{Scope: "reset-code:account", MaxFailures: 5, BaseLockout: 15 * time.Minute}
+	resetCodeIPPolicy         = throttlePolicy{Scope: "reset-code:ip", MaxFailures: 20, BaseLockout: 15 * time.Minute}
+	verifyEmailCodePolicy     = throttlePolicy{Scope: "verify-email-code:user", MaxFailures: 5, BaseLockout: 15 * time.Minute}
+	emailChangeCodePolicy     = throttlePolicy{Scope: "email-change-code:user", MaxFailures: 5, BaseLockout: 15 * time.Minute}
+)
+
+const (
+	// Failures older than this are forgotten
+	throttleFailureWindow = 24 * time.Hour
+	// Upper bound for the exponential lockout
+	maxLockout = 24 * time.Hour
+)
+
+// throttleSubject is a policy applied to a specific account or IP address
+type throttleSubject struct {
+	policy  throttlePolicy
+	subject string
+}
+
+func (s throttleSubject) key() string {
+	return fmt.Sprintf("%s:%s", s.policy.Scope, strings.ToLower(s.subject))
+}
+
+// throttleSubjects pairs an account policy with the email and an IP policy with the client IP.
+// The IP is skipped when unknown.
+func throttleSubjects(accountPolicy, ipPolicy throttlePolicy, email, ip string) []throttleSubject {
+	subjects := []throttleSubject{{policy: accountPolicy, subject: email}}
+	if ip != "" {
+		subjects = append(subjects, throttleSubject{policy: ipPolicy, subject: ip})
+	}
+	return subjects
+}
+
+// loginThrottleSubjects returns the subjects a login attempt counts towards:
+//   - The account from this IP, so an attacker locks out their own source rather than the account owner
+//   - The account from any IP, a higher limit that stops guessing spread over many addresses
+//   - The IP across all accounts
+//
+// The tradeoff is that the account-wide limit can still be used to lock out the owner,
+// but only by an attacker sending many failed logins, from more addresses than the IP limit allows.
+// The IP is skipped when unknown, the account alone is then the source.
+func loginThrottleSubjects(email, ip string) []throttleSubject {
+	source := email
+	if ip != "" {
+		source = email + "|" + ip
+	}
+
+	subjects := []throttleSubject{
+		{policy: loginSourcePolicy, subject: source},
+		{policy: loginAccountPolicy, subject: email},
+	}
+	if ip != "" {
+		subjects = append(subjects, throttleSubject{policy: loginIPPolicy, subject: ip})
+	}
+	return subjects
+}
+
+// userThrottleSubject applies a policy to a signed in user, for codes that are checked by user rather than by email.
+func userThrottleSubject(policy throttlePolicy, userID uint) throttleSubject {
+	return throttleSubject{policy: policy, subject: fmt.Sprint(userID)}
+}
+
+// checkThrottle returns a RateLimitError if any of the subjects is locked out.
+func checkThrottle(subjects ...throttleSubject) error {
+	keys := make([]string, len(subjects))
+	for i, s := range subjects {
+		keys[i] = s.key()
+	}
+
+	var throttles []models.AuthThrottle
+	err := config.DB.
+		Where("key IN ? AND locked_until > ?", keys, time.Now()).
+		Find(&throttles).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	var retryAfter time.Duration
+	for _, t := range throttles {
+		retryAfter = max(retryAfter, time.Until(*t.LockedUntil))
+	}
+
+	if retryAfter > 0 {
+		return &appError.RateLimitError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// recordFailure counts a failed attempt for each subject and locks the ones over their limit.
+// It returns true if any of the subjects became locked.
+func recordFailure(subjects ...throttleSubject) (bool, error) {
+	locked := false
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, s := range subjects {
+			isLocked, err := recordSubjectFailure(s, tx)
+			if err != nil {
+				return err
+			}
+			locked = locked || isLocked
+		}
+		return nil
+	})
+
+	return locked, err
+}
+
+// failedAttempt records a failed credential check and returns ErrInvalidCredentials.
+func failedAttempt(subjects ...throttleSubject) error {
+	if _, err := recordFailure(subjects...); err != nil {
+		slog.Error("Failed to record failed authentication attempt", slog.Any("error", err))
+	}
+
+	return appError.ErrInvalidCredentials
+}
+
+// clearThrottle forgets the failures of the subjects, e.g. after a successful login.
+func clearThrottle(subjects ...throttleSubject) error {
+	keys := make([]string, len(subjects))
+	for i, s := range subjects {
+		keys[i] = s.key()
+	}
+
+	return config.DB.
+		Where("key IN ?", keys).
+		Delete(&models.AuthThrottle{}).
+		Error
+}
+
+func recordSubjectFailure(s throttleSubject, db *gorm.DB) (bool, error) {
+	now := time.Now()
+	throttle := models.AuthThrottle{
+		Key:           s.key(),
+		Failures:      1,
+		LastFailureAt: now,
+	}
+
+	// Atomic upsert, so concurrent attempts from several instances are all counted
+	err := db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"failures": gorm.Expr(
+					"CASE WHEN auth_throttles.last_failure_at < ? THEN 1 ELSE auth_throttles.failures + 1 END",
+					now.Add(-throttleFailureWindow),
+				),
+				"last_failure_at": now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&throttle).Error
+
+	if err != nil {
+		return false, err
+	}
+
+	if throttle.Failures < s.policy.MaxFailures {
+		return false, nil
+	}
+
+	lockout := lockoutDuration(s.policy, throttle.Failures)
+	err = db.Model(&models.AuthThrottle{}).
+		Where("key = ?", throttle.Key).
+		Update("locked_until", now.Add(lockout)).
+		Error
+
+	return true, err
+}
+
+// lockoutDuration doubles the base lockout for every failure past the limit.
+func lockoutDuration(policy throttlePolicy, failures int) time.Duration {
+	lockout := policy.BaseLockout
+	for i := policy.MaxFailures; i < failures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, maxLockout)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+)
+
+// DiscoverChallenges returns upcoming public challenges matching the current user's sport profiles.
+// - Only sports the user has a profile (or favorite) for are included
+// - If origin is given, challenges further away than the profile's max travel distance are skipped
+// - Results are ranked by how well they fit the preferred days and times, then by start time
+func DiscoverChallenges(currentUserID uint, origin *models.Point) ([]models.Challenge, error) {
+	var user models.User
+	err := config.DB.
+		Preload("FavoriteSports").
+		Preload("SportProfiles.Sport").
+		First(&user, currentUserID).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Favorites without a profile are included with no preferences
+	profiles := make(map[string]models.UserSportProfile)
+	for _, s := range user.FavoriteSports {
+		profiles[strings.ToLower(s.Name)] = models.UserSportProfile{Sport: s}
+	}
+	for _, p := range user.SportProfiles {
+		profiles[strings.ToLower(p.Sport.Name)] = p
+	}
+
+	if len(profiles) == 0 {
+		return []models.Challenge{}, nil
+	}
+
+	sportNames := make([]string, 0, len(profiles))
+	for name := range profiles {
+		sportNames = append(sportNames, name)
+	}
+
+	var challenges []models.Challenge
+	err = config.DB.
+		Scopes(ExcludeBlockedUsersOn(currentUserID, "creator_id"), HideChallengesOfRestrictedCreators(currentUserID)).
+		Preload("Users", ExcludeBlockedUsers(currentUserID)).
+		Preload("Users.Settings").
+		Preload("Teams").
+		Preload("Creator").
+		Preload("Creator.Settings").
+		Preload("Location").
+		Preload("Facility").
+		Where("is_public = ?", true).
+		Where("start_time > ?", time.Now()).
+		Where("status IN ?", []models.ChallengeStatus{models.ChallengeStatusOpen, models.ChallengeStatusPending}).
+		Where("LOWER(sport) IN ?", sportNames).
+		Where("id NOT IN (SELECT challenge_id FROM user_challenges WHERE user_id = ?)", currentUserID).
+		Find(&challenges).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	type scoredChallenge struct {
+		challenge models.Challenge
+		score     int
+	}
+
+	scored := make([]scoredChallenge, 0, len(challenges))
+	for _, c := range challenges {
+		profile := profiles[strings.ToLower(c.Sport)]
+
+		if origin != nil && profile.MaxTravelDistanceKm != nil {
+			if distanceKm(*origin, c.Location.Coordinates) > float64(*profile.MaxTravelDistanceKm) {
+				continue
+			}
+		}
+
+		scored = append(scored, scoredChallenge{
+			challenge: c,
+			score:     scheduleScore(profile, c.StartTime),
+		})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].challenge.StartTime.Before(scored[j].challenge.StartTime)
+	})
+
+	result := make([]models.Challenge, len(scored))
+	for i, s := range scored {
+		result[i] = s.challenge
+	}
+
+	return result, nil
+}
+
+// scheduleLocation is the time zone preferred days and times are matched in, loaded once.
+var scheduleLocation = sync.OnceValue(func() *time.Location {
+	loc, err := time.LoadLocation("Europe/Copenhagen")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+})
+
+// scheduleScore rates how well a start time fits the profile.
+// Weights: Preferred Day (2) > Preferred Time (1)
+func scheduleScore(profile models.UserSportProfile, startTime time.Time) int {
+	local := startTime.In(scheduleLocation())
+
+	score := 0
+
+	day := strings.ToLower(local.Weekday().String())
+	for _, d := range dto.StringsFromJSON(profile.PreferredDays) {
+		if d == day {
+			score += 2
+			break
+		}
+	}
+
+	slot := timeSlot(local.Hour())
+	for _, t := range dto.StringsFromJSON(profile.PreferredTimes) {
+		if slot != "" && t == slot {
+			score += 1
+			break
+		}
+	}
+
+	return score
+}
+
+// timeSlot maps an hour of the day to a preferred time slot, or "" at night.
+func timeSlot(hour int) string {
+	switch {
+	case hour >= 6 && hour < 12:
+		return models.TimeSlotMorning
+	case hour >= 12 && hour < 17:
+		return models.TimeSlotAfternoon
+	case hour >= 17 && hour < 23:
+		return models.TimeSlotEvening
+	default:
+		return ""
+	}
+}
+
+// distanceKm returns the great-circle distance between two points (haversine).
+func distanceKm(a, b models.Point) float64 {
+	const earthRadiusKm = 6371.0
+
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lon - a.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
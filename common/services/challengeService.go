@@ -18,8 +18,10 @@ func GetChallengeByID(id uint, currentUserID uint) (models.Challenge, error) {
 	err := config.DB.
 		Scopes(ExcludeBlockedUsersOn(currentUserID, "creator_id")).
 		Preload("Users", ExcludeBlockedUsers(currentUserID)).
+		Preload("Users.Settings").
 		Preload("Teams").
 		Preload("Creator").
+		Preload("Creator.Settings").
 		Preload("Location").
 		Preload("Facility").
 		First(&c, id).
@@ -40,10 +42,12 @@ func GetChallenges(currentUserID uint) ([]models.Challenge, error) {
 	var challenges []models.Challenge
 
 	err := config.DB.
-		Scopes(ExcludeBlockedUsersOn(currentUserID, "creator_id")).
+		Scopes(ExcludeBlockedUsersOn(currentUserID, "creator_id"), HideChallengesOfRestrictedCreators(currentUserID)).
 		Preload("Users", ExcludeBlockedUsers(currentUserID)).
+		Preload("Users.Settings").
 		Preload("Teams").
 		Preload("Creator").
+		Preload("Creator.Settings").
 		Preload("Location").
 		Preload("Facility").
 		Find(&challenges).
@@ -71,6 +75,11 @@ func CreateChallenge(c models.Challenge, invitedUserIds []uint) (models.Challeng
 			return err
 		}
 
+		// Unverified users can only create private challenges without invitations
+		if (c.IsPublic || len(invitedUserIds) > 0) && !creator.EmailVerified {
+			return appError.ErrEmailNotVerified
+		}
+
 		c.CreatorID = creator.ID
 		c.Creator = models.User{}
 
@@ -167,8 +176,10 @@ func CreateChallenge(c models.Challenge, invitedUserIds []uint) (models.Challeng
 		}
 
 		err = tx.Preload("Users").
+			Preload("Users.Settings").
 			Preload("Teams").
 			Preload("Creator").
+			Preload("Creator.Settings").
 			Preload("Location").
 			Preload("Facility").
 			First(&c, c.ID).
@@ -249,6 +260,12 @@ func UpdateChallenge(id uint, ch models.Challenge) error {
 			c.FacilityID = ch.FacilityID
 		}
 
+		if ch.IsPublic && !c.IsPublic {
+			if err := ensureEmailVerified(c.CreatorID, tx); err != nil {
+				return err
+			}
+		}
+
 		// Update boolean fields (always update since they can be true/false)
 		c.IsIndoor = ch.IsIndoor
 		c.IsPublic = ch.IsPublic
@@ -396,6 +413,85 @@ func DeleteChallenge(id uint) error {
 	})
 }
 
+// HardDeleteChallenge completely deletes a challenge and its associations (no soft delete).
+// Also works on soft deleted challenges, so it can be used to purge them.
+func HardDeleteChallenge(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var c models.Challenge
+
+		if err := tx.Unscoped().First(&c, id).Error; err != nil {
+			return err
+		}
+
+		// Delete conversations, participants and messages
+		if err := deleteResourceConversations(tx, "challenge_id", c.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("resource_type = ? AND resource_id = ?", models.ResourceTypeChallenge, c.ID).
+			Delete(&models.Invitation{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM challenge_teams WHERE challenge_id = ?", c.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM user_challenges WHERE challenge_id = ?", c.ID).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&c).Error
+	})
+}
+
+// RestoreChallenge restores a soft deleted challenge. Only the creator can restore,
+// and only within the restore window.
+func RestoreChallenge(id uint, currentUserID uint) (models.Challenge, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var c models.Challenge
+
+		if err := tx.Unscoped().First(&c, id).Error; err != nil {
+			return err
+		}
+
+		if err := checkRestoreWindow(c.DeletedAt); err != nil {
+			return err
+		}
+
+		if c.CreatorID != currentUserID {
+			return appError.ErrUnauthorized
+		}
+
+		return tx.Unscoped().Model(&c).Update("deleted_at", nil).Error
+	})
+
+	if err != nil {
+		return models.Challenge{}, err
+	}
+
+	// Re-sync challenge conversation members
+	var challenge models.Challenge
+	if err := config.DB.Preload("Users").First(&challenge, id).Error; err != nil {
+		return models.Challenge{}, err
+	}
+
+	memberIDs := make([]uint, len(challenge.Users))
+	for i, u := range challenge.Users {
+		memberIDs[i] = u.ID
+	}
+
+	if err := SyncChallengeConversationMembers(id, memberIDs); err != nil {
+		// Log error but don't fail the request
+		slog.Warn("Failed to sync challenge conversation after restore",
+			slog.Uint64("challenge_id", uint64(id)),
+			slog.Any("error", err),
+		)
+	}
+
+	return GetChallengeByID(id, currentUserID)
+}
+
 // updateChallengeStatusIfExpired checks if a challenge's EndTime has passed
 // and updates the status to "completed" if it has and the challenge is not already completed
 func updateChallengeStatusIfExpired(c *models.Challenge) {
@@ -1,15 +1,21 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"server/common/appError"
 	"server/common/config"
+	"server/common/dto"
 	"server/common/models"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Delivery and read receipts are only tracked in these conversation types
+var receiptConversationTypes = []models.ConversationType{models.ConversationTypeDirect, models.ConversationTypeGroup}
+
 // CreateDirectConversation creates or returns existing direct conversation between two users
 func CreateDirectConversation(currentUserID, otherUserID uint) (*models.Conversation, error) {
 	if currentUserID == otherUserID {
@@ -40,6 +46,7 @@ func CreateDirectConversation(currentUserID, otherUserID uint) (*models.Conversa
 	if count > 0 {
 		err := config.DB.Where("direct_key = ?", directKey).
 			Preload("Participants.User").
+			Preload("Participants.User.Settings").
 			First(&conversation).Error
 		if err != nil {
 			return nil, err
@@ -68,6 +75,9 @@ func CreateDirectConversation(currentUserID, otherUserID uint) (*models.Conversa
 			return err
 		}
 
+		members := []uint{currentUserID, otherUserID}
+		publishMembershipChange(conversation.ID, nil, members, nil, members, tx)
+
 		return nil
 	})
 
@@ -76,7 +86,7 @@ func CreateDirectConversation(currentUserID, otherUserID uint) (*models.Conversa
 	}
 
 	// Reload with participants
-	config.DB.Preload("Participants.User").First(&conversation, conversation.ID)
+	config.DB.Preload("Participants.User").Preload("Participants.User.Settings").First(&conversation, conversation.ID)
 
 	return &conversation, nil
 }
@@ -111,12 +121,13 @@ func CreateGroupConversation(currentUserID uint, participantIDs []uint, title st
 			return err
 		}
 
-		// Add all participants
+		// Add all participants, the creator manages the group
 		participants := make([]models.ConversationParticipant, len(participantIDs))
 		for i, userID := range participantIDs {
 			participants[i] = models.ConversationParticipant{
 				ConversationID: conversation.ID,
 				UserID:         userID,
+				IsAdmin:        userID == currentUserID,
 			}
 		}
 
@@ -124,6 +135,8 @@ func CreateGroupConversation(currentUserID uint, participantIDs []uint, title st
 			return err
 		}
 
+		publishMembershipChange(conversation.ID, nil, participantIDs, nil, participantIDs, tx)
+
 		return nil
 	})
 
@@ -132,7 +145,7 @@ func CreateGroupConversation(currentUserID uint, participantIDs []uint, title st
 	}
 
 	// Reload with participants
-	config.DB.Preload("Participants.User").First(&conversation, conversation.ID)
+	config.DB.Preload("Participants.User").Preload("Participants.User.Settings").First(&conversation, conversation.ID)
 
 	return &conversation, nil
 }
@@ -154,6 +167,7 @@ func EnsureTeamConversation(teamID uint) (*models.Conversation, error) {
 	if count > 0 {
 		err := config.DB.Where("team_id = ?", teamID).
 			Preload("Participants.User").
+			Preload("Participants.User.Settings").
 			First(&conversation).Error
 		if err != nil {
 			return nil, err
@@ -201,6 +215,8 @@ func SyncTeamConversationMembers(teamID uint, memberIDs []uint) error {
 			newMemberMap[id] = true
 		}
 
+		var added, removed []uint
+
 		// Add missing members
 		for _, memberID := range memberIDs {
 			if !currentMemberMap[memberID] {
@@ -211,6 +227,7 @@ func SyncTeamConversationMembers(teamID uint, memberIDs []uint) error {
 				if err := tx.Create(&participant).Error; err != nil {
 					return err
 				}
+				added = append(added, memberID)
 			}
 		}
 
@@ -220,9 +237,12 @@ func SyncTeamConversationMembers(teamID uint, memberIDs []uint) error {
 				if err := tx.Delete(&p).Error; err != nil {
 					return err
 				}
+				removed = append(removed, p.UserID)
 			}
 		}
 
+		publishMembershipChange(conversation.ID, &teamID, added, removed, memberIDs, tx)
+
 		return nil
 	})
 }
@@ -244,6 +264,7 @@ func EnsureChallengeConversation(challengeID uint) (*models.Conversation, error)
 	if count > 0 {
 		err := config.DB.Where("challenge_id = ?", challengeID).
 			Preload("Participants.User").
+			Preload("Participants.User.Settings").
 			First(&conversation).Error
 		if err != nil {
 			return nil, err
@@ -291,6 +312,8 @@ func SyncChallengeConversationMembers(challengeID uint, memberIDs []uint) error
 			newMemberMap[id] = true
 		}
 
+		var added, removed []uint
+
 		// Add missing members
 		for _, memberID := range memberIDs {
 			if !currentMemberMap[memberID] {
@@ -301,6 +324,7 @@ func SyncChallengeConversationMembers(challengeID uint, memberIDs []uint) error
 				if err := tx.Create(&participant).Error; err != nil {
 					return err
 				}
+				added = append(added, memberID)
 			}
 		}
 
@@ -310,9 +334,12 @@ func SyncChallengeConversationMembers(challengeID uint, memberIDs []uint) error
 				if err := tx.Delete(&p).Error; err != nil {
 					return err
 				}
+				removed = append(removed, p.UserID)
 			}
 		}
 
+		publishMembershipChange(conversation.ID, nil, added, removed, memberIDs, tx)
+
 		return nil
 	})
 }
@@ -321,6 +348,7 @@ func SyncChallengeConversationMembers(challengeID uint, memberIDs []uint) error
 func GetConversationByID(conversationID uint) (*models.Conversation, error) {
 	var conversation models.Conversation
 	err := config.DB.Preload("Participants.User").
+		Preload("Participants.User.Settings").
 		First(&conversation, conversationID).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -350,6 +378,7 @@ func ListConversations(userID uint) ([]models.Conversation, []int64, []*models.M
 		Scopes(ExcludeBlockedUsersOn(userID, "user_id")). // Exclude conversations from blocked users
 		Preload("Conversation.Team").
 		Preload("Conversation.Participants.User", ExcludeBlockedUsers(userID)).
+		Preload("Conversation.Participants.User.Settings").
 		Preload("Conversation.Messages", func(db *gorm.DB) *gorm.DB {
 			return db.Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).Order("created_at DESC")
 		}).
@@ -402,23 +431,130 @@ func ListConversations(userID uint) ([]models.Conversation, []int64, []*models.M
 	return conversations, unreadCounts, lastMessages, nil
 }
 
-// MarkConversationRead updates the last_read_at timestamp for a user in a conversation
+// MarkConversationRead updates the last_read_at timestamp for a user in a conversation.
+// The time never moves back, e.g. when an older read from another device arrives late.
+// Every conversation counts unread messages, but read receipts are only sent in direct and group conversations.
 func MarkConversationRead(conversationID, userID uint, readAt time.Time) error {
+	var participant models.ConversationParticipant
+	err := config.DB.
+		Preload("Conversation").
+		Where("conversation_id = ? AND user_id = ? AND left_at IS NULL", conversationID, userID).
+		First(&participant).
+		Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return appError.ErrNotConversationMember
+	}
+
+	if err != nil {
+		return err
+	}
+
 	result := config.DB.Model(&models.ConversationParticipant{}).
 		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Where("last_read_at IS NULL OR last_read_at < ?", readAt).
 		Update("last_read_at", readAt)
 
 	if result.Error != nil {
 		return result.Error
 	}
 
-	if result.RowsAffected == 0 {
-		return appError.ErrNotConversationMember
+	if result.RowsAffected > 0 && slices.Contains(receiptConversationTypes, participant.Conversation.Type) {
+		PublishRealtimeEvent(dto.RealtimeEventDto{
+			Type:           dto.RealtimeEventRead,
+			ConversationID: &conversationID,
+			UserID:         userID,
+			Timestamp:      time.Now(),
+			Receipt:        &dto.ReceiptResponseDto{LastReadAt: &readAt},
+		}, nil)
 	}
 
 	return nil
 }
 
+// MarkConversationDelivered records that messages up to deliveredAt reached one of the user's devices,
+// and tells the other participants. Only direct and group conversations are tracked, and the time never moves back.
+func MarkConversationDelivered(conversationID, userID uint, deliveredAt time.Time) error {
+	result := config.DB.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ? AND left_at IS NULL", conversationID, userID).
+		Where("last_delivered_at IS NULL OR last_delivered_at < ?", deliveredAt).
+		Where("conversation_id IN (SELECT id FROM conversations WHERE type IN ?)", receiptConversationTypes).
+		Update("last_delivered_at", deliveredAt)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		PublishRealtimeEvent(dto.RealtimeEventDto{
+			Type:           dto.RealtimeEventDelivered,
+			ConversationID: &conversationID,
+			UserID:         userID,
+			Timestamp:      time.Now(),
+			Receipt:        &dto.ReceiptResponseDto{LastDeliveredAt: &deliveredAt},
+		}, nil)
+	}
+
+	return nil
+}
+
+// UpdateConversationNotifications changes the user's push notification settings for a conversation.
+func UpdateConversationNotifications(conversationID, userID uint, req dto.UpdateConversationNotificationsDto) (*models.ConversationParticipant, error) {
+	updates := map[string]any{
+		"muted_at":      nil,
+		"muted_until":   nil,
+		"mentions_only": req.MentionsOnly,
+	}
+
+	if req.Muted {
+		updates["muted_at"] = time.Now()
+		updates["muted_until"] = req.MutedUntil
+	}
+
+	result := config.DB.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ? AND left_at IS NULL", conversationID, userID).
+		Updates(updates)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, appError.ErrNotConversationMember
+	}
+
+	var participant models.ConversationParticipant
+	err := config.DB.
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		First(&participant).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &participant, nil
+}
+
+// GetReceiptParticipants returns the other participants whose receipts the user sees.
+// Receipts are only shown in direct and group conversations, and never for blocked users.
+func GetReceiptParticipants(conversationID, userID uint) ([]models.ConversationParticipant, error) {
+	var participants []models.ConversationParticipant
+
+	err := config.DB.
+		Scopes(ExcludeBlockedUsersOn(userID, "user_id")).
+		Where("conversation_id = ? AND user_id <> ? AND left_at IS NULL", conversationID, userID).
+		Where("conversation_id IN (SELECT id FROM conversations WHERE type IN ?)", receiptConversationTypes).
+		Find(&participants).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return participants, nil
+}
+
 // GetConversationParticipantIDs returns all user IDs that currently belong to a conversation.
 // - Only includes participants where left_at IS NULL
 // - Used by the chat WebSocket hub for routing conversation messages live
@@ -437,3 +573,31 @@ func GetConversationParticipantIDs(conversationID uint) ([]uint, error) {
 
 	return userIDs, nil
 }
+
+// deleteResourceConversations hard-deletes the team or challenge conversations
+// matching column = resourceID together with their participants and messages.
+func deleteResourceConversations(tx *gorm.DB, column string, resourceID uint) error {
+	var conversationIDs []uint
+	if err := tx.Model(&models.Conversation{}).
+		Where(column+" = ?", resourceID).
+		Pluck("id", &conversationIDs).Error; err != nil {
+		return err
+	}
+
+	if len(conversationIDs) == 0 {
+		return nil
+	}
+
+	if err := tx.Where("conversation_id IN ?", conversationIDs).
+		Delete(&models.ConversationParticipant{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("conversation_id IN ?", conversationIDs).
+		Delete(&models.Message{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("id IN ?", conversationIDs).
+		Delete(&models.Conversation{}).Error
+}
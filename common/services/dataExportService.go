@@ -0,0 +1,345 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- GET ---
+func GetLatestDataExport(userID uint) (models.DataExport, error) {
+	var export models.DataExport
+	err := config.DB.
+		Omit("data").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		First(&export).
+		Error
+
+	if err != nil {
+		return models.DataExport{}, err
+	}
+
+	return export, nil
+}
+
+// GetDataExportByToken returns a ready export for download.
+// The token is the only credential, so expired exports are rejected.
+func GetDataExportByToken(token string) (models.DataExport, error) {
+	var export models.DataExport
+	err := config.DB.
+		Where("token = ? AND status = ?", token, models.DataExportReady).
+		First(&export).
+		Error
+
+	if err != nil {
+		return models.DataExport{}, err
+	}
+
+	if export.ExpiresAt == nil || !export.ExpiresAt.After(time.Now()) {
+		return models.DataExport{}, appError.ErrDataExportExpired
+	}
+
+	return export, nil
+}
+
+// --- POST ---
+
+// RequestDataExport queues a new export for the user.
+// The ZIP is built by the cron task, see ProcessDataExport.
+func RequestDataExport(userID uint) (models.DataExport, error) {
+	var export models.DataExport
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user, so concurrent requests can't both pass the check below
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			First(&models.User{}, userID).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&models.DataExport{}).
+			Where("user_id = ? AND status IN ?", userID,
+				[]models.DataExportStatus{models.DataExportPending, models.DataExportProcessing}).
+			Count(&count).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return appError.ErrDataExportInProgress
+		}
+
+		export = models.DataExport{
+			UserID: userID,
+			Status: models.DataExportPending,
+		}
+
+		return tx.Create(&export).Error
+	})
+
+	if err != nil {
+		return models.DataExport{}, err
+	}
+
+	return export, nil
+}
+
+// ProcessDataExport builds the ZIP for a pending export and makes it downloadable.
+// The user is notified when the export is ready, and the export is marked failed on any error,
+// so the user can request a new one.
+func ProcessDataExport(exportID uint) error {
+	// Claim the export, so it is only processed once
+	result := config.DB.Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", exportID, models.DataExportPending).
+		Updates(map[string]any{
+			"status":     models.DataExportProcessing,
+			"started_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := completeDataExport(exportID); err != nil {
+		failErr := config.DB.Model(&models.DataExport{}).
+			Where("id = ? AND status = ?", exportID, models.DataExportProcessing).
+			Update("status", models.DataExportFailed).
+			Error
+		return errors.Join(err, failErr)
+	}
+
+	return nil
+}
+
+// Package private methods
+
+// completeDataExport builds the ZIP for a claimed export and stores it with a download token.
+func completeDataExport(exportID uint) error {
+	var export models.DataExport
+	if err := config.DB.Omit("data").First(&export, exportID).Error; err != nil {
+		return err
+	}
+
+	data, err := buildDataExportZip(export.UserID, config.DB)
+	if err != nil {
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.AppConfig.DataExportExpirationHours) * time.Hour)
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&export).Updates(map[string]any{
+			"status":       models.DataExportReady,
+			"token":        token,
+			"data":         data,
+			"expires_at":   expiresAt,
+			"completed_at": now,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		CreateDataExportReadyNotification(tx, export.UserID)
+
+		return nil
+	})
+}
+
+// buildDataExportZip collects everything stored about the user into a ZIP of JSON files.
+// Other users are only referenced by ID or their public profile.
+func buildDataExportZip(userID uint, db *gorm.DB) ([]byte, error) {
+	var user models.User
+	err := db.
+		Preload("FavoriteSports").
+		Preload("SportProfiles.Sport").
+		Preload("Friends", ExcludeBlockedUsers(userID)).
+		Preload("Friends.Settings").
+		Preload("Teams.Team").
+		Preload("Teams.Team.Users.User").
+		Preload("Teams.Team.Creator").
+		Preload("Teams.Team.Location").
+		Preload("Settings").
+		Preload("EmergencyContacts").
+		First(&user, userID).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var challenges []models.Challenge
+	err = db.
+		Preload("Users").
+		Preload("Teams").
+		Preload("Creator").
+		Preload("Location").
+		Preload("Facility").
+		Where("creator_id = ? OR id IN (SELECT challenge_id FROM user_challenges WHERE user_id = ?)", userID, userID).
+		Order("start_time ASC").
+		Find(&challenges).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var invitations []models.Invitation
+	err = db.Where("inviter_id = ? OR invitee_id = ?", userID, userID).
+		Order("created_at ASC").
+		Find(&invitations).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications []models.Notification
+	err = db.Preload("Actor").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&notifications).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []models.Message
+	err = db.Where("sender_id = ?", userID).
+		Order("created_at ASC").
+		Find(&messages).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []models.Report
+	err = db.Where("reporter_id = ?", userID).
+		Order("created_at ASC").
+		Find(&reports).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	var acceptances []models.EulaAcceptance
+	err = db.Where("user_id = ?", userID).
+		Order("accepted_at ASC").
+		Find(&acceptances).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Map to DTOs
+	profile := dto.ToUserResponseDto(user)
+
+	friends := make([]dto.PublicUserDtoResponse, len(user.Friends))
+	for i, f := range user.Friends {
+		friends[i] = dto.ToPublicUserDtoResponseForViewer(f, dto.ProfileViewer{IsFriend: true})
+	}
+
+	teams := make([]dto.TeamResponseDto, len(user.Teams))
+	for i, t := range user.Teams {
+		teams[i] = dto.ToTeamResponseDto(t.Team)
+	}
+
+	challengeDtos := make([]dto.ChallengeResponseDto, len(challenges))
+	for i, c := range challenges {
+		challengeDtos[i] = dto.ToChallengeResponseDto(c)
+	}
+
+	invitationDtos := make([]dto.InvitationExportResponseDto, len(invitations))
+	for i, inv := range invitations {
+		invitationDtos[i] = dto.ToInvitationExportResponseDto(inv)
+	}
+
+	notificationDtos := make([]dto.NotificationResponseDto, len(notifications))
+	for i, n := range notifications {
+		notificationDtos[i] = dto.ToNotificationResponseDto(n)
+	}
+
+	messageDtos := make([]dto.MessageExportResponseDto, len(messages))
+	for i, m := range messages {
+		messageDtos[i] = dto.ToMessageExportResponseDto(m)
+	}
+
+	reportDtos := make([]dto.ReportExportResponseDto, len(reports))
+	for i, r := range reports {
+		reportDtos[i] = dto.ToReportExportResponseDto(r)
+	}
+
+	acceptanceDtos := make([]dto.EulaAcceptanceExportResponseDto, len(acceptances))
+	for i, a := range acceptances {
+		acceptanceDtos[i] = dto.ToEulaAcceptanceExportResponseDto(a)
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", profile},
+		{"settings.json", profile.Settings},
+		{"friends.json", friends},
+		{"teams.json", teams},
+		{"challenges.json", challengeDtos},
+		{"invitations.json", invitationDtos},
+		{"notifications.json", notificationDtos},
+		{"messages.json", messageDtos},
+		{"reports.json", reportDtos},
+		{"eula_acceptances.json", acceptanceDtos},
+		{"emergency_contacts.json", profile.EmergencyContacts},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -79,3 +79,171 @@ This code will expire in 1 hour. If you didn't request this, please ignore this
 	slog.Info("Password reset email sent successfully", "to", email, "message_id", response.MessageID)
 	return nil
 }
+
+func SendEmailVerificationEmail(email, code string) error {
+	client := getPostmarkClient()
+
+	htmlBody := fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="UTF-8">
+			<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		</head>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+			<div style="background-color: #f4f4f4; padding: 30px; border-radius: 5px;">
+				<h2 style="color: #333; margin-top: 0; text-align: center;">Verify Your Email</h2>
+				<p style="text-align: center;">Welcome to Challenger! Enter this code in the app to verify your email address:</p>
+				<div style="text-align: center; margin: 30px 0;">
+					<div style="background-color: #fff; border: 2px solid #007bff; border-radius: 8px; padding: 20px; display: inline-block;">
+						<div style="font-size: 36px; font-weight: bold; letter-spacing: 8px; color: #007bff; font-family: 'Courier New', monospace;">%s</div>
+					</div>
+				</div>
+				<p style="text-align: center; color: #666; font-size: 14px;">This code will expire in 24 hours.</p>
+				<p style="text-align: center; color: #999; font-size: 12px; margin-top: 30px;">If you didn't create an account, please ignore this email.</p>
+			</div>
+		</body>
+		</html>
+	`, code)
+
+	textBody := fmt.Sprintf(`
+Verify Your Email
+
+Welcome to Challenger! Enter this code in the app to verify your email address:
+
+%s
+
+This code will expire in 24 hours. If you didn't create an account, please ignore this email.
+	`, code)
+
+	emailMessage := postmark.Email{
+		From:          config.AppConfig.PostmarkFromEmail,
+		To:            email,
+		Subject:       "Verify Your Email",
+		HTMLBody:      htmlBody,
+		TextBody:      textBody,
+		MessageStream: "outbound",
+		Tag:           "email-verification",
+	}
+
+	ctx := context.Background()
+	response, err := client.SendEmail(ctx, emailMessage)
+	if err != nil {
+		slog.Error("Failed to send verification email", "error", err, "to", email)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	slog.Info("Verification email sent successfully", "to", email, "message_id", response.MessageID)
+	return nil
+}
+
+func SendEmailChangeVerificationEmail(newEmail, code string) error {
+	client := getPostmarkClient()
+
+	htmlBody := fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="UTF-8">
+			<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		</head>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+			<div style="background-color: #f4f4f4; padding: 30px; border-radius: 5px;">
+				<h2 style="color: #333; margin-top: 0; text-align: center;">Confirm Your New Email</h2>
+				<p style="text-align: center;">You requested to change the email address of your Challenger account to this address. Enter this code in the app:</p>
+				<div style="text-align: center; margin: 30px 0;">
+					<div style="background-color: #fff; border: 2px solid #007bff; border-radius: 8px; padding: 20px; display: inline-block;">
+						<div style="font-size: 36px; font-weight: bold; letter-spacing: 8px; color: #007bff; font-family: 'Courier New', monospace;">%s</div>
+					</div>
+				</div>
+				<p style="text-align: center; color: #666; font-size: 14px;">This code will expire in 24 hours.</p>
+				<p style="text-align: center; color: #999; font-size: 12px; margin-top: 30px;">If you didn't request this, please ignore this email.</p>
+			</div>
+		</body>
+		</html>
+	`, code)
+
+	textBody := fmt.Sprintf(`
+Confirm Your New Email
+
+You requested to change the email address of your Challenger account to this address. Enter this code in the app:
+
+%s
+
+This code will expire in 24 hours. If you didn't request this, please ignore this email.
+	`, code)
+
+	emailMessage := postmark.Email{
+		From:          config.AppConfig.PostmarkFromEmail,
+		To:            newEmail,
+		Subject:       "Confirm Your New Email",
+		HTMLBody:      htmlBody,
+		TextBody:      textBody,
+		MessageStream: "outbound",
+		Tag:           "email-change",
+	}
+
+	ctx := context.Background()
+	response, err := client.SendEmail(ctx, emailMessage)
+	if err != nil {
+		slog.Error("Failed to send email change verification email", "error", err, "to", newEmail)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	slog.Info("Email change verification email sent successfully", "to", newEmail, "message_id", response.MessageID)
+	return nil
+}
+
+// SendEmailChangeNoticeEmail warns the current address that a change to newEmail was requested
+func SendEmailChangeNoticeEmail(oldEmail, newEmail string) error {
+	client := getPostmarkClient()
+
+	htmlBody := fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="UTF-8">
+			<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		</head>
+		<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+			<div style="background-color: #f4f4f4; padding: 30px; border-radius: 5px;">
+				<h2 style="color: #333; margin-top: 0; text-align: center;">Email Change Requested</h2>
+				<p style="text-align: center;">A request was made to change the email address of your Challenger account to:</p>
+				<p style="text-align: center; font-weight: bold;">%s</p>
+				<p style="text-align: center; color: #666; font-size: 14px;">The change only takes effect once it is confirmed from the new address.</p>
+				<p style="text-align: center; color: #999; font-size: 12px; margin-top: 30px;">If you didn't request this, please reset your password right away.</p>
+			</div>
+		</body>
+		</html>
+	`, newEmail)
+
+	textBody := fmt.Sprintf(`
+Email Change Requested
+
+A request was made to change the email address of your Challenger account to:
+
+%s
+
+The change only takes effect once it is confirmed from the new address. If you didn't request this, please reset your password right away.
+	`, newEmail)
+
+	emailMessage := postmark.Email{
+		From:          config.AppConfig.PostmarkFromEmail,
+		To:            oldEmail,
+		Subject:       "Your Email Address Is Being Changed",
+		HTMLBody:      htmlBody,
+		TextBody:      textBody,
+		MessageStream: "outbound",
+		Tag:           "email-change-notice",
+	}
+
+	ctx := context.Background()
+	response, err := client.SendEmail(ctx, emailMessage)
+	if err != nil {
+		slog.Error("Failed to send email change notice", "error", err, "to", oldEmail)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	slog.Info("Email change notice sent successfully", "to", oldEmail, "message_id", response.MessageID)
+	return nil
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"server/common/appError"
 	"server/common/config"
+	"server/common/dto"
 	"server/common/models"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +15,7 @@ func GetFriends(userID uint) ([]models.User, error) {
 
 	err := config.DB.
 		Preload("Friends", ExcludeBlockedUsers(userID)).
+		Preload("Friends.Settings").
 		First(&user, userID).
 		Error
 
@@ -23,7 +26,8 @@ func GetFriends(userID uint) ([]models.User, error) {
 	return user.Friends, nil
 }
 
-// tries to find suggested friends for a user based on common friends, teams, challenges, and favorite sports
+// tries to find suggested friends for a user based on common friends, teams, challenges, favorite sports
+// and how well their sport profiles (level and preferred days/times) match
 func GetSuggestedFriends(userID uint) ([]models.User, error) {
 	// Get the current user with their friends, teams, challenges, and sports
 	var user models.User
@@ -31,6 +35,7 @@ func GetSuggestedFriends(userID uint) ([]models.User, error) {
 		Preload("Teams").
 		Preload("JoinedChallenges").
 		Preload("FavoriteSports").
+		Preload("SportProfiles").
 		First(&user, userID).Error
 
 	if err != nil {
@@ -71,9 +76,11 @@ func GetSuggestedFriends(userID uint) ([]models.User, error) {
 	// Get all potential candidates (users who are not friends and not blocked and not invited by or to the user)
 	var candidates []models.User
 	query := config.DB.Preload("Friends").
+		Preload("Settings").
 		Preload("Teams").
 		Preload("JoinedChallenges").
 		Preload("FavoriteSports").
+		Preload("SportProfiles").
 		Where("id != ?", userID). // Exclude self
 		// Exclude users who have pending friend invitations with the current user
 		Where("id NOT IN (SELECT invitee_id FROM invitations WHERE inviter_id = ? AND resource_type = 'friend' AND status = 'pending')", userID). // Exclude users invited by current user
@@ -101,6 +108,8 @@ func GetSuggestedFriends(userID uint) ([]models.User, error) {
 		commonTeamsCount      int
 		commonChallengesCount int
 		commonSportsCount     int
+		matchingLevelCount    int
+		matchingScheduleCount int
 		totalScore            float64
 	}
 
@@ -127,6 +136,11 @@ func GetSuggestedFriends(userID uint) ([]models.User, error) {
 		userSportMap[sport.ID] = true
 	}
 
+	userProfileMap := make(map[uint]models.UserSportProfile)
+	for _, profile := range user.SportProfiles {
+		userProfileMap[profile.SportID] = profile
+	}
+
 	// Calculate scores for each candidate
 	for _, candidate := range candidates {
 		scored := scoredUser{user: candidate}
@@ -159,12 +173,30 @@ func GetSuggestedFriends(userID uint) ([]models.User, error) {
 			}
 		}
 
+		// Compare sport profiles for sports both users have a profile for
+		for _, candidateProfile := range candidate.SportProfiles {
+			userProfile, ok := userProfileMap[candidateProfile.SportID]
+			if !ok {
+				continue
+			}
+
+			if sportLevelsMatch(userProfile, candidateProfile) {
+				scored.matchingLevelCount++
+			}
+			if sportSchedulesOverlap(userProfile, candidateProfile) {
+				scored.matchingScheduleCount++
+			}
+		}
+
 		// Calculate weighted score
 		// Weights: Common Friends (4.0) > Common Teams (3.0) > Common Challenges (2.0) > Common Sports (1.0)
+		// > Matching Level (1.0) > Matching Schedule (0.5)
 		scored.totalScore = float64(scored.commonFriendsCount)*4.0 +
 			float64(scored.commonTeamsCount)*3.0 +
 			float64(scored.commonChallengesCount)*2.0 +
-			float64(scored.commonSportsCount)*1.0
+			float64(scored.commonSportsCount)*1.0 +
+			float64(scored.matchingLevelCount)*1.0 +
+			float64(scored.matchingScheduleCount)*0.5
 
 		// Only include users with at least some connection
 		if scored.totalScore > 0 {
@@ -195,6 +227,37 @@ func GetSuggestedFriends(userID uint) ([]models.User, error) {
 	return suggestions, nil
 }
 
+// AreFriends checks if the two users are friends.
+func AreFriends(userIdA uint, userIdB uint) bool {
+	var count int64
+	config.DB.Table("user_friends").
+		Where("user_id = ? AND friend_id = ?", userIdA, userIdB).
+		Count(&count)
+
+	return count > 0
+}
+
+// GetFriendIDs returns the IDs of all friends of the given user.
+func GetFriendIDs(userID uint) []uint {
+	var friendIDs []uint
+	config.DB.Table("user_friends").
+		Where("user_id = ?", userID).
+		Pluck("friend_id", &friendIDs)
+
+	return friendIDs
+}
+
+// HaveMutualFriends checks if the two users share at least one friend.
+func HaveMutualFriends(userIdA uint, userIdB uint) bool {
+	var count int64
+	config.DB.Table("user_friends as f1").
+		Joins("JOIN user_friends as f2 ON f1.friend_id = f2.friend_id").
+		Where("f1.user_id = ? AND f2.user_id = ?", userIdA, userIdB).
+		Count(&count)
+
+	return count > 0
+}
+
 // DeleteFriendship removes both users from each other's friends list
 func RemoveFriend(userIdA uint, userIdB uint) error {
 	return config.DB.Transaction(func(tx *gorm.DB) error {
@@ -266,3 +329,48 @@ func createFriendship(userIdA uint, userIdB uint, db *gorm.DB) error {
 
 	return nil
 }
+
+// sportLevelsMatch returns true if both users declared a level and they are at most one step apart.
+func sportLevelsMatch(a, b models.UserSportProfile) bool {
+	if a.Level == nil || b.Level == nil {
+		return false
+	}
+
+	rankA, rankB := a.Level.Rank(), b.Level.Rank()
+	if rankA < 0 || rankB < 0 {
+		return false
+	}
+
+	diff := rankA - rankB
+	return diff >= -1 && diff <= 1
+}
+
+// sportSchedulesOverlap returns true if the users share at least one preferred day and,
+// when both have preferred times, at least one preferred time slot.
+func sportSchedulesOverlap(a, b models.UserSportProfile) bool {
+	if !jsonListsOverlap(a.PreferredDays, b.PreferredDays) {
+		return false
+	}
+
+	timesA, timesB := dto.StringsFromJSON(a.PreferredTimes), dto.StringsFromJSON(b.PreferredTimes)
+	if len(timesA) == 0 || len(timesB) == 0 {
+		return true
+	}
+
+	return jsonListsOverlap(a.PreferredTimes, b.PreferredTimes)
+}
+
+func jsonListsOverlap(a, b datatypes.JSON) bool {
+	set := make(map[string]bool)
+	for _, v := range dto.StringsFromJSON(a) {
+		set[v] = true
+	}
+
+	for _, v := range dto.StringsFromJSON(b) {
+		if set[v] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Every change to a group posts a system message into it and sends a "conversation_updated" event.
+// Team and challenge conversations are managed by their sync functions and can't be changed here.
+
+// --- POST ---
+
+// AddGroupParticipants adds users to a group, users who left before rejoin it. Only admins can add users.
+func AddGroupParticipants(conversationID, userID uint, participantIDs []uint) (*models.Conversation, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := getGroupAsAdmin(conversationID, userID, tx); err != nil {
+			return err
+		}
+
+		blocked := make(map[uint]bool)
+		for _, id := range GetBlockedUserIDs(userID) {
+			blocked[id] = true
+		}
+
+		actorName := userDisplayName(userID, tx)
+		seen := make(map[uint]bool, len(participantIDs))
+		var added []uint
+
+		for _, participantID := range participantIDs {
+			if seen[participantID] {
+				continue
+			}
+			seen[participantID] = true
+
+			if blocked[participantID] {
+				return appError.ErrUserBlocked
+			}
+
+			var participant models.ConversationParticipant
+			err := tx.Where("conversation_id = ? AND user_id = ?", conversationID, participantID).First(&participant).Error
+
+			switch {
+			case err == nil && participant.LeftAt == nil:
+				// Already in the group
+				continue
+
+			case err == nil:
+				err = tx.Model(&participant).Updates(map[string]any{
+					"left_at":   nil,
+					"joined_at": time.Now(),
+					"is_admin":  false,
+				}).Error
+
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.First(&models.User{}, participantID).Error; err != nil {
+					return err
+				}
+
+				err = tx.Create(&models.ConversationParticipant{
+					ConversationID: conversationID,
+					UserID:         participantID,
+				}).Error
+			}
+
+			if err != nil {
+				return err
+			}
+
+			content := fmt.Sprintf("%s added %s", actorName, userDisplayName(participantID, tx))
+			if err := postSystemMessage(conversationID, userID, models.SystemEventParticipantAdded, &participantID, content, tx); err != nil {
+				return err
+			}
+
+			added = append(added, participantID)
+		}
+
+		if len(added) == 0 {
+			return nil
+		}
+
+		return publishGroupMembershipChange(conversationID, added, nil, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetConversationByID(conversationID)
+}
+
+// LeaveGroupConversation removes the user from a group. When the last admin leaves,
+// the participant who joined first becomes admin, so the group can still be managed.
+func LeaveGroupConversation(conversationID, userID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := getGroupForUpdate(conversationID, tx); err != nil {
+			return err
+		}
+
+		participant, err := getActiveParticipant(conversationID, userID, tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrNotConversationMember
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := leaveGroup(participant, tx); err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf("%s left the group", userDisplayName(userID, tx))
+		if err := postSystemMessage(conversationID, userID, models.SystemEventParticipantLeft, nil, content, tx); err != nil {
+			return err
+		}
+
+		if participant.IsAdmin {
+			if err := ensureGroupAdmin(conversationID, userID, tx); err != nil {
+				return err
+			}
+		}
+
+		return publishGroupMembershipChange(conversationID, nil, []uint{userID}, tx)
+	})
+}
+
+// --- PUT ---
+
+// RenameGroupConversation changes the title of a group. Only admins can rename it.
+func RenameGroupConversation(conversationID, userID uint, title string) (*models.Conversation, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		conversation, err := getGroupAsAdmin(conversationID, userID, tx)
+		if err != nil {
+			return err
+		}
+
+		if conversation.Title != nil && *conversation.Title == title {
+			return nil
+		}
+
+		if err := tx.Model(conversation).Update("title", title).Error; err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf("%s renamed the group to %q", userDisplayName(userID, tx), title)
+		if err := postSystemMessage(conversationID, userID, models.SystemEventGroupRenamed, nil, content, tx); err != nil {
+			return err
+		}
+
+		return publishConversationUpdated(conversationID, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetConversationByID(conversationID)
+}
+
+// SetGroupAdmin makes a participant an admin of the group, or revokes it. Only admins can do it,
+// and the last admin can't be revoked.
+func SetGroupAdmin(conversationID, userID, participantID uint, admin bool) (*models.Conversation, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := getGroupAsAdmin(conversationID, userID, tx); err != nil {
+			return err
+		}
+
+		participant, err := getActiveParticipant(conversationID, participantID, tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrParticipantNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if participant.IsAdmin == admin {
+			return nil
+		}
+
+		if !admin {
+			var admins int64
+			err := tx.Model(&models.ConversationParticipant{}).
+				Where("conversation_id = ? AND left_at IS NULL AND is_admin", conversationID).
+				Count(&admins).
+				Error
+
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return appError.ErrLastConversationAdmin
+			}
+		}
+
+		if err := tx.Model(participant).Update("is_admin", admin).Error; err != nil {
+			return err
+		}
+
+		event := models.SystemEventAdminGranted
+		content := fmt.Sprintf("%s made %s an admin", userDisplayName(userID, tx), userDisplayName(participantID, tx))
+		if !admin {
+			event = models.SystemEventAdminRevoked
+			content = fmt.Sprintf("%s removed %s as admin", userDisplayName(userID, tx), userDisplayName(participantID, tx))
+			if participantID == userID {
+				content = fmt.Sprintf("%s is no longer an admin", userDisplayName(userID, tx))
+			}
+		}
+
+		if err := postSystemMessage(conversationID, userID, event, &participantID, content, tx); err != nil {
+			return err
+		}
+
+		return publishConversationUpdated(conversationID, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetConversationByID(conversationID)
+}
+
+// --- DELETE ---
+
+// RemoveGroupParticipant removes another participant from a group. Only admins can remove participants,
+// users remove themselves with LeaveGroupConversation.
+func RemoveGroupParticipant(conversationID, userID, participantID uint) (*models.Conversation, error) {
+	if participantID == userID {
+		if err := LeaveGroupConversation(conversationID, userID); err != nil {
+			return nil, err
+		}
+		return GetConversationByID(conversationID)
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := getGroupAsAdmin(conversationID, userID, tx); err != nil {
+			return err
+		}
+
+		participant, err := getActiveParticipant(conversationID, participantID, tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrParticipantNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := leaveGroup(participant, tx); err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf("%s removed %s", userDisplayName(userID, tx), userDisplayName(participantID, tx))
+		if err := postSystemMessage(conversationID, userID, models.SystemEventParticipantRemoved, &participantID, content, tx); err != nil {
+			return err
+		}
+
+		return publishGroupMembershipChange(conversationID, nil, []uint{participantID}, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetConversationByID(conversationID)
+}
+
+// Package private methods
+
+// getGroupForUpdate locks a group conversation, so concurrent changes to it run one after another.
+func getGroupForUpdate(conversationID uint, db *gorm.DB) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&conversation, conversationID).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrConversationNotFound
+		}
+		return nil, err
+	}
+
+	if conversation.Type != models.ConversationTypeGroup {
+		return nil, appError.ErrConversationNotEditable
+	}
+
+	return &conversation, nil
+}
+
+// getGroupAsAdmin locks a group conversation that the user manages.
+func getGroupAsAdmin(conversationID, userID uint, db *gorm.DB) (*models.Conversation, error) {
+	conversation, err := getGroupForUpdate(conversationID, db)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := getActiveParticipant(conversationID, userID, db)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, appError.ErrNotConversationMember
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !participant.IsAdmin {
+		return nil, appError.ErrNotConversationAdmin
+	}
+
+	return conversation, nil
+}
+
+func getActiveParticipant(conversationID, userID uint, db *gorm.DB) (*models.ConversationParticipant, error) {
+	var participant models.ConversationParticipant
+	err := db.
+		Where("conversation_id = ? AND user_id = ? AND left_at IS NULL", conversationID, userID).
+		First(&participant).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &participant, nil
+}
+
+// leaveGroup marks the participant as left, admins lose their role.
+func leaveGroup(participant *models.ConversationParticipant, db *gorm.DB) error {
+	return db.Model(participant).Updates(map[string]any{
+		"left_at":  time.Now(),
+		"is_admin": false,
+	}).Error
+}
+
+// ensureGroupAdmin makes the participant who joined first an admin when the group has none left.
+func ensureGroupAdmin(conversationID, actorID uint, db *gorm.DB) error {
+	var participants []models.ConversationParticipant
+	err := db.
+		Where("conversation_id = ? AND left_at IS NULL", conversationID).
+		Order("joined_at, user_id").
+		Find(&participants).
+		Error
+
+	if err != nil || len(participants) == 0 {
+		return err
+	}
+
+	for _, p := range participants {
+		if p.IsAdmin {
+			return nil
+		}
+	}
+
+	next := participants[0]
+	if err := db.Model(&next).Update("is_admin", true).Error; err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("%s is now an admin", userDisplayName(next.UserID, db))
+	return postSystemMessage(conversationID, actorID, models.SystemEventAdminGranted, &next.UserID, content, db)
+}
+
+// postSystemMessage posts a message about a change to the group and sends it to the participants.
+func postSystemMessage(conversationID, actorID uint, event models.SystemEvent, targetUserID *uint, content string, db *gorm.DB) error {
+	message := models.Message{
+		ConversationID: &conversationID,
+		SenderID:       actorID,
+		Content:        content,
+		SystemEvent:    &event,
+		TargetUserID:   targetUserID,
+	}
+
+	if err := db.Create(&message).Error; err != nil {
+		return err
+	}
+
+	if err := db.Preload("Sender").First(&message, message.ID).Error; err != nil {
+		return err
+	}
+
+	publishMessageChange(dto.RealtimeEventMessage, message, db)
+
+	return nil
+}
+
+// publishGroupMembershipChange tells the current and removed participants who joined or left the group.
+func publishGroupMembershipChange(conversationID uint, added, removed []uint, db *gorm.DB) error {
+	var memberIDs []uint
+	err := db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND left_at IS NULL", conversationID).
+		Pluck("user_id", &memberIDs).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	publishMembershipChange(conversationID, nil, added, removed, memberIDs, db)
+
+	return publishConversationUpdated(conversationID, db)
+}
+
+// publishConversationUpdated sends the changed group to its participants.
+func publishConversationUpdated(conversationID uint, db *gorm.DB) error {
+	var conversation models.Conversation
+	if err := db.Preload("Participants.User").Preload("Participants.User.Settings").First(&conversation, conversationID).Error; err != nil {
+		return err
+	}
+
+	res := dto.ToConversationResponseDto(conversation)
+	PublishRealtimeEvent(dto.RealtimeEventDto{
+		Type:           dto.RealtimeEventConversationUpdated,
+		ConversationID: &conversationID,
+		Timestamp:      time.Now(),
+		Conversation:   &res,
+	}, db)
+
+	return nil
+}
+
+// userDisplayName is the name system messages show for a user.
+func userDisplayName(userID uint, db *gorm.DB) string {
+	var user models.User
+	if err := db.Select("id", "first_name", "last_name").First(&user, userID).Error; err != nil {
+		return models.DeletedUserName
+	}
+
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+
+	return models.DeletedUserName
+}
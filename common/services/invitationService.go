@@ -2,7 +2,6 @@ package services
 
 import (
 	"errors"
-	"log/slog"
 	"server/common/appError"
 	"server/common/config"
 	"server/common/models"
@@ -39,6 +38,16 @@ func SendInvitation(invitation *models.Invitation) error {
 		return appError.ErrUserBlocked
 	}
 
+	if err := ensureEmailVerified(invitation.InviterId, config.DB); err != nil {
+		return err
+	}
+
+	if invitation.ResourceType == models.ResourceTypeFriend {
+		if err := checkFriendRequestPrivacy(invitation.InviterId, invitation.InviteeId); err != nil {
+			return err
+		}
+	}
+
 	// Normalize friend invitations so ResourceID is always deterministic.
 	// ResourceID is required (not null) and part of the unique index.
 	// Convention: for friends, ResourceID points to the inviter (sender).
@@ -209,7 +218,7 @@ func AcceptInvitation(invitationId uint, currentUserId uint) error {
 				return appError.ErrServerError
 			}
 
-			err = addUserToTeam(team.ID, invitation.InviteeId, tx)
+			err = addUserToTeam(team.ID, invitation.InviteeId, models.RoleMember, tx)
 			if err != nil {
 				return err
 			}
@@ -272,22 +281,7 @@ func AcceptInvitation(invitationId uint, currentUserId uint) error {
 
 	// Sync team conversation members after successful transaction
 	if isTeamInvitation {
-		// Get all team members
-		var team models.Team
-		if err := config.DB.Preload("Users").First(&team, teamID).Error; err == nil {
-			memberIDs := make([]uint, len(team.Users))
-			for i, u := range team.Users {
-				memberIDs[i] = u.UserID
-			}
-			// Sync conversation (don't fail if this errors)
-			if err := SyncTeamConversationMembers(teamID, memberIDs); err != nil {
-				// Log error but don't fail the request
-				slog.Warn("Failed to sync team conversation for team",
-					slog.Int("team_id", int(teamID)),
-					slog.Any("error", err),
-				)
-			}
-		}
+		syncTeamConversation(teamID)
 	}
 
 	return nil
@@ -366,3 +360,27 @@ func getResource(invitation models.Invitation, db *gorm.DB) (any, error) {
 		return nil, appError.ErrUnknownResource
 	}
 }
+
+// checkFriendRequestPrivacy enforces the invitee's setting for who can send friend requests.
+// "friends" only allows friends of friends.
+func checkFriendRequestPrivacy(inviterID uint, inviteeID uint) error {
+	settings, err := GetUserSettings(inviteeID)
+	if err != nil {
+		// Users without settings fall back to everyone
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	switch settings.PrivacyFriendRequests {
+	case models.PrivacyNobody:
+		return appError.ErrFriendRequestsNotAllowed
+	case models.PrivacyFriends:
+		if !HaveMutualFriends(inviterID, inviteeID) {
+			return appError.ErrFriendRequestsNotAllowed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+
+	"server/common/models"
+
+	"gorm.io/gorm"
+)
+
+// Apps insert mentions as @[Name](userID), the name is only used to show the message without the app
+var mentionPattern = regexp.MustCompile(`@\[([^\]\n]+)\]\((\d+)\)`)
+
+// Package private methods
+
+// parseMentionIDs returns the IDs of the users mentioned in the content, without duplicates.
+func parseMentionIDs(content string) []uint {
+	var ids []uint
+	seen := make(map[uint]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		id, err := strconv.ParseUint(match[2], 10, 32)
+		if err != nil || id == 0 || seen[uint(id)] {
+			continue
+		}
+
+		seen[uint(id)] = true
+		ids = append(ids, uint(id))
+	}
+
+	return ids
+}
+
+// renderMentions replaces the mention tokens with @Name, for places the app doesn't render, e.g. push notifications.
+func renderMentions(content string) string {
+	return mentionPattern.ReplaceAllString(content, "@$1")
+}
+
+// saveMentions replaces the stored mentions of the message with the participants its content mentions.
+// Mentions of the sender and of users outside the conversation are ignored.
+func saveMentions(message *models.Message, db *gorm.DB) error {
+	if err := db.Where("message_id = ?", message.ID).Delete(&models.MessageMention{}).Error; err != nil {
+		return err
+	}
+	message.Mentions = nil
+
+	ids := parseMentionIDs(message.Content)
+	if len(ids) == 0 || message.ConversationID == nil {
+		return nil
+	}
+
+	var userIDs []uint
+	err := db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id IN ? AND user_id <> ? AND left_at IS NULL", *message.ConversationID, ids, message.SenderID).
+		Order("user_id").
+		Pluck("user_id", &userIDs).
+		Error
+
+	if err != nil || len(userIDs) == 0 {
+		return err
+	}
+
+	mentions := make([]models.MessageMention, len(userIDs))
+	for i, userID := range userIDs {
+		mentions[i] = models.MessageMention{MessageID: message.ID, UserID: userID}
+	}
+
+	if err := db.Create(&mentions).Error; err != nil {
+		return err
+	}
+
+	message.Mentions = mentions
+
+	return nil
+}
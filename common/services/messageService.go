@@ -1,19 +1,37 @@
 package services
 
 import (
+	"errors"
 	"log/slog"
 	"server/common/appError"
 	"server/common/config"
+	"server/common/dto"
 	"server/common/models"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const maxPushBodyLength = 200
 
 // SendMessage creates a new message in a conversation
 func SendMessage(conversationID, senderID uint, content string) (*models.Message, error) {
+	return SendConversationMessage(conversationID, senderID, dto.SendMessageDto{Content: content})
+}
+
+// SendReply creates a message that quotes another message of the same conversation.
+func SendReply(conversationID, senderID, replyToMessageID uint, content string) (*models.Message, error) {
+	return SendConversationMessage(conversationID, senderID, dto.SendMessageDto{
+		Content:          content,
+		ReplyToMessageID: &replyToMessageID,
+	})
+}
+
+// SendConversationMessage creates a message, optionally replying to another message and with
+// attachments the sender uploaded before. Deleted messages and messages of blocked users can't be replied to.
+func SendConversationMessage(conversationID, senderID uint, req dto.SendMessageDto) (*models.Message, error) {
 	// Check if sender is a member of the conversation
 	isMember, err := IsConversationMember(conversationID, senderID)
 	if err != nil {
@@ -26,15 +44,54 @@ func SendMessage(conversationID, senderID uint, content string) (*models.Message
 	message := models.Message{
 		ConversationID: &conversationID,
 		SenderID:       senderID,
-		Content:        content,
+		Content:        req.Content,
+	}
+
+	if req.ReplyToMessageID != nil {
+		var parent models.Message
+		err = config.DB.
+			Scopes(ExcludeBlockedUsersOn(senderID, "sender_id")).
+			Where("id = ? AND conversation_id = ?", *req.ReplyToMessageID, conversationID).
+			First(&parent).
+			Error
+
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, appError.ErrMessageNotFound
+			}
+			return nil, err
+		}
+
+		if parent.IsDeleted() {
+			return nil, appError.ErrMessageDeleted
+		}
+
+		message.ReplyToMessageID = &parent.ID
 	}
 
-	if err := config.DB.Create(&message).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+
+		if err := attachToMessage(&message, req.AttachmentIDs, tx); err != nil {
+			return err
+		}
+
+		return saveMentions(&message, tx)
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
 	// Preload sender info
-	config.DB.Preload("Sender").First(&message, message.ID)
+	config.DB.
+		Preload("Sender").
+		Preload("Attachments", orderByID).
+		Preload("Mentions", orderByID).
+		Scopes(preloadReplyTo(message.SenderID)).
+		First(&message, message.ID)
 
 	// Update conversation's updated_at timestamp
 	config.DB.Model(&models.Conversation{}).
@@ -47,15 +104,337 @@ func SendMessage(conversationID, senderID uint, content string) (*models.Message
 	return &message, nil
 }
 
+// EditMessage replaces the content of the sender's own message and keeps the previous version.
+// Participants are told through a message_edited event.
+func EditMessage(conversationID, messageID, userID uint, content string) (*models.Message, error) {
+	var message models.Message
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := getOwnMessageForUpdate(&message, conversationID, messageID, userID, tx); err != nil {
+			return err
+		}
+
+		// Saving the same content again is a no-op
+		if message.Content == content {
+			return nil
+		}
+
+		edit := models.MessageEdit{MessageID: message.ID, Content: message.Content}
+		if err := tx.Create(&edit).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err := tx.Model(&message).Updates(map[string]any{
+			"content":   content,
+			"edited_at": now,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		message.Content = content
+		message.EditedAt = &now
+
+		// Mentions follow the new content, edits don't send push notifications
+		if err := saveMentions(&message, tx); err != nil {
+			return err
+		}
+
+		publishMessageChange(dto.RealtimeEventMessageEdited, message, tx)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// DeleteMessage deletes the sender's own message for everyone. A tombstone without content,
+// edit history, reactions, mentions or attachments stays behind. Participants are told through a message_deleted event.
+func DeleteMessage(conversationID, messageID, userID uint) error {
+	var attachments []models.MessageAttachment
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var message models.Message
+		err := getOwnMessageForUpdate(&message, conversationID, messageID, userID, tx)
+
+		// Deleting twice is a no-op
+		if errors.Is(err, appError.ErrMessageDeleted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageEdit{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageMention{}).Error; err != nil {
+			return err
+		}
+
+		attachments = message.Attachments
+		if len(attachments) > 0 {
+			if err := tx.Delete(&attachments).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		err = tx.Model(&message).Updates(map[string]any{
+			"content":    "",
+			"deleted_at": now,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		message.Content = ""
+		message.DeletedAt = &now
+		message.Reactions = nil
+		message.Mentions = nil
+		message.Attachments = nil
+
+		publishMessageChange(dto.RealtimeEventMessageDeleted, message, tx)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Files can't be restored, so they are only removed once the deletion is committed
+	deleteAttachmentFiles(attachments)
+
+	return nil
+}
+
+// ToggleReaction adds the user's reaction with the emoji to a message, or removes it if it exists.
+// Participants are told through a reaction event. Returns whether the reaction was added.
+func ToggleReaction(conversationID, messageID, userID uint, emoji string) (*models.Message, bool, error) {
+	var message models.Message
+	added := false
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		isMember, err := IsConversationMember(conversationID, userID)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			return appError.ErrNotConversationMember
+		}
+
+		err = tx.
+			Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).
+			Where("id = ? AND conversation_id = ?", messageID, conversationID).
+			First(&message).
+			Error
+
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appError.ErrMessageNotFound
+			}
+			return err
+		}
+
+		if message.IsDeleted() {
+			return appError.ErrMessageDeleted
+		}
+
+		result := tx.
+			Where("message_id = ? AND user_id = ? AND emoji = ?", message.ID, userID, emoji).
+			Delete(&models.MessageReaction{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			// A concurrent toggle may have added it already, the reaction exists either way
+			reaction := models.MessageReaction{MessageID: message.ID, UserID: userID, Emoji: emoji}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error; err != nil {
+				return err
+			}
+			added = true
+		}
+
+		err = tx.
+			Preload("Sender").
+			Preload("Reactions", orderByID).
+			Preload("Attachments", orderByID).
+			Preload("Mentions", orderByID).
+			Scopes(preloadReplyTo(userID)).
+			First(&message, message.ID).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		msgDto := dto.ToMessageResponseDto(message)
+		PublishRealtimeEvent(dto.RealtimeEventDto{
+			Type:           dto.RealtimeEventReaction,
+			ConversationID: message.ConversationID,
+			UserID:         userID,
+			Timestamp:      time.Now(),
+			Message:        &msgDto,
+			Reaction: &dto.ReactionResponseDto{
+				MessageID: message.ID,
+				Emoji:     emoji,
+				Added:     added,
+			},
+		}, tx)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &message, added, nil
+}
+
+// GetMessageEdits returns the previous versions of a message, oldest first.
+func GetMessageEdits(conversationID, messageID, userID uint) ([]models.MessageEdit, error) {
+	isMember, err := IsConversationMember(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, appError.ErrNotConversationMember
+	}
+
+	var message models.Message
+	err = config.DB.
+		Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).
+		Where("id = ? AND conversation_id = ?", messageID, conversationID).
+		First(&message).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appError.ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	var edits []models.MessageEdit
+	err = config.DB.
+		Where("message_id = ?", message.ID).
+		Order("created_at ASC").
+		Find(&edits).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return edits, nil
+}
+
+// getOwnMessageForUpdate locks a message of the conversation that the user sent and may still change.
+func getOwnMessageForUpdate(message *models.Message, conversationID, messageID, userID uint, db *gorm.DB) error {
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND conversation_id = ?", messageID, conversationID).
+		First(message).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrMessageNotFound
+		}
+		return err
+	}
+
+	if message.SenderID != userID || message.IsSystem() {
+		return appError.ErrNotMessageSender
+	}
+
+	// Senders who left the conversation can't change their messages anymore
+	isMember, err := IsConversationMember(conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return appError.ErrNotConversationMember
+	}
+
+	if message.IsDeleted() {
+		return appError.ErrMessageDeleted
+	}
+
+	return db.
+		Preload("Sender").
+		Preload("Reactions", orderByID).
+		Preload("Attachments", orderByID).
+		Preload("Mentions", orderByID).
+		Scopes(preloadReplyTo(userID)).
+		First(message, message.ID).
+		Error
+}
+
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
+
+// visibleReactions preloads the reactions of a message without those of users the user blocked.
+func visibleReactions(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(ExcludeBlockedUsersOn(userID, "user_id")).Order("id ASC")
+	}
+}
+
+// preloadReplyTo preloads the quoted parent of replies. Parents sent by users the user blocked
+// stay nil, the hub hides them the same way for each recipient of an event.
+func preloadReplyTo(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Preload("ReplyTo", func(db *gorm.DB) *gorm.DB {
+				return db.Scopes(ExcludeBlockedUsersOn(userID, "sender_id"))
+			}).
+			Preload("ReplyTo.Sender")
+	}
+}
+
+// publishMessageChange sends the changed message to the conversation's participants.
+func publishMessageChange(eventType dto.RealtimeEventType, message models.Message, db *gorm.DB) {
+	msgDto := dto.ToMessageResponseDto(message)
+	PublishRealtimeEvent(dto.RealtimeEventDto{
+		Type:           eventType,
+		ConversationID: message.ConversationID,
+		UserID:         message.SenderID,
+		Timestamp:      time.Now(),
+		Message:        &msgDto,
+	}, db)
+}
+
 // sendMessagePushNotifications sends push notifications to all conversation recipients except the sender.
-// Recipients who have blocked the sender or have no Expo token are skipped.
+// Recipients who have blocked the sender or have no Expo token are skipped, and so are recipients who
+// muted the conversation or only want mentions. Mentioned recipients are always notified,
+// unless they muted the sender by muting their direct conversation.
 // Errors are logged but do not affect the caller.
 func sendMessagePushNotifications(message *models.Message) {
 	if message.ConversationID == nil {
 		return
 	}
 
-	participantIDs, err := GetConversationParticipantIDs(*message.ConversationID)
+	var participants []models.ConversationParticipant
+	err := config.DB.
+		Where("conversation_id = ? AND user_id <> ? AND left_at IS NULL", *message.ConversationID, message.SenderID).
+		Find(&participants).
+		Error
+
 	if err != nil {
 		slog.Warn("Failed to get conversation participants for push",
 			slog.Uint64("conversation_id", uint64(*message.ConversationID)),
@@ -64,16 +443,30 @@ func sendMessagePushNotifications(message *models.Message) {
 		return
 	}
 
-	// Collect recipient IDs (exclude sender, exclude users who blocked the sender)
+	mentioned := make(map[uint]bool, len(message.Mentions))
+	for _, m := range message.Mentions {
+		mentioned[m.UserID] = true
+	}
+
+	now := time.Now()
+	mutedSender := usersWhoMutedSender(message.SenderID, message.Mentions, now)
+
+	// Collect recipient IDs (exclude users who blocked the sender, and muted users who weren't mentioned)
 	var recipientIDs []uint
-	for _, userID := range participantIDs {
-		if userID == message.SenderID {
+	for _, p := range participants {
+		if IsBlocked(p.UserID, message.SenderID) {
 			continue
 		}
-		if IsBlocked(userID, message.SenderID) {
+
+		if mentioned[p.UserID] {
+			if mutedSender[p.UserID] {
+				continue
+			}
+		} else if p.IsMuted(now) || p.MentionsOnly {
 			continue
 		}
-		recipientIDs = append(recipientIDs, userID)
+
+		recipientIDs = append(recipientIDs, p.UserID)
 	}
 
 	if len(recipientIDs) == 0 {
@@ -94,10 +487,7 @@ func sendMessagePushNotifications(message *models.Message) {
 	}
 
 	senderName := getSenderDisplayName(message)
-	body := message.Content
-	if len(body) > maxPushBodyLength {
-		body = body[:maxPushBodyLength-3] + "..."
-	}
+	body := messagePushBody(message)
 
 	data := map[string]any{
 		"conversation_id": *message.ConversationID,
@@ -128,6 +518,64 @@ func sendMessagePushNotifications(message *models.Message) {
 	}
 }
 
+// messagePushBody returns the push text of the message, attachment-only messages get a placeholder.
+func messagePushBody(message *models.Message) string {
+	body := renderMentions(message.Content)
+
+	if body == "" && len(message.Attachments) > 0 {
+		body = "Sendte et billede"
+		for _, a := range message.Attachments {
+			if !strings.HasPrefix(a.ContentType, "image/") {
+				body = "Sendte en vedhæftet fil"
+				break
+			}
+		}
+	}
+
+	if len(body) > maxPushBodyLength {
+		body = body[:maxPushBodyLength-3] + "..."
+	}
+
+	return body
+}
+
+// usersWhoMutedSender returns which of the mentioned users currently mute their direct conversation with the sender.
+func usersWhoMutedSender(senderID uint, mentions []models.MessageMention, now time.Time) map[uint]bool {
+	muted := make(map[uint]bool)
+	if len(mentions) == 0 {
+		return muted
+	}
+
+	userIDs := make([]uint, len(mentions))
+	for i, m := range mentions {
+		userIDs[i] = m.UserID
+	}
+
+	var mutedIDs []uint
+	err := config.DB.Model(&models.ConversationParticipant{}).
+		Joins("JOIN conversations ON conversations.id = conversation_participants.conversation_id").
+		Where("conversations.type = ?", models.ConversationTypeDirect).
+		Where("conversation_participants.user_id IN ?", userIDs).
+		Where("conversation_participants.conversation_id IN (SELECT conversation_id FROM conversation_participants WHERE user_id = ?)", senderID).
+		Where("conversation_participants.muted_at IS NOT NULL").
+		Where("conversation_participants.muted_until IS NULL OR conversation_participants.muted_until > ?", now).
+		Pluck("conversation_participants.user_id", &mutedIDs).
+		Error
+
+	if err != nil {
+		slog.Warn("Failed to check muted senders for push",
+			slog.Uint64("sender_id", uint64(senderID)),
+			slog.Any("error", err),
+		)
+	}
+
+	for _, id := range mutedIDs {
+		muted[id] = true
+	}
+
+	return muted
+}
+
 func getSenderDisplayName(message *models.Message) string {
 	if message.Sender.ID != 0 {
 		name := strings.TrimSpace(message.Sender.FirstName + " " + message.Sender.LastName)
@@ -148,7 +596,8 @@ func truncateForLog(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// GetMessages retrieves messages from a conversation with pagination
+// GetMessages retrieves messages from a conversation with pagination.
+// Deleted messages are included as tombstones.
 func GetMessages(conversationID, userID uint, limit int, beforeMessageID *uint) ([]models.Message, bool, int64, error) {
 	// Check if user is a member
 	isMember, err := IsConversationMember(conversationID, userID)
@@ -171,6 +620,10 @@ func GetMessages(conversationID, userID uint, limit int, beforeMessageID *uint)
 		Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).
 		Where("conversation_id = ?", conversationID).
 		Preload("Sender").
+		Preload("Reactions", visibleReactions(userID)).
+		Preload("Attachments", orderByID).
+		Preload("Mentions", orderByID).
+		Scopes(preloadReplyTo(userID)).
 		Order("created_at DESC")
 
 	// Apply cursor-based pagination
@@ -202,6 +655,93 @@ func GetMessages(conversationID, userID uint, limit int, beforeMessageID *uint)
 	return messages, hasMore, total, nil
 }
 
+// GetMessagesSince returns messages from all of the user's conversations after the given message
+// and, if set, after the given time, oldest first. Used to replay missed messages on reconnect.
+// Only conversations the user still belongs to are replayed, from the time they joined.
+func GetMessagesSince(userID uint, afterMessageID uint, since *time.Time, limit int) ([]models.Message, error) {
+	query := config.DB.
+		Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).
+		Where(`EXISTS (SELECT 1 FROM conversation_participants cp
+			WHERE cp.conversation_id = messages.conversation_id AND cp.user_id = ?
+			AND cp.left_at IS NULL AND messages.created_at >= cp.joined_at)`, userID).
+		Where("id > ?", afterMessageID).
+		Preload("Sender").
+		Preload("Reactions", visibleReactions(userID)).
+		Preload("Attachments", orderByID).
+		Preload("Mentions", orderByID).
+		Scopes(preloadReplyTo(userID)).
+		Order("id ASC").
+		Limit(limit)
+
+	if since != nil {
+		query = query.Where("created_at > ?", *since)
+	}
+
+	var messages []models.Message
+	if err := query.Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// GetMessageThread returns a message and every reply below it, oldest first.
+// Replies of blocked users are left out, a deleted message is returned as a tombstone.
+func GetMessageThread(conversationID, messageID, userID uint) (*models.Message, []models.Message, error) {
+	isMember, err := IsConversationMember(conversationID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isMember {
+		return nil, nil, appError.ErrNotConversationMember
+	}
+
+	var message models.Message
+	err = config.DB.
+		Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).
+		Where("id = ? AND conversation_id = ?", messageID, conversationID).
+		Preload("Sender").
+		Preload("Reactions", visibleReactions(userID)).
+		Preload("Attachments", orderByID).
+		Preload("Mentions", orderByID).
+		Scopes(preloadReplyTo(userID)).
+		First(&message).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, appError.ErrMessageNotFound
+		}
+		return nil, nil, err
+	}
+
+	var replies []models.Message
+	err = config.DB.
+		Scopes(ExcludeBlockedUsersOn(userID, "sender_id")).
+		Where(`id IN (
+			WITH RECURSIVE thread AS (
+				SELECT id FROM messages WHERE reply_to_message_id = ?
+				UNION
+				SELECT m.id FROM messages m JOIN thread t ON m.reply_to_message_id = t.id
+			)
+			SELECT id FROM thread
+		)`, message.ID).
+		Preload("Sender").
+		Preload("Reactions", visibleReactions(userID)).
+		Preload("Attachments", orderByID).
+		Preload("Mentions", orderByID).
+		Scopes(preloadReplyTo(userID)).
+		Order("id ASC").
+		Find(&replies).
+		Error
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &message, replies, nil
+}
+
 // GetMessageByID retrieves a single message
 func GetMessageByID(messageID uint) (*models.Message, error) {
 	var message models.Message
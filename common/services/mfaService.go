@@ -0,0 +1,423 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+	"server/common/totp"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	mfaIssuer = "Challenger"
+	// How long the second login step may take
+	mfaChallengeTTL = 5 * time.Minute
+	// Wrong codes before the challenge is discarded and the password must be entered again
+	mfaChallengeMaxAttempts = 5
+	recoveryCodeCount       = 10
+	// OAuth accounts have no password, so enrolling requires a session this recent instead
+	mfaReauthWindow = 10 * time.Minute
+)
+
+// MFAEnrollment is returned when 2FA is set up, the secret is shown to the user once.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// --- POST ---
+
+// EnrollMFA creates a new TOTP secret for the user.
+// 2FA isn't active until a code from the authenticator app is confirmed with ConfirmMFA.
+// Password accounts must confirm their password and OAuth accounts must have signed in recently,
+// so a stolen access token can't set up 2FA and lock the owner out.
+func EnrollMFA(userID, sessionID uint, password string) (MFAEnrollment, error) {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return MFAEnrollment{}, appError.ErrMFAAlreadyEnabled
+	}
+
+	if err := reauthenticate(&user, sessionID, password); err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	err = config.DB.Model(&user).Updates(map[string]any{
+		"totp_secret":         secret,
+		"totp_last_used_step": 0,
+	}).Error
+
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	return MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA activates 2FA and returns the recovery codes.
+func ConfirmMFA(userID uint, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, userID).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		if user.TOTPEnabledAt != nil {
+			return appError.ErrMFAAlreadyEnabled
+		}
+
+		if user.TOTPSecret == nil {
+			return appError.ErrMFANotEnabled
+		}
+
+		subject := mfaCodeThrottle(&user)
+		if err := checkThrottle(subject); err != nil {
+			return err
+		}
+
+		step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), user.TOTPLastUsedStep)
+		if !ok {
+			if _, err := recordFailure(subject); err != nil {
+				return err
+			}
+			return appError.ErrInvalidMFACode
+		}
+
+		if err := clearThrottle(subject); err != nil {
+			return err
+		}
+
+		err = tx.Model(&user).Updates(map[string]any{
+			"totp_enabled_at":     time.Now(),
+			"totp_last_used_step": step,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		recoveryCodes, err = replaceRecoveryCodes(userID, tx)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// VerifyMFALogin completes a login that returned an MFARequiredError.
+// Either a TOTP code or one of the recovery codes is accepted.
+func VerifyMFALogin(mfaToken, code string, client SessionClient) (*models.User, AuthTokens, error) {
+	var challenge models.MFAChallenge
+	err := config.DB.
+		Where("token_hash = ? AND expires_at > ?", hashToken(mfaToken), time.Now()).
+		First(&challenge).
+		Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, AuthTokens{}, appError.ErrInvalidToken
+	}
+
+	if err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	// Include deleted accounts, they are restored once the code is right
+	var user models.User
+	if err := config.DB.Unscoped().Preload("Settings").First(&user, challenge.UserID).Error; err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	subjects := loginThrottleSubjects(user.Email, client.IPAddress)
+	if err := checkThrottle(subjects...); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	ok, err := verifyMFACode(&user, code, config.DB)
+	if err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	if !ok {
+		if _, err := recordFailure(subjects...); err != nil {
+			return nil, AuthTokens{}, err
+		}
+
+		err := config.DB.Model(&challenge).
+			UpdateColumn("attempts", gorm.Expr("attempts + 1")).
+			Error
+
+		if err != nil {
+			return nil, AuthTokens{}, err
+		}
+
+		if challenge.Attempts+1 >= mfaChallengeMaxAttempts {
+			config.DB.Delete(&challenge)
+		}
+
+		return nil, AuthTokens{}, appError.ErrInvalidMFACode
+	}
+
+	if err := config.DB.Delete(&challenge).Error; err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	if err := clearThrottle(subjects[:2]...); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	if err := restoreDeletedAccount(&user); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	tokens, err := CreateSession(&user, client)
+	if err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	return &user, tokens, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, e.g. when they are used up.
+func RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := requireMFACode(userID, code, tx)
+		if err != nil {
+			return err
+		}
+
+		recoveryCodes, err = replaceRecoveryCodes(user.ID, tx)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// --- DELETE ---
+
+// DisableMFA turns 2FA off, a valid code is required so a stolen session can't do it.
+func DisableMFA(userID uint, code string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := requireMFACode(userID, code, tx)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&user).Updates(map[string]any{
+			"totp_secret":         nil,
+			"totp_enabled_at":     nil,
+			"totp_last_used_step": 0,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFAChallenge{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// Package private methods
+
+// createMFAChallenge starts the second login step and returns its token.
+func createMFAChallenge(userID uint) (*appError.MFARequiredError, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := models.MFAChallenge{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	}
+
+	if err := config.DB.Create(&challenge).Error; err != nil {
+		return nil, err
+	}
+
+	return &appError.MFARequiredError{Token: token, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// requireMFACode loads a user with 2FA enabled and checks the code.
+func requireMFACode(userID uint, code string, db *gorm.DB) (models.User, error) {
+	var user models.User
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&user, userID).
+		Error
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if user.TOTPEnabledAt == nil {
+		return models.User{}, appError.ErrMFANotEnabled
+	}
+
+	subject := mfaCodeThrottle(&user)
+	if err := checkThrottle(subject); err != nil {
+		return models.User{}, err
+	}
+
+	ok, err := verifyMFACode(&user, code, db)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if !ok {
+		if _, err := recordFailure(subject); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, appError.ErrInvalidMFACode
+	}
+
+	if err := clearThrottle(subject); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// reauthenticate confirms that the owner is present, rather than just someone holding their access token.
+// Wrong passwords count towards the login lockout of the account.
+func reauthenticate(user *models.User, sessionID uint, password string) error {
+	if user.AuthProvider != "" {
+		var session models.UserSession
+		err := config.DB.
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, user.ID).
+			First(&session).
+			Error
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrReauthRequired
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if time.Since(session.CreatedAt) > mfaReauthWindow {
+			return appError.ErrReauthRequired
+		}
+
+		return nil
+	}
+
+	subject := mfaCodeThrottle(user)
+	if err := checkThrottle(subject); err != nil {
+		return err
+	}
+
+	if user.Password == nil ||
+		bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)) != nil {
+		return failedAttempt(subject)
+	}
+
+	return nil
+}
+
+// mfaCodeThrottle counts wrong codes of a signed in user towards the login lockout of the account,
+// so a stolen session can't guess a code to turn 2FA off or to get new recovery codes.
+// The failures are recorded outside the caller's transaction, so its rollback doesn't undo them.
+func mfaCodeThrottle(user *models.User) throttleSubject {
+	return loginThrottleSubjects(user.Email, "")[0]
+}
+
+// verifyMFACode accepts a TOTP code or an unused recovery code and marks it as used.
+func verifyMFACode(user *models.User, code string, db *gorm.DB) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+
+	if step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), user.TOTPLastUsedStep); ok {
+		// Only move forward, a concurrent login may have used a later step
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+			Update("totp_last_used_step", step)
+
+		return result.RowsAffected > 0, result.Error
+	}
+
+	result := db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// replaceRecoveryCodes deletes the old recovery codes and returns the new ones in plain text.
+func replaceRecoveryCodes(userID uint, db *gorm.DB) ([]string, error) {
+	if err := db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]models.MFARecoveryCode, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		raw := base32.StdEncoding.EncodeToString(b)
+		codes[i] = fmt.Sprintf("%s-%s", raw[:4], raw[4:])
+		rows[i] = models.MFARecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(codes[i])),
+		}
+	}
+
+	if err := db.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and dash insensitive
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+}
@@ -74,6 +74,7 @@ func GetMyNotifications(userID uint, filters NotificationFilters) ([]models.Noti
 	query := config.DB.
 		Scopes(ExcludeBlockedUsersOn(userID, "actor_id")).
 		Preload("Actor").
+		Preload("Actor.Settings").
 		Where("user_id = ? AND is_relevant = ?", userID, true)
 
 	// Apply Read/Unread filter if provided
@@ -419,6 +420,25 @@ func CreateTeamDeletedNotification(db *gorm.DB, user models.User, team models.Te
 	})
 }
 
+// User joined through an invite link, notifies the team owners and admins
+func CreateUserJoinedTeamViaLinkNotification(db *gorm.DB, recipientID uint, joiner models.User, team models.Team) {
+	title := "Nyt medlem i klubben"
+	content := fmt.Sprintf("%s er blevet medlem af '%s' via et invitationslink", joiner.FirstName, team.Name)
+
+	rid := team.ID
+	rType := models.ResourceTypeTeam
+
+	CreateNotification(db, NotificationParams{
+		RecipientID:  recipientID,
+		Type:         models.NotifTypeTeamJoinedLink,
+		Title:        title,
+		Content:      content,
+		ActorID:      &joiner.ID,
+		ResourceID:   &rid,
+		ResourceType: &rType,
+	})
+}
+
 // ------ CHALLENGES ----- \\
 
 func CreateUserJoinedChallengeNotificationToCreator(db *gorm.DB, user models.User, challenge models.Challenge) {
@@ -552,6 +572,41 @@ func CreateNotificationChallengeMissingParticipants(db *gorm.DB, user models.Use
 	})
 }
 
+// ------ ACCOUNT ----- \\
+
+func CreateDataExportReadyNotification(db *gorm.DB, userID uint) {
+	title := "Din data-eksport er klar"
+	content := fmt.Sprintf("Du kan downloade dine data de næste %d timer", config.AppConfig.DataExportExpirationHours)
+
+	CreateNotification(db, NotificationParams{
+		RecipientID: userID,
+		Type:        models.NotifTypeSystem,
+		Title:       title,
+		Content:     content,
+	})
+}
+
+// ------ MODERATION ----- \\
+
+// CreateReportOutcomeNotification tells a reporter how their report was handled
+func CreateReportOutcomeNotification(db *gorm.DB, reporterID uint, status string) {
+	notifType := models.NotifTypeReportResolved
+	title := "Din anmeldelse er behandlet"
+	content := "Tak for din anmeldelse. Vi har gennemgået den og grebet ind"
+
+	if status == models.ReportStatusDismissed {
+		notifType = models.NotifTypeReportDismissed
+		content = "Tak for din anmeldelse. Vi har gennemgået den, men fandt ikke et brud på vores retningslinjer"
+	}
+
+	CreateNotification(db, NotificationParams{
+		RecipientID: reporterID,
+		Type:        notifType,
+		Title:       title,
+		Content:     content,
+	})
+}
+
 // -------------- Private -------------- \\
 func shouldNotify(db *gorm.DB, userID uint, notifType models.NotificationType) bool {
 	var settings models.UserSettings
@@ -633,7 +688,12 @@ func persistNotification(db *gorm.DB, params NotificationParams) {
 
 	// Savepoint
 	err := db.Transaction(func(tx *gorm.DB) error {
-		return tx.Create(&n).Error
+		if err := tx.Create(&n).Error; err != nil {
+			return err
+		}
+
+		publishNotification(n, tx)
+		return nil
 	})
 
 	if err != nil {
@@ -654,6 +714,7 @@ var notificationSettingsMap = map[models.NotificationType]func(s models.UserSett
 	models.NotifTypeTeamRemovedUser: func(s models.UserSettings) bool { return s.NotifyTeamMembership },
 	models.NotifTypeTeamUserLeft:    func(s models.UserSettings) bool { return s.NotifyTeamMembership },
 	models.NotifTypeTeamDeleted:     func(s models.UserSettings) bool { return s.NotifyTeamMembership },
+	models.NotifTypeTeamJoinedLink:  func(s models.UserSettings) bool { return s.NotifyTeamMembership },
 
 	// ---------------- Friend ----------------
 	models.NotifTypeFriendReq:     func(s models.UserSettings) bool { return s.NotifyFriendRequests },
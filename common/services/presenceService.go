@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// How often the chat instances confirm their open connections
+	PresenceHeartbeatPeriod = 30 * time.Second
+
+	// Connections that weren't confirmed for this long belong to an instance that went away
+	presenceStaleAfter = 3 * PresenceHeartbeatPeriod
+
+	// Users whose apps sent nothing for this long are away
+	presenceAwayAfter = 5 * time.Minute
+)
+
+// --- GET ---
+
+// GetPresence returns the presence of the given users as the viewer sees it, in the same order.
+// Users who hide their presence, aren't friends or chat partners of the viewer, or are blocked appear offline.
+func GetPresence(userIDs []uint, viewerID uint) ([]models.UserPresence, error) {
+	audience, err := presenceAudience(viewerID, config.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[uint]bool, len(audience)+1)
+	visible[viewerID] = true
+	for _, id := range audience {
+		visible[id] = true
+	}
+
+	var rows []models.UserPresence
+	err = config.DB.
+		Where("user_id IN ?", userIDs).
+		Where("user_id = ? OR user_id NOT IN (SELECT user_id FROM user_settings WHERE show_presence = false)", viewerID).
+		Find(&rows).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint]models.UserPresence, len(rows))
+	for _, p := range rows {
+		byUser[p.UserID] = p
+	}
+
+	presences := make([]models.UserPresence, len(userIDs))
+	for i, id := range userIDs {
+		p, ok := byUser[id]
+		if !ok || !visible[id] {
+			p = models.UserPresence{UserID: id, Status: models.PresenceOffline}
+		}
+		presences[i] = p
+	}
+
+	return presences, nil
+}
+
+// --- POST ---
+
+// ConnectPresence records a new connection of the user and returns its ID.
+func ConnectPresence(userID uint) (uint, error) {
+	now := time.Now()
+	connection := models.PresenceConnection{
+		UserID:          userID,
+		LastActiveAt:    now,
+		LastHeartbeatAt: now,
+	}
+
+	if err := config.DB.Create(&connection).Error; err != nil {
+		return 0, err
+	}
+
+	return connection.ID, RefreshPresence([]uint{userID})
+}
+
+// HeartbeatPresence confirms the open connections of a chat instance.
+// The active ones received something from the app since the previous heartbeat.
+func HeartbeatPresence(connectionIDs []uint, activeIDs []uint) error {
+	now := time.Now()
+
+	if len(connectionIDs) > 0 {
+		err := config.DB.Model(&models.PresenceConnection{}).
+			Where("id IN ?", connectionIDs).
+			Update("last_heartbeat_at", now).
+			Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(activeIDs) > 0 {
+		return config.DB.Model(&models.PresenceConnection{}).
+			Where("id IN ?", activeIDs).
+			Update("last_active_at", now).
+			Error
+	}
+
+	return nil
+}
+
+// RefreshPresence combines the connections of each user into their presence, and publishes the ones that changed.
+// A user is online when any connection was recently active, away when none was, and offline without connections.
+func RefreshPresence(userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	var rows []struct {
+		UserID       uint
+		LastActiveAt time.Time
+	}
+
+	err := config.DB.Model(&models.PresenceConnection{}).
+		Select("user_id, MAX(last_active_at) AS last_active_at").
+		Where("user_id IN ? AND last_heartbeat_at > ?", userIDs, now.Add(-presenceStaleAfter)).
+		Group("user_id").
+		Scan(&rows).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	lastActive := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		lastActive[row.UserID] = row.LastActiveAt
+	}
+
+	seen := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		status := models.PresenceOffline
+		if at, ok := lastActive[userID]; ok {
+			status = models.PresenceAway
+			if at.After(now.Add(-presenceAwayAfter)) {
+				status = models.PresenceOnline
+			}
+		}
+
+		if err := setPresence(userID, status, now, config.DB); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// --- DELETE ---
+
+// DisconnectPresence removes a closed connection, the user goes offline with their last one.
+func DisconnectPresence(connectionID, userID uint) error {
+	if err := config.DB.Delete(&models.PresenceConnection{}, connectionID).Error; err != nil {
+		return err
+	}
+
+	return RefreshPresence([]uint{userID})
+}
+
+// ExpirePresenceConnections removes the connections of chat instances that stopped sending heartbeats,
+// e.g. because they crashed, and updates the presence of their users.
+func ExpirePresenceConnections() error {
+	var userIDs []uint
+	err := config.DB.
+		Raw("DELETE FROM presence_connections WHERE last_heartbeat_at < ? RETURNING user_id", time.Now().Add(-presenceStaleAfter)).
+		Scan(&userIDs).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	return RefreshPresence(userIDs)
+}
+
+// Package private methods
+
+// setPresence stores the user's presence and publishes it if it changed.
+// Concurrent updates from several chat instances only publish a change once.
+func setPresence(userID uint, status models.PresenceStatus, now time.Time, db *gorm.DB) error {
+	var lastSeenAt *time.Time
+	if status == models.PresenceOffline {
+		lastSeenAt = &now
+	}
+
+	var changed []models.UserPresence
+	err := db.Raw(`
+		INSERT INTO user_presences (user_id, status, last_seen_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			last_seen_at = COALESCE(EXCLUDED.last_seen_at, user_presences.last_seen_at),
+			updated_at = EXCLUDED.updated_at
+		WHERE user_presences.status <> EXCLUDED.status
+		RETURNING *`, userID, status, lastSeenAt, now).
+		Scan(&changed).
+		Error
+
+	if err != nil || len(changed) == 0 {
+		return err
+	}
+
+	var hidden int64
+	err = db.Model(&models.UserSettings{}).
+		Where("user_id = ? AND show_presence = false", userID).
+		Count(&hidden).
+		Error
+
+	if err != nil || hidden > 0 {
+		return err
+	}
+
+	return publishPresence(changed[0], db)
+}
+
+// publishPresenceVisibility tells the user's audience that they turned presence on or off.
+// Hidden users look like they went offline, without a last seen time.
+func publishPresenceVisibility(userID uint, visible bool, db *gorm.DB) error {
+	presence := models.UserPresence{UserID: userID, Status: models.PresenceOffline}
+
+	if visible {
+		err := db.Where("user_id = ?", userID).First(&presence).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	return publishPresence(presence, db)
+}
+
+// publishPresence sends the presence to the user's friends and chat partners.
+func publishPresence(presence models.UserPresence, db *gorm.DB) error {
+	audience, err := presenceAudience(presence.UserID, db)
+	if err != nil {
+		return err
+	}
+
+	res := dto.ToPresenceResponseDto(presence)
+	PublishRealtimeEventToUsers(dto.RealtimeEventDto{
+		Type:     dto.RealtimeEventPresence,
+		UserID:   presence.UserID,
+		Presence: &res,
+	}, audience, db)
+
+	return nil
+}
+
+// presenceAudience returns who may see the user's presence: friends and the other active
+// participants of direct and group conversations, except blocked users in either direction.
+func presenceAudience(userID uint, db *gorm.DB) ([]uint, error) {
+	var userIDs []uint
+	err := db.Raw(`
+		SELECT friend_id FROM user_friends WHERE user_id = ?
+		UNION
+		SELECT other.user_id
+		FROM conversation_participants own
+		JOIN conversation_participants other ON other.conversation_id = own.conversation_id
+		JOIN conversations c ON c.id = own.conversation_id
+		WHERE own.user_id = ? AND other.user_id <> own.user_id
+			AND own.left_at IS NULL AND other.left_at IS NULL
+			AND c.type IN ?`,
+		userID, userID, []models.ConversationType{models.ConversationTypeDirect, models.ConversationTypeGroup}).
+		Scan(&userIDs).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[uint]bool)
+	for _, id := range GetBlockedUserIDs(userID) {
+		blocked[id] = true
+	}
+
+	audience := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !blocked[id] {
+			audience = append(audience, id)
+		}
+	}
+
+	return audience, nil
+}
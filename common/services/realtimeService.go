@@ -0,0 +1,105 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"server/common/backplane"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+)
+
+// realtimeBackplane delivers realtime events to the chat instances.
+// Without one, e.g. in tests, events are dropped.
+var realtimeBackplane backplane.Backplane
+
+func SetBackplane(b backplane.Backplane) {
+	realtimeBackplane = b
+}
+
+// PublishRealtimeEvent sends the event to the clients it routes to, on every chat instance.
+// When db is a transaction, the event is sent once it commits.
+// Realtime delivery is best effort, so errors are logged and never fail the caller.
+func PublishRealtimeEvent(evt dto.RealtimeEventDto, db *gorm.DB) {
+	publishRealtime(backplane.Envelope{Event: evt}, db)
+}
+
+// PublishRealtimeEventToUsers sends the event to exactly the given users, on every chat instance.
+func PublishRealtimeEventToUsers(evt dto.RealtimeEventDto, userIDs []uint, db *gorm.DB) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	publishRealtime(backplane.Envelope{Event: evt, UserIDs: userIDs}, db)
+}
+
+// Package private methods
+func publishRealtime(env backplane.Envelope, db *gorm.DB) {
+	if realtimeBackplane == nil {
+		return
+	}
+
+	if db == nil {
+		db = config.DB
+	}
+
+	if env.Event.Timestamp.IsZero() {
+		env.Event.Timestamp = time.Now()
+	}
+
+	// Savepoint, a failed publish must not abort the caller's transaction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return realtimeBackplane.Publish(env, tx)
+	})
+
+	if err != nil {
+		slog.Error("Failed to publish realtime event",
+			slog.String("type", string(env.Event.Type)),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// publishNotification pushes a new notification to the recipient's open connections.
+func publishNotification(n models.Notification, db *gorm.DB) {
+	if realtimeBackplane == nil {
+		return
+	}
+
+	if n.ActorID != nil {
+		var actor models.User
+		if err := db.First(&actor, *n.ActorID).Error; err == nil {
+			n.Actor = &actor
+		}
+	}
+
+	notification := dto.ToNotificationResponseDto(n)
+	PublishRealtimeEventToUsers(dto.RealtimeEventDto{
+		Type:         dto.RealtimeEventNotification,
+		Notification: &notification,
+	}, []uint{n.UserID}, db)
+}
+
+// publishMembershipChange tells the members of a conversation, and those who were removed, who joined or left.
+func publishMembershipChange(conversationID uint, teamID *uint, added, removed, memberIDs []uint, db *gorm.DB) {
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	recipients := make([]uint, 0, len(memberIDs)+len(removed))
+	recipients = append(recipients, memberIDs...)
+	recipients = append(recipients, removed...)
+
+	PublishRealtimeEventToUsers(dto.RealtimeEventDto{
+		Type:           dto.RealtimeEventMembershipChanged,
+		ConversationID: &conversationID,
+		TeamID:         teamID,
+		Membership: &dto.MembershipChangeDto{
+			Added:   added,
+			Removed: removed,
+		},
+	}, recipients, db)
+}
@@ -1,13 +1,99 @@
 package services
 
 import (
+	"strings"
+	"time"
+
+	"server/common/appError"
 	"server/common/config"
 	"server/common/dto"
 	"server/common/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// reportReasonSeverity ranks known reasons for the moderation queue, unknown reasons count as 1.
+// Keep in sync with the backfill in the add_report_moderation migration.
+var reportReasonSeverity = map[string]int{
+	"CHILD_SAFETY":       5,
+	"VIOLENCE":           4,
+	"THREAT":             4,
+	"SELF_HARM":          4,
+	"HARASSMENT":         3,
+	"HATE_SPEECH":        3,
+	"RACISM":             3,
+	"SEXUAL_CONTENT":     3,
+	"SCAM":               2,
+	"IMPERSONATION":      2,
+	"INAPPROPRIATE_NAME": 2,
+}
+
+type ModerationQueueFilters struct {
+	TargetType models.ReportTargetType
+	Limit      int
+	Offset     int
+}
+
+// --- GET ---
+
+// GetModerationQueue returns the targets with pending reports, most severe and most reported first.
+func GetModerationQueue(filters ModerationQueueFilters) ([]models.ReportGroup, error) {
+	query := config.DB.Model(&models.Report{}).
+		Select(`target_type, target_id,
+			COUNT(*) AS report_count,
+			MAX(severity) AS severity,
+			STRING_AGG(DISTINCT reason, ',') AS reasons,
+			MIN(created_at) AS first_reported_at,
+			MAX(created_at) AS last_reported_at`).
+		Where("status = ?", models.ReportStatusPending).
+		Group("target_type, target_id")
+
+	if filters.TargetType != "" {
+		query = query.Where("target_type = ?", filters.TargetType)
+	}
+
+	var groups []models.ReportGroup
+	err := paginate(query, filters.Limit, filters.Offset).
+		Order("severity desc").
+		Order("report_count desc").
+		Order("first_reported_at asc").
+		Scan(&groups).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// GetReportsForTarget returns every report about a target, including resolved ones.
+func GetReportsForTarget(targetType models.ReportTargetType, targetID uint) ([]models.Report, error) {
+	var reports []models.Report
+	err := config.DB.
+		Preload("Reporter", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("ResolvedBy", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("created_at desc").
+		Find(&reports).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// --- POST ---
+
+// CreateReport files a report. A reporter has at most one pending report per target,
+// reporting the same target again while it is pending is a no-op.
 func CreateReport(reporterID uint, req dto.ReportCreateDto) error {
 	return config.DB.Transaction(func(tx *gorm.DB) error {
 		report := models.Report{
@@ -15,15 +101,164 @@ func CreateReport(reporterID uint, req dto.ReportCreateDto) error {
 			TargetID:   req.TargetID,
 			TargetType: models.ReportTargetType(req.TargetType),
 			Reason:     req.Reason,
+			Severity:   reportSeverity(req.Reason),
 			Comment:    req.Comment,
-			Status:     "PENDING",
+			Status:     models.ReportStatusPending,
+		}
+
+		if report.TargetType == models.ReportTargetMessage {
+			if err := snapshotReportedMessage(&report, tx); err != nil {
+				return err
+			}
 		}
 
 		// Use 'tx' instead of 'config.DB' to ensure this runs inside the transaction
-		if err := tx.Create(&report).Error; err != nil {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "reporter_id"}, {Name: "target_id"}, {Name: "target_type"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'PENDING'"}}},
+			DoNothing:   true,
+		}).Create(&report).Error
+
+		if err != nil {
 			return err
 		}
 
 		return nil
 	})
 }
+
+// ResolveReports closes all pending reports about a target with the given outcome.
+// Every reporter is notified, the moderator's note stays internal.
+func ResolveReports(moderatorID uint, targetType models.ReportTargetType, targetID uint, status string, note string) error {
+	if status != models.ReportStatusResolved && status != models.ReportStatusDismissed {
+		return appError.ErrBadRequest
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var reports []models.Report
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportStatusPending).
+			Find(&reports).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		if len(reports) == 0 {
+			return appError.ErrNoPendingReports
+		}
+
+		ids := make([]uint, len(reports))
+		for i, r := range reports {
+			ids[i] = r.ID
+		}
+
+		err = tx.Model(&models.Report{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":          status,
+				"resolved_by_id":  moderatorID,
+				"resolution_note": note,
+				"resolved_at":     time.Now(),
+			}).Error
+
+		if err != nil {
+			return err
+		}
+
+		action := models.AdminActionReportsResolved
+		if status == models.ReportStatusDismissed {
+			action = models.AdminActionReportsDismissed
+		}
+
+		err = writeAuditLog(moderatorID, action, strings.ToLower(string(targetType)), targetID, map[string]any{
+			"report_ids": ids,
+			"note":       note,
+		}, tx)
+
+		if err != nil {
+			return err
+		}
+
+		notified := make(map[uint]bool)
+		for _, r := range reports {
+			if notified[r.ReporterID] {
+				continue
+			}
+			notified[r.ReporterID] = true
+
+			CreateReportOutcomeNotification(tx, r.ReporterID, status)
+		}
+
+		return nil
+	})
+}
+
+// Package private methods
+func reportSeverity(reason string) int {
+	key := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(reason), " ", "_"))
+	if severity, ok := reportReasonSeverity[key]; ok {
+		return severity
+	}
+
+	return 1
+}
+
+// snapshotReportedMessage copies the message into the report.
+// Only messages the reporter can see may be reported.
+func snapshotReportedMessage(report *models.Report, db *gorm.DB) error {
+	var message models.Message
+	if err := db.First(&message, report.TargetID).Error; err != nil {
+		return err
+	}
+
+	// Nothing left to review
+	if message.IsDeleted() {
+		return appError.ErrMessageDeleted
+	}
+
+	canSee, err := canSeeMessage(report.ReporterID, message, db)
+	if err != nil {
+		return err
+	}
+
+	if !canSee {
+		return appError.ErrNotConversationMember
+	}
+
+	content := message.Content
+	senderID := message.SenderID
+	report.TargetSnapshot = &content
+	report.TargetOwnerID = &senderID
+
+	return nil
+}
+
+func canSeeMessage(userID uint, message models.Message, db *gorm.DB) (bool, error) {
+	if message.SenderID == userID || (message.RecipientID != nil && *message.RecipientID == userID) {
+		return true, nil
+	}
+
+	var count int64
+	var err error
+
+	switch {
+	case message.ConversationID != nil:
+		err = db.Model(&models.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id = ?", *message.ConversationID, userID).
+			Count(&count).
+			Error
+	case message.TeamID != nil:
+		err = db.Model(&models.TeamMember{}).
+			Where("team_id = ? AND user_id = ?", *message.TeamID, userID).
+			Count(&count).
+			Error
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SessionClient describes the device a session is started from
+type SessionClient struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// AuthTokens is a short-lived access token and the refresh token used to renew it
+type AuthTokens struct {
+	AccessToken          string
+	AccessTokenExpiresAt time.Time
+	RefreshToken         string
+}
+
+// --- GET ---
+func GetActiveSessions(userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := config.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at desc").
+		Find(&sessions).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// ValidateSession checks that the session behind an access token hasn't been revoked or expired.
+func ValidateSession(userID, sessionID uint) error {
+	// Tokens issued before sessions existed can't be revoked, so they are rejected
+	if sessionID == 0 {
+		return appError.ErrSessionRevoked
+	}
+
+	var count int64
+	err := config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).
+		Count(&count).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return appError.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// GetInvalidSessionIDs returns which of the given sessions were revoked, expired or deleted.
+func GetInvalidSessionIDs(sessionIDs []uint) ([]uint, error) {
+	if len(sessionIDs) == 0 {
+		return nil, nil
+	}
+
+	var validIDs []uint
+	err := config.DB.Model(&models.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL AND expires_at > ?", sessionIDs, time.Now()).
+		Pluck("id", &validIDs).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make(map[uint]bool, len(validIDs))
+	for _, id := range validIDs {
+		valid[id] = true
+	}
+
+	var invalid []uint
+	for _, id := range sessionIDs {
+		if !valid[id] {
+			invalid = append(invalid, id)
+		}
+	}
+
+	return invalid, nil
+}
+
+// --- POST ---
+
+// CreateSession starts a new device session and returns its first token pair.
+func CreateSession(user *models.User, client SessionClient) (AuthTokens, error) {
+	if err := CheckAccountStatus(user); err != nil {
+		return AuthTokens{}, err
+	}
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	now := time.Now()
+	session := models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		DeviceName:       client.DeviceName,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		LastUsedAt:       now,
+		ExpiresAt:        now.AddDate(0, 0, config.AppConfig.RefreshTokenExpirationDays),
+	}
+
+	if err := config.DB.Create(&session).Error; err != nil {
+		return AuthTokens{}, err
+	}
+
+	return issueTokens(user, session.ID, refreshToken)
+}
+
+// RefreshSession exchanges a refresh token for a new token pair.
+// Refresh tokens are single use. Presenting an already rotated token revokes the session,
+// as either the token or its replacement has been stolen.
+func RefreshSession(refreshToken string) (*models.User, AuthTokens, error) {
+	hash := hashToken(refreshToken)
+	newRefreshToken, err := generateToken()
+	if err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	var session models.UserSession
+	reused := false
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ?", hash).
+			First(&session).
+			Error
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var revoked []models.UserSession
+			result := tx.Model(&revoked).
+				Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "user_id"}}}).
+				Where("previous_refresh_token_hash = ? AND revoked_at IS NULL", hash).
+				Update("revoked_at", time.Now())
+
+			for _, s := range revoked {
+				publishSessionRevoked(s.UserID, &s.ID, tx)
+			}
+
+			reused = result.RowsAffected > 0
+			return result.Error
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+			return appError.ErrSessionRevoked
+		}
+
+		return tx.Model(&session).Updates(map[string]any{
+			"refresh_token_hash":          hashToken(newRefreshToken),
+			"previous_refresh_token_hash": hash,
+			"last_used_at":                time.Now(),
+		}).Error
+	})
+
+	if err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	if reused || session.ID == 0 {
+		return nil, AuthTokens{}, appError.ErrSessionRevoked
+	}
+
+	user, err := GetUserByID(session.UserID)
+	if err != nil {
+		return nil, AuthTokens{}, appError.ErrSessionRevoked
+	}
+
+	if err := CheckAccountStatus(user); err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	tokens, err := issueTokens(user, session.ID, newRefreshToken)
+	if err != nil {
+		return nil, AuthTokens{}, err
+	}
+
+	return user, tokens, nil
+}
+
+// --- DELETE ---
+func RevokeSession(userID, sessionID uint) error {
+	var session models.UserSession
+	err := config.DB.
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		First(&session).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	// Revoking twice is a no-op
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	if err := config.DB.Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	publishSessionRevoked(userID, &session.ID, config.DB)
+
+	return nil
+}
+
+// RevokeAllSessions logs the user out on every device.
+func RevokeAllSessions(userID uint) error {
+	return revokeAllSessions(userID, config.DB)
+}
+
+// Package private methods
+func revokeAllSessions(userID uint, db *gorm.DB) error {
+	err := db.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	publishSessionRevoked(userID, nil, db)
+
+	return nil
+}
+
+// publishSessionRevoked disconnects the session from the chat on every instance,
+// or every session of the user when sessionID is nil.
+func publishSessionRevoked(userID uint, sessionID *uint, db *gorm.DB) {
+	PublishRealtimeEventToUsers(dto.RealtimeEventDto{
+		Type:      dto.RealtimeEventSessionRevoked,
+		SessionID: sessionID,
+	}, []uint{userID}, db)
+}
+
+func issueTokens(user *models.User, sessionID uint, refreshToken string) (AuthTokens, error) {
+	accessToken, expiresAt, err := GenerateJWTToken(user, sessionID)
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	return AuthTokens{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: expiresAt,
+		RefreshToken:         refreshToken,
+	}, nil
+}
+
+// hashToken returns the SHA-256 hex digest stored instead of the refresh token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
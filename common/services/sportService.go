@@ -58,3 +58,74 @@ func associateFavoriteSports(tx *gorm.DB, userID uint, sportNames []string) erro
 		Association("FavoriteSports").
 		Replace(sports)
 }
+
+// replaceSportProfiles replaces all sport profiles of a user.
+// Sports with a profile are added to the user's favorite sports if missing.
+func replaceSportProfiles(tx *gorm.DB, userID uint, profiles []models.UserSportProfile) error {
+	var user models.User
+	if err := tx.Preload("FavoriteSports").First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	favorites := make(map[string]bool, len(user.FavoriteSports))
+	for _, s := range user.FavoriteSports {
+		favorites[s.Name] = true
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserSportProfile{}).Error; err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(profiles))
+	var newFavorites []models.Sport
+
+	for _, profile := range profiles {
+		name := profile.Sport.Name
+		if _, ok := config.SportsCache[name]; !ok {
+			return appError.ErrInvalidSport
+		}
+
+		// Ignore duplicates, the first profile for a sport wins
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var sport models.Sport
+		err := tx.Where("name = ?", name).
+			FirstOrCreate(&sport, models.Sport{Name: name}).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		profile.UserID = userID
+		profile.SportID = sport.ID
+		profile.Sport = models.Sport{}
+
+		if err := tx.Create(&profile).Error; err != nil {
+			return err
+		}
+
+		if !favorites[name] {
+			newFavorites = append(newFavorites, sport)
+		}
+	}
+
+	if len(newFavorites) == 0 {
+		return nil
+	}
+
+	return tx.Model(&user).
+		Association("FavoriteSports").
+		Append(newFavorites)
+}
+
+// pruneSportProfiles removes sport profiles for sports that are no longer a favorite.
+func pruneSportProfiles(tx *gorm.DB, userID uint) error {
+	return tx.Where("user_id = ?", userID).
+		Where("sport_id NOT IN (SELECT sport_id FROM user_favorite_sports WHERE user_id = ?)", userID).
+		Delete(&models.UserSportProfile{}).
+		Error
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+
+	"gorm.io/gorm"
+)
+
+// CheckAccountStatus returns an *appError.AccountRestrictedError if the user is banned or suspended.
+func CheckAccountStatus(user *models.User) error {
+	if user.IsBanned() {
+		return &appError.AccountRestrictedError{Reason: user.BanReason}
+	}
+
+	if user.IsSuspended(time.Now()) {
+		return &appError.AccountRestrictedError{Until: user.SuspendedUntil, Reason: user.SuspensionReason}
+	}
+
+	return nil
+}
+
+// HideChallengesOfRestrictedCreators returns a GORM scope that hides public challenges
+// of banned and suspended users, unless the current user created or joined them.
+// Usage: db.Scopes(services.HideChallengesOfRestrictedCreators(currentUserID)).Find(&challenges)
+func HideChallengesOfRestrictedCreators(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`NOT (
+			is_public AND
+			creator_id <> ? AND
+			creator_id IN (SELECT id FROM users WHERE banned_at IS NOT NULL OR suspended_until > ?) AND
+			id NOT IN (SELECT challenge_id FROM user_challenges WHERE user_id = ?)
+		)`, userID, time.Now(), userID)
+	}
+}
+
+// --- GET ---
+
+// GetRestrictedUserIDs returns which of the given users are currently banned or suspended.
+func GetRestrictedUserIDs(userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var ids []uint
+	err := config.DB.Model(&models.User{}).
+		Where("id IN ?", userIDs).
+		Where("banned_at IS NOT NULL OR suspended_until > ?", time.Now()).
+		Pluck("id", &ids).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// --- POST ---
+
+// SuspendUser blocks the user from the app until the given time
+// and logs them out on every device.
+func SuspendUser(moderatorID uint, userID uint, req dto.SuspendUserDto) (*models.User, error) {
+	if !req.Until.After(time.Now()) {
+		return nil, appError.ErrBadRequest
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := getModeratedUser(moderatorID, userID, tx)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"suspended_until":   req.Until,
+			"suspension_reason": req.Reason,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		// Published first, so the app learns why it is disconnected
+		publishAccountRestricted(userID, tx)
+
+		if err := revokeAllSessions(userID, tx); err != nil {
+			return err
+		}
+
+		return writeAuditLog(moderatorID, models.AdminActionUserSuspended, "user", userID, map[string]any{
+			"until":  req.Until,
+			"reason": req.Reason,
+		}, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAdminUserByID(userID)
+}
+
+// BanUser blocks the user from the app until the ban is lifted
+// and logs them out on every device.
+func BanUser(moderatorID uint, userID uint, req dto.BanUserDto) (*models.User, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := getModeratedUser(moderatorID, userID, tx)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"banned_at":  time.Now(),
+			"ban_reason": req.Reason,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		// Published first, so the app learns why it is disconnected
+		publishAccountRestricted(userID, tx)
+
+		if err := revokeAllSessions(userID, tx); err != nil {
+			return err
+		}
+
+		return writeAuditLog(moderatorID, models.AdminActionUserBanned, "user", userID, map[string]any{
+			"reason": req.Reason,
+		}, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAdminUserByID(userID)
+}
+
+// --- DELETE ---
+func UnsuspendUser(moderatorID uint, userID uint) (*models.User, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := getModeratedUser(moderatorID, userID, tx)
+		if err != nil {
+			return err
+		}
+
+		// Lifting a suspension that isn't active is a no-op
+		if !user.IsSuspended(time.Now()) {
+			return nil
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"suspended_until":   nil,
+			"suspension_reason": "",
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(moderatorID, models.AdminActionUserUnsuspended, "user", userID, nil, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAdminUserByID(userID)
+}
+
+func UnbanUser(moderatorID uint, userID uint) (*models.User, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := getModeratedUser(moderatorID, userID, tx)
+		if err != nil {
+			return err
+		}
+
+		// Lifting a ban that doesn't exist is a no-op
+		if !user.IsBanned() {
+			return nil
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"banned_at":  nil,
+			"ban_reason": "",
+		}).Error
+
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(moderatorID, models.AdminActionUserUnbanned, "user", userID, nil, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAdminUserByID(userID)
+}
+
+// Package private methods
+
+// getModeratedUser loads the user a moderator wants to act on.
+// Moderators can't act on themselves, and only admins can act on other moderators or admins.
+func getModeratedUser(moderatorID uint, userID uint, db *gorm.DB) (*models.User, error) {
+	if moderatorID == userID {
+		return nil, appError.ErrSameUser
+	}
+
+	var moderator models.User
+	if err := db.First(&moderator, moderatorID).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	if user.IsModerator() && moderator.Role != models.UserRoleAdmin {
+		return nil, appError.ErrAdminRequired
+	}
+
+	return &user, nil
+}
+
+// publishAccountRestricted disconnects the user from the chat on every instance.
+func publishAccountRestricted(userID uint, db *gorm.DB) {
+	PublishRealtimeEventToUsers(dto.RealtimeEventDto{
+		Type: dto.RealtimeEventAccountRestricted,
+	}, []uint{userID}, db)
+}
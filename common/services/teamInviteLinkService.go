@@ -0,0 +1,224 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- GET ---
+func GetTeamInviteLinks(teamID uint, currentUserID uint) ([]models.TeamInviteLink, error) {
+	if err := ensureTeamOwner(config.DB, teamID, currentUserID); err != nil {
+		return nil, err
+	}
+
+	var links []models.TeamInviteLink
+	err := config.DB.
+		Preload("Creator").
+		Preload("Creator.Settings").
+		Where("team_id = ?", teamID).
+		Order("created_at desc").
+		Find(&links).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// --- POST ---
+func CreateTeamInviteLink(link models.TeamInviteLink) (models.TeamInviteLink, error) {
+	if link.Role == "" {
+		link.Role = models.RoleMember
+	}
+
+	// Links must never be able to hand out ownership
+	if link.Role != models.RoleMember && link.Role != models.RoleAdmin {
+		return models.TeamInviteLink{}, appError.ErrInvalidTeamRole
+	}
+
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return models.TeamInviteLink{}, appError.ErrInviteLinkExpired
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureTeamOwner(tx, link.TeamID, link.CreatorID); err != nil {
+			return err
+		}
+
+		if err := ensureEmailVerified(link.CreatorID, tx); err != nil {
+			return err
+		}
+
+		token, err := generateToken()
+		if err != nil {
+			return err
+		}
+
+		link.Token = token
+		link.Uses = 0
+		link.RevokedAt = nil
+
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+
+		return tx.Preload("Creator").Preload("Creator.Settings").First(&link, link.ID).Error
+	})
+
+	if err != nil {
+		return models.TeamInviteLink{}, err
+	}
+
+	return link, nil
+}
+
+// RedeemTeamInviteLink adds the current user to the team behind the link.
+// Owners and admins are notified and the team conversation is synced afterwards.
+func RedeemTeamInviteLink(token string, currentUserID uint) (models.Team, error) {
+	var teamID uint
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var link models.TeamInviteLink
+
+		// Lock the link so concurrent redemptions can't exceed MaxUses
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token = ?", token).
+			First(&link).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		if link.RevokedAt != nil {
+			return appError.ErrInviteLinkRevoked
+		}
+
+		if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+			return appError.ErrInviteLinkExpired
+		}
+
+		if link.MaxUses != nil && link.Uses >= *link.MaxUses {
+			return appError.ErrInviteLinkExhausted
+		}
+
+		if IsBlocked(link.CreatorID, currentUserID) {
+			return appError.ErrUserBlocked
+		}
+
+		if err := addUserToTeam(link.TeamID, currentUserID, link.Role, tx); err != nil {
+			return err
+		}
+
+		err = tx.Model(&link).
+			UpdateColumn("uses", gorm.Expr("uses + 1")).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		var team models.Team
+		if err := tx.First(&team, link.TeamID).Error; err != nil {
+			return err
+		}
+
+		var joiner models.User
+		if err := tx.First(&joiner, currentUserID).Error; err != nil {
+			return err
+		}
+
+		// Notify owners and admins
+		var managers []models.TeamMember
+		err = tx.Where("team_id = ? AND role IN ? AND user_id <> ?",
+			link.TeamID, []models.TeamRole{models.RoleOwner, models.RoleAdmin}, currentUserID).
+			Find(&managers).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		for _, m := range managers {
+			CreateUserJoinedTeamViaLinkNotification(tx, m.UserID, joiner, team)
+		}
+
+		teamID = team.ID
+
+		return nil
+	})
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	syncTeamConversation(teamID)
+
+	return GetTeamByID(teamID, currentUserID)
+}
+
+// --- DELETE ---
+func RevokeTeamInviteLink(teamID uint, linkID uint, currentUserID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureTeamOwner(tx, teamID, currentUserID); err != nil {
+			return err
+		}
+
+		var link models.TeamInviteLink
+		err := tx.Where("id = ? AND team_id = ?", linkID, teamID).
+			First(&link).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		// Revoking twice is a no-op
+		if link.RevokedAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		return tx.Model(&link).Update("revoked_at", now).Error
+	})
+}
+
+// Package private methods
+func ensureTeamOwner(db *gorm.DB, teamID uint, userID uint) error {
+	var t models.Team
+	if err := db.First(&t, teamID).Error; err != nil {
+		return err
+	}
+
+	var member models.TeamMember
+	err := db.Where("team_id = ? AND user_id = ? AND role = ?", teamID, userID, models.RoleOwner).
+		First(&member).
+		Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return appError.ErrUnauthorized
+	}
+
+	return err
+}
+
+// generateToken returns a random hex token for links that grant access without a login.
+func generateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"errors"
+	"log/slog"
 	"strings"
+	"time"
 
 	"server/common/appError"
 	"server/common/config"
@@ -18,7 +20,9 @@ func GetTeamByID(id uint, currentUserID uint) (models.Team, error) {
 	err := config.DB.
 		Scopes(ExcludeBlockedUsersOn(currentUserID, "creator_id")).
 		Preload("Users.User", ExcludeBlockedUsers(currentUserID)).
+		Preload("Users.User.Settings").
 		Preload("Creator").
+		Preload("Creator.Settings").
 		Preload("Location").
 		Preload("Sports").
 		First(&t, id).
@@ -37,7 +41,9 @@ func GetTeams(currentUserID uint) ([]models.Team, error) {
 	err := config.DB.
 		Scopes(ExcludeBlockedUsersOn(currentUserID, "creator_id")).
 		Preload("Users.User", ExcludeBlockedUsers(currentUserID)).
+		Preload("Users.User.Settings").
 		Preload("Creator").
+		Preload("Creator.Settings").
 		Preload("Location").
 		Preload("Sports").
 		Find(&teams).
@@ -49,13 +55,29 @@ func GetTeams(currentUserID uint) ([]models.Team, error) {
 	return teams, nil
 }
 
+// GetTeamsByUserId returns the teams of a user.
+// The list is empty when the user's privacy settings hide their teams from the current user.
 func GetTeamsByUserId(id uint, currentUserID uint) ([]models.TeamMember, error) {
+	if id != currentUserID {
+		var settings models.UserSettings
+		err := config.DB.Where("user_id = ?", id).Limit(1).Find(&settings).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if !settings.PrivacyTeamsAndChallenges.Allows(false, AreFriends(currentUserID, id)) {
+			return []models.TeamMember{}, nil
+		}
+	}
+
 	var user models.User
 
 	err := config.DB.
 		Preload("Teams.Team", ExcludeBlockedUsersOn(currentUserID, "creator_id")).
 		Preload("Teams.Team.Users.User", ExcludeBlockedUsers(currentUserID)).
+		Preload("Teams.Team.Users.User.Settings").
 		Preload("Teams.Team.Creator").
+		Preload("Teams.Team.Creator.Settings").
 		Preload("Teams.Team.Location").
 		Preload("Teams.Team.Sports").
 		First(&user, id).
@@ -89,6 +111,10 @@ func CreateTeam(t models.Team, sportNames []string, inviteeIDs []uint) (models.T
 			return err
 		}
 
+		if len(inviteeIDs) > 0 && !creator.EmailVerified {
+			return appError.ErrEmailNotVerified
+		}
+
 		t.CreatorID = creator.ID
 		t.Creator = models.User{}
 		t.Users = append(t.Users, models.TeamMember{
@@ -108,7 +134,9 @@ func CreateTeam(t models.Team, sportNames []string, inviteeIDs []uint) (models.T
 		}
 
 		err = tx.Preload("Users.User").
+			Preload("Users.User.Settings").
 			Preload("Creator").
+			Preload("Creator.Settings").
 			Preload("Location").
 			Preload("Sports").
 			First(&t, t.ID).
@@ -258,12 +286,41 @@ func SoftDeleteTeam(id uint) error {
 	})
 }
 
-// Completely Delete team and associations (no soft delete)
+// Completely Delete team and associations (no soft delete).
+// Also works on soft deleted teams, so it can be used to purge them.
 func DeleteTeam(id uint) error {
 	return config.DB.Transaction(func(tx *gorm.DB) error {
 		var t models.Team
-		// Load team + users in a single query
-		if err := tx.Preload("Users.User").First(&t, id).Error; err != nil {
+		if err := tx.Unscoped().First(&t, id).Error; err != nil {
+			return err
+		}
+
+		// Delete conversations, participants and messages
+		if err := deleteResourceConversations(tx, "team_id", t.ID); err != nil {
+			return err
+		}
+
+		// Delete legacy team messages
+		if err := tx.Where("team_id = ?", t.ID).
+			Delete(&models.Message{}).Error; err != nil {
+			return err
+		}
+
+		// Delete pending and processed invitations to the team
+		if err := tx.Where("resource_type = ? AND resource_id = ?", models.ResourceTypeTeam, t.ID).
+			Delete(&models.Invitation{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM challenge_teams WHERE team_id = ?", t.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM team_sports WHERE team_id = ?", t.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM team_members WHERE team_id = ?", t.ID).Error; err != nil {
 			return err
 		}
 
@@ -276,6 +333,43 @@ func DeleteTeam(id uint) error {
 	})
 }
 
+// RestoreTeam restores a soft deleted team. Only the owner can restore,
+// and only within the restore window.
+func RestoreTeam(id uint, currentUserID uint) (models.Team, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var t models.Team
+		if err := tx.Unscoped().First(&t, id).Error; err != nil {
+			return err
+		}
+
+		if err := checkRestoreWindow(t.DeletedAt); err != nil {
+			return err
+		}
+
+		var owner models.TeamMember
+		err := tx.Where("team_id = ? AND user_id = ? AND role = ?", id, currentUserID, models.RoleOwner).
+			First(&owner).
+			Error
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appError.ErrUnauthorized
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&t).Update("deleted_at", nil).Error
+	})
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	syncTeamConversation(id)
+
+	return GetTeamByID(id, currentUserID)
+}
+
 func RemoveUserFromTeam(creator models.User, teamId uint, userId uint) error {
 	return config.DB.Transaction(func(tx *gorm.DB) error {
 		var t models.Team
@@ -329,7 +423,7 @@ func LeaveTeam(user models.User, teamId uint) error {
 }
 
 // Package private methods
-func addUserToTeam(teamId uint, userId uint, db *gorm.DB) error {
+func addUserToTeam(teamId uint, userId uint, role models.TeamRole, db *gorm.DB) error {
 	// Verify team exists
 	var t models.Team
 	err := db.First(&t, teamId).Error
@@ -344,11 +438,26 @@ func addUserToTeam(teamId uint, userId uint, db *gorm.DB) error {
 		return err
 	}
 
-	// Create TeamMember record with default member role
+	// Reject users who are already members
+	var count int64
+	err = db.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", teamId, userId).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return appError.ErrUserAlreadyInTeam
+	}
+
+	if role == "" {
+		role = models.RoleMember
+	}
+
 	teamMember := models.TeamMember{
 		TeamID: teamId,
 		UserID: userId,
-		Role:   models.RoleMember,
+		Role:   role,
 	}
 
 	err = db.Create(&teamMember).Error
@@ -358,3 +467,42 @@ func addUserToTeam(teamId uint, userId uint, db *gorm.DB) error {
 
 	return nil
 }
+
+// checkRestoreWindow verifies that a soft deleted row can still be restored.
+func checkRestoreWindow(deletedAt gorm.DeletedAt) error {
+	if !deletedAt.Valid {
+		return appError.ErrNotDeleted
+	}
+
+	window := time.Duration(config.AppConfig.SoftDeleteRestoreDays) * 24 * time.Hour
+	if time.Since(deletedAt.Time) > window {
+		return appError.ErrRestoreWindowExpired
+	}
+
+	return nil
+}
+
+// syncTeamConversation syncs the team conversation with the current team members.
+// Errors are logged but never fail the caller, since the membership change already succeeded.
+func syncTeamConversation(teamID uint) {
+	var team models.Team
+	if err := config.DB.Preload("Users").First(&team, teamID).Error; err != nil {
+		slog.Warn("Failed to load team for conversation sync",
+			slog.Int("team_id", int(teamID)),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	memberIDs := make([]uint, len(team.Users))
+	for i, u := range team.Users {
+		memberIDs[i] = u.UserID
+	}
+
+	if err := SyncTeamConversationMembers(teamID, memberIDs); err != nil {
+		slog.Warn("Failed to sync team conversation for team",
+			slog.Int("team_id", int(teamID)),
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,24 @@
+// Package storage stores uploaded files, e.g. chat attachments.
+//
+// Files are addressed by a slash separated key. The local filesystem implementation
+// is used for now, object storage can replace it without touching the callers.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+type Storage interface {
+	// Put stores the content of r under key, replacing an existing file.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Get opens the file stored under key. Returns ErrNotFound if it doesn't exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the file stored under key. Deleting a missing file is a no-op.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,101 @@
+package validator
+
+import (
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	zeroWidthJoiner   = '\u200D'
+	variationSelector = '\uFE0F'
+	textSelector      = '\uFE0E'
+	keycapMark        = '\u20E3'
+)
+
+// validateSingleEmoji accepts exactly one emoji as the app's picker sends it: a single emoji,
+// optionally with a skin tone or variation selector, a ZWJ sequence (e.g. 👩‍💻), a flag or a keycap.
+func validateSingleEmoji(fl validator.FieldLevel) bool {
+	return isSingleEmoji(fl.Field().String())
+}
+
+func isSingleEmoji(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+
+	runes := []rune(s)
+
+	// Flags are a pair of regional indicators
+	if isRegionalIndicator(runes[0]) {
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	}
+
+	// Keycaps are a digit, # or * followed by the keycap mark
+	if (runes[0] >= '0' && runes[0] <= '9') || runes[0] == '#' || runes[0] == '*' {
+		return (len(runes) == 2 && runes[1] == keycapMark) ||
+			(len(runes) == 3 && runes[1] == variationSelector && runes[2] == keycapMark)
+	}
+
+	// Subdivision flags (e.g. England) are a black flag followed by tag characters
+	if runes[0] == '\U0001F3F4' && len(runes) > 2 && isTag(runes[1]) {
+		for _, r := range runes[1:] {
+			if !isTag(r) {
+				return false
+			}
+		}
+		return runes[len(runes)-1] == '\U000E007F'
+	}
+
+	// One or more emoji joined by ZWJ, each with an optional modifier and variation selector
+	expectBase := true
+	for _, r := range runes {
+		switch {
+		case expectBase:
+			if !isEmojiBase(r) {
+				return false
+			}
+			expectBase = false
+		case r == zeroWidthJoiner:
+			expectBase = true
+		case r == variationSelector || r == textSelector || isSkinTone(r):
+		default:
+			return false
+		}
+	}
+
+	return !expectBase
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= '\U0001F1E6' && r <= '\U0001F1FF'
+}
+
+func isSkinTone(r rune) bool {
+	return r >= '\U0001F3FB' && r <= '\U0001F3FF'
+}
+
+func isTag(r rune) bool {
+	return r >= '\U000E0020' && r <= '\U000E007F'
+}
+
+func isEmojiBase(r rune) bool {
+	switch {
+	case r >= '\U0001F000' && r <= '\U0001FAFF' && !isRegionalIndicator(r) && !isSkinTone(r):
+		return true
+	case r >= '\u2190' && r <= '\u21FF', // Arrows
+		r >= '\u2300' && r <= '\u23FF', // Miscellaneous technical, e.g. ⌚ ⏰
+		r >= '\u25A0' && r <= '\u25FF', // Geometric shapes
+		r >= '\u2600' && r <= '\u27BF', // Miscellaneous symbols and dingbats, e.g. ☀ ❤ ✅
+		r >= '\u2900' && r <= '\u297F', // Supplemental arrows
+		r >= '\u2B00' && r <= '\u2BFF': // Miscellaneous symbols and arrows, e.g. ⭐ ⬆
+		return true
+	}
+
+	switch r {
+	case '©', '®', '‼', '⁉', '™', 'ℹ', 'Ⓜ', '〰', '〽', '㊗', '㊙':
+		return true
+	}
+
+	return false
+}
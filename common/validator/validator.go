@@ -31,6 +31,12 @@ func init() {
 		panic("Failed to register sanitize validation: " + err.Error())
 	}
 
+	// Reactions must be a single emoji, not arbitrary text
+	err = V.RegisterValidation("single-emoji", validateSingleEmoji)
+	if err != nil {
+		panic("Failed to register single-emoji validation: " + err.Error())
+	}
+
 	V.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
 		if name == "-" {
@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+	"server/common/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminService_SuspendUser(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	admin, _ := services.CreateUser(models.User{Email: "admin@a.com", FirstName: "A", LastName: "A"}, "pw")
+	user, _ := services.CreateUser(models.User{Email: "user@a.com", FirstName: "U", LastName: "U"}, "password123")
+	config.DB.Model(&models.User{}).Where("id = ?", admin.ID).Update("role", models.UserRoleAdmin)
+
+	_, tokens, err := services.Login(user.Email, "password123", services.SessionClient{})
+	assert.NoError(t, err)
+
+	// 1. Admins can't suspend themselves
+	_, err = services.SuspendUser(admin.ID, admin.ID, dto.SuspendUserDto{Until: time.Now().Add(time.Hour), Reason: "test"})
+	assert.ErrorIs(t, err, appError.ErrSameUser)
+
+	// 2. Suspend logs the user out and blocks new logins
+	suspended, err := services.SuspendUser(admin.ID, user.ID, dto.SuspendUserDto{Until: time.Now().Add(time.Hour), Reason: "Spam"})
+	assert.NoError(t, err)
+	assert.True(t, suspended.IsSuspended(time.Now()))
+	assert.Equal(t, "Spam", suspended.SuspensionReason)
+
+	_, _, err = services.RefreshSession(tokens.RefreshToken)
+	assert.ErrorIs(t, err, appError.ErrSessionRevoked)
+
+	_, _, err = services.Login(user.Email, "password123", services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrAccountSuspended)
+
+	// 3. Lifting the suspension allows logins again
+	_, err = services.UnsuspendUser(admin.ID, user.ID)
+	assert.NoError(t, err)
+
+	_, _, err = services.Login(user.Email, "password123", services.SessionClient{})
+	assert.NoError(t, err)
+
+	// 4. Both actions are audited
+	logs, err := services.GetAdminAuditLogs(services.AdminAuditLogFilters{AdminID: &admin.ID})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, models.AdminActionUserUnsuspended, logs[0].Action)
+	assert.Equal(t, models.AdminActionUserSuspended, logs[1].Action)
+	assert.Equal(t, user.ID, logs[1].TargetID)
+}
+
+func TestAdminService_UsersAndRoles(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	admin, _ := services.CreateUser(models.User{Email: "admin@r.com", FirstName: "Ada", LastName: "Admin"}, "pw")
+	user, _ := services.CreateUser(models.User{Email: "bob@r.com", FirstName: "Bob", LastName: "Builder"}, "pw")
+	config.DB.Model(&models.User{}).Where("id = ?", admin.ID).Update("role", models.UserRoleAdmin)
+
+	// 1. Search matches email and names
+	users, err := services.GetAdminUsers(services.AdminUserFilters{Query: "bob@"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+
+	users, err = services.GetAdminUsers(services.AdminUserFilters{Role: models.UserRoleAdmin})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, admin.ID, users[0].ID)
+
+	// 2. Promote, but admins can't change their own role
+	promoted, err := services.UpdateUserRole(admin.ID, user.ID, models.UserRoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserRoleAdmin, promoted.Role)
+
+	_, err = services.UpdateUserRole(admin.ID, admin.ID, models.UserRoleUser)
+	assert.ErrorIs(t, err, appError.ErrSameUser)
+}
+
+func TestAdminService_EulaVersions(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	admin, _ := services.CreateUser(models.User{Email: "admin@e.com", FirstName: "A", LastName: "A"}, "pw")
+
+	v1, err := services.CreateEulaVersion(admin.ID, dto.EulaVersionCreateDto{Version: "1.0", Locale: "da-DK", Content: "Terms v1", Activate: true})
+	assert.NoError(t, err)
+	assert.True(t, v1.IsActive)
+	assert.Len(t, v1.ContentHash, 64)
+
+	_, err = services.CreateEulaVersion(admin.ID, dto.EulaVersionCreateDto{Version: "1.0", Locale: "da-DK", Content: "Again"})
+	assert.ErrorIs(t, err, appError.ErrEulaExists)
+
+	// A draft doesn't replace the active version until it is activated
+	v2, err := services.CreateEulaVersion(admin.ID, dto.EulaVersionCreateDto{Version: "2.0", Locale: "da-DK", Content: "Terms v2"})
+	assert.NoError(t, err)
+	assert.False(t, v2.IsActive)
+
+	active, _ := services.GetActiveEula("da-DK")
+	assert.Equal(t, v1.ID, active.ID)
+
+	_, err = services.ActivateEulaVersion(admin.ID, v2.ID)
+	assert.NoError(t, err)
+
+	active, _ = services.GetActiveEula("da-DK")
+	assert.Equal(t, v2.ID, active.ID)
+
+	versions, _ := services.GetEulaVersions("da-DK")
+	assert.Len(t, versions, 2)
+}
+
+func TestAdminService_Sports(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	admin, _ := services.CreateUser(models.User{Email: "admin@s.com", FirstName: "A", LastName: "A"}, "pw")
+
+	_, err := services.CreateSport(admin.ID, "Football")
+	assert.ErrorIs(t, err, appError.ErrSportExists)
+
+	sport, err := services.CreateSport(admin.ID, "Cricket")
+	assert.NoError(t, err)
+	assert.True(t, config.SportsCache["Cricket"])
+
+	// Deleting retires the sport, creating it again restores it
+	assert.NoError(t, services.DeleteSport(admin.ID, sport.ID))
+	assert.False(t, config.SportsCache["Cricket"])
+
+	restored, err := services.CreateSport(admin.ID, "Cricket")
+	assert.NoError(t, err)
+	assert.Equal(t, sport.ID, restored.ID)
+
+	assert.NoError(t, services.DeleteSport(admin.ID, sport.ID))
+	assert.ErrorIs(t, services.DeleteSport(admin.ID, sport.ID), appError.ErrSportNotFound)
+}
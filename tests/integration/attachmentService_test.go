@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+	"server/common/services"
+	"server/common/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachments_UploadSendAndDownload(t *testing.T) {
+	setupTest(t)
+
+	files, err := storage.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+	services.SetAttachmentStorage(files)
+	config.AppConfig.AttachmentMaxSizeMB = 1
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	outsider := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&outsider)
+
+	conv, _ := services.CreateDirectConversation(user1.ID, user2.ID)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 800, 400))))
+
+	// 1. The type is sniffed from the content and the size is limited
+	_, err = services.UploadAttachment(conv.ID, user1.ID, "notes.png", []byte("just some text"))
+	assert.ErrorIs(t, err, appError.ErrAttachmentTypeNotAllowed)
+
+	_, err = services.UploadAttachment(conv.ID, user1.ID, "huge.png", make([]byte, 2<<20))
+	assert.ErrorIs(t, err, appError.ErrAttachmentTooLarge)
+
+	_, err = services.UploadAttachment(conv.ID, outsider.ID, "court.png", buf.Bytes())
+	assert.ErrorIs(t, err, appError.ErrNotConversationMember)
+
+	// 2. Images get a thumbnail
+	upload, err := services.UploadAttachment(conv.ID, user1.ID, "../court.png", buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "court.png", upload.FileName)
+	assert.Equal(t, "image/png", upload.ContentType)
+	assert.Equal(t, 800, upload.Width)
+	assert.Equal(t, 400, upload.Height)
+	assert.True(t, upload.IsImage())
+
+	thumbnailFile, err := services.OpenAttachment(upload, true)
+	assert.NoError(t, err)
+	thumbnail, err := png.DecodeConfig(thumbnailFile)
+	thumbnailFile.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 320, thumbnail.Width)
+	assert.Equal(t, 160, thumbnail.Height)
+
+	// 3. Until it is sent, only the uploader can see the upload
+	_, err = services.GetAttachment(conv.ID, upload.ID, user2.ID)
+	assert.ErrorIs(t, err, appError.ErrAttachmentNotFound)
+
+	msg, err := services.SendConversationMessage(conv.ID, user1.ID, dto.SendMessageDto{AttachmentIDs: []uint{upload.ID}})
+	assert.NoError(t, err)
+	assert.Len(t, dto.ToMessageResponseDto(*msg).Attachments, 1)
+
+	// An attachment belongs to one message
+	_, err = services.SendConversationMessage(conv.ID, user1.ID, dto.SendMessageDto{AttachmentIDs: []uint{upload.ID}})
+	assert.ErrorIs(t, err, appError.ErrAttachmentNotFound)
+
+	// 4. Members can download it, others can't
+	attachment, err := services.GetAttachment(conv.ID, upload.ID, user2.ID)
+	assert.NoError(t, err)
+
+	file, err := services.OpenAttachment(attachment, false)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, buf.Bytes(), data)
+
+	_, err = services.GetAttachment(conv.ID, upload.ID, outsider.ID)
+	assert.ErrorIs(t, err, appError.ErrNotConversationMember)
+
+	// 5. Deleting the message removes the files
+	assert.NoError(t, services.DeleteMessage(conv.ID, msg.ID, user1.ID))
+
+	_, err = services.GetAttachment(conv.ID, upload.ID, user1.ID)
+	assert.ErrorIs(t, err, appError.ErrAttachmentNotFound)
+
+	_, err = files.Get(t.Context(), upload.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDeleteOrphanedAttachments(t *testing.T) {
+	setupTest(t)
+
+	files, err := storage.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+	services.SetAttachmentStorage(files)
+	config.AppConfig.AttachmentMaxSizeMB = 1
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+
+	conv, _ := services.CreateDirectConversation(user1.ID, user2.ID)
+	pdf := []byte("%PDF-1.4\n%âãÏÓ\n")
+
+	sent, err := services.UploadAttachment(conv.ID, user1.ID, "rules.pdf", pdf)
+	assert.NoError(t, err)
+	_, err = services.SendConversationMessage(conv.ID, user1.ID, dto.SendMessageDto{Content: "Rules", AttachmentIDs: []uint{sent.ID}})
+	assert.NoError(t, err)
+
+	unsent, err := services.UploadAttachment(conv.ID, user1.ID, "draft.pdf", pdf)
+	assert.NoError(t, err)
+
+	// Recent uploads are kept, so they can still be sent
+	count, err := services.DeleteOrphanedAttachments(time.Now().Add(-services.AttachmentUploadTTL))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	count, err = services.DeleteOrphanedAttachments(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = files.Get(t.Context(), unsent.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = services.GetAttachment(conv.ID, sent.ID, user2.ID)
+	assert.NoError(t, err)
+}
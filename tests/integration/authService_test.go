@@ -1,6 +1,8 @@
 package integration
 
 import (
+	"errors"
+	"fmt"
 	"server/common/appError"
 	"server/common/config"
 	"server/common/models"
@@ -16,13 +18,14 @@ func TestAuthService_FullFlow(t *testing.T) {
 	defer teardown()
 
 	config.AppConfig.JWTSecret = "test_secret_key_12345"
-	config.AppConfig.JWTExpirationHours = 24 // Set expiration to 24 hours for tests
+	config.AppConfig.AccessTokenExpirationMinutes = 15
+	config.AppConfig.RefreshTokenExpirationDays = 30
 
 	email := "auth_full@test.com"
 	password := "strongPassword"
 
 	// 1. Login Non-Existent User
-	_, _, err := services.Login("ghost@test.com", password)
+	_, _, err := services.Login("ghost@test.com", password, services.SessionClient{})
 	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
 
 	// 2. Create User
@@ -35,21 +38,23 @@ func TestAuthService_FullFlow(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 3. Login Wrong Password
-	_, _, err = services.Login(email, "wrongPass")
+	_, _, err = services.Login(email, "wrongPass", services.SessionClient{})
 	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
 
 	// 4. Login Success
-	user, token, err := services.Login(email, password)
+	user, tokens, err := services.Login(email, password, services.SessionClient{})
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
 	assert.Equal(t, createdUser.ID, user.ID)
 
 	// 5. Validate Token Success
-	claims, err := services.ValidateJWTToken(token)
+	claims, err := services.ValidateJWTToken(tokens.AccessToken)
 	assert.NoError(t, err)
 	assert.Equal(t, user.ID, claims.UserID)
 	assert.Equal(t, user.Email, claims.Email)
+	assert.NotZero(t, claims.SessionID)
 
 	// 6. Validate Invalid Token (Garbage)
 	_, err = services.ValidateJWTToken("garbage.token.string")
@@ -78,12 +83,12 @@ func TestAuthService_PasswordReset(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 2. Request password reset for non-existent user (should not error for security)
-	err = services.RequestPasswordReset("nonexistent@test.com")
+	err = services.RequestPasswordReset("nonexistent@test.com", "")
 	assert.NoError(t, err)
 
 	// 3. Request password reset for existing user
 	// Note: Email sending may fail in test environment, but reset code should still be set
-	err = services.RequestPasswordReset(email)
+	err = services.RequestPasswordReset(email, "")
 	// Email sending might fail, but we can still test the reset code functionality
 	// by manually checking the database
 
@@ -115,11 +120,11 @@ func TestAuthService_PasswordReset(t *testing.T) {
 	assert.NotEmpty(t, resetCode)
 
 	// 5. Try to reset password with wrong reset code
-	err = services.ResetPassword(email, "wrongcode", newPassword)
+	err = services.ResetPassword(email, "wrongcode", newPassword, "")
 	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
 
 	// 6. Try to reset password with correct reset code
-	err = services.ResetPassword(email, resetCode, newPassword)
+	err = services.ResetPassword(email, resetCode, newPassword, "")
 	assert.NoError(t, err)
 
 	// 7. Verify password was changed and reset code was cleared
@@ -131,19 +136,19 @@ func TestAuthService_PasswordReset(t *testing.T) {
 	assert.Nil(t, updatedUser.PasswordResetCodeExpiresAt)
 
 	// 8. Verify old password no longer works
-	_, _, err = services.Login(email, password)
+	_, _, err = services.Login(email, password, services.SessionClient{})
 	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
 
 	// 9. Verify new password works
-	_, _, err = services.Login(email, newPassword)
+	_, _, err = services.Login(email, newPassword, services.SessionClient{})
 	assert.NoError(t, err)
 
 	// 10. Try to reset password again with same code (should fail - code was cleared)
-	err = services.ResetPassword(email, resetCode, "anotherPassword")
+	err = services.ResetPassword(email, resetCode, "anotherPassword", "")
 	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
 
 	// 11. Request new reset code (email may fail, but code should be set)
-	services.RequestPasswordReset(email)
+	services.RequestPasswordReset(email, "")
 
 	// 12. Get the new reset code (or set manually if email failed)
 	err = config.DB.Where("email = ?", email).First(&user).Error
@@ -167,6 +172,311 @@ func TestAuthService_PasswordReset(t *testing.T) {
 	config.DB.Save(&user)
 
 	// 14. Try to reset with expired code
-	err = services.ResetPassword(email, newResetCode, "newpass")
+	err = services.ResetPassword(email, newResetCode, "newpass", "")
 	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
 }
+
+func TestAuthService_EmailVerification(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	// 1. Register a password account (email sending may fail in test environment)
+	user, err := services.RegisterUser(models.User{Email: "verify@test.com", FirstName: "Verify", LastName: "User"}, "password123")
+	assert.NoError(t, err)
+	assert.False(t, user.EmailVerified)
+
+	var registered models.User
+	config.DB.First(&registered, user.ID)
+	assert.False(t, registered.EmailVerified)
+
+	other, _ := services.CreateUser(models.User{Email: "other@test.com", FirstName: "Other", LastName: "User"}, "pw")
+
+	// 2. Unverified users can't invite others or create public challenges
+	err = services.SendInvitation(&models.Invitation{InviterId: user.ID, InviteeId: other.ID, ResourceType: models.ResourceTypeFriend})
+	assert.ErrorIs(t, err, appError.ErrEmailNotVerified)
+
+	_, err = services.CreateChallenge(models.Challenge{
+		Name:      "Public",
+		Sport:     "Football",
+		CreatorID: user.ID,
+		IsPublic:  true,
+		Location:  models.Location{Address: "Verify St", Coordinates: models.Point{Lat: 55, Lon: 12}},
+		StartTime: time.Now().Add(24 * time.Hour),
+	}, nil)
+	assert.ErrorIs(t, err, appError.ErrEmailNotVerified)
+
+	// 3. Resend is throttled
+	err = services.RequestEmailVerification(user.ID)
+	assert.ErrorIs(t, err, appError.ErrTooManyRequests)
+
+	// 4. Code was stored even if the email failed
+	var stored models.User
+	assert.NoError(t, config.DB.First(&stored, user.ID).Error)
+	assert.Len(t, stored.EmailVerificationCode, 6)
+	assert.NotNil(t, stored.EmailVerificationCodeExpiresAt)
+
+	// 5. Wrong code
+	err = services.VerifyEmail(user.ID, "wrong1")
+	assert.ErrorIs(t, err, appError.ErrInvalidVerificationCode)
+
+	// 6. Expired code
+	expired := time.Now().Add(-1 * time.Minute)
+	config.DB.Model(&stored).Update("email_verification_code_expires_at", expired)
+	err = services.VerifyEmail(user.ID, stored.EmailVerificationCode)
+	assert.ErrorIs(t, err, appError.ErrInvalidVerificationCode)
+
+	// 7. Correct code
+	valid := time.Now().Add(1 * time.Hour)
+	config.DB.Model(&stored).Update("email_verification_code_expires_at", valid)
+	err = services.VerifyEmail(user.ID, stored.EmailVerificationCode)
+	assert.NoError(t, err)
+
+	var verified models.User
+	assert.NoError(t, config.DB.First(&verified, user.ID).Error)
+	assert.True(t, verified.EmailVerified)
+	assert.Empty(t, verified.EmailVerificationCode)
+
+	// 8. Verified users can invite and resending is rejected
+	err = services.SendInvitation(&models.Invitation{InviterId: user.ID, InviteeId: other.ID, ResourceType: models.ResourceTypeFriend})
+	assert.NoError(t, err)
+
+	err = services.RequestEmailVerification(user.ID)
+	assert.ErrorIs(t, err, appError.ErrEmailAlreadyVerified)
+}
+
+func TestAuthService_VerificationCodeGuessLimit(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	user, _ := services.RegisterUser(models.User{Email: "verify-guess@test.com", FirstName: "Guess", LastName: "User"}, "password123")
+
+	var stored models.User
+	config.DB.First(&stored, user.ID)
+	code := stored.EmailVerificationCode
+	assert.NotEmpty(t, code)
+
+	// 1. Wrong guesses use up the code
+	for i := 0; i < 5; i++ {
+		err := services.VerifyEmail(user.ID, "000000")
+		assert.ErrorIs(t, err, appError.ErrInvalidVerificationCode)
+	}
+
+	config.DB.First(&stored, user.ID)
+	assert.Empty(t, stored.EmailVerificationCode)
+
+	// 2. Further attempts are locked out, also with the right code
+	err := services.VerifyEmail(user.ID, code)
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	config.DB.First(&stored, user.ID)
+	assert.False(t, stored.EmailVerified)
+}
+
+func TestAuthService_EmailChange(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	password := "password123"
+	user, _ := services.CreateUser(models.User{Email: "old@test.com", FirstName: "Old", LastName: "User"}, password)
+	services.CreateUser(models.User{Email: "taken@test.com", FirstName: "Taken", LastName: "User"}, "pw")
+
+	// 1. Wrong password
+	err := services.RequestEmailChange(user.ID, "new@test.com", "wrongpassword")
+	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
+
+	// 2. Email already in use, regardless of case
+	err = services.RequestEmailChange(user.ID, "Taken@Test.com", password)
+	assert.ErrorIs(t, err, appError.ErrUserExists)
+
+	// 3. Request change, the address is trimmed and lowercased
+	// Note: Email sending may fail in test environment, but the code is stored before sending
+	services.RequestEmailChange(user.ID, " New@Test.com ", password)
+
+	var pending models.User
+	assert.NoError(t, config.DB.First(&pending, user.ID).Error)
+	assert.NotNil(t, pending.PendingEmail)
+	assert.Equal(t, "new@test.com", *pending.PendingEmail)
+	assert.Len(t, pending.PendingEmailCode, 6)
+	assert.Equal(t, "old@test.com", pending.Email)
+
+	// 4. Requests are throttled
+	err = services.RequestEmailChange(user.ID, "new@test.com", password)
+	assert.ErrorIs(t, err, appError.ErrTooManyRequests)
+
+	// 5. Wrong code
+	err = services.ConfirmEmailChange(user.ID, "wrong1")
+	assert.ErrorIs(t, err, appError.ErrInvalidVerificationCode)
+
+	// 6. Correct code
+	err = services.ConfirmEmailChange(user.ID, pending.PendingEmailCode)
+	assert.NoError(t, err)
+
+	var updated models.User
+	assert.NoError(t, config.DB.First(&updated, user.ID).Error)
+	assert.Equal(t, "new@test.com", updated.Email)
+	assert.True(t, updated.EmailVerified)
+	assert.Nil(t, updated.PendingEmail)
+	assert.Empty(t, updated.PendingEmailCode)
+
+	// 7. Address taken between request and confirmation
+	config.DB.Model(&updated).Update("email_verification_sent_at", nil)
+	services.RequestEmailChange(user.ID, "race@test.com", password)
+	assert.NoError(t, config.DB.First(&pending, user.ID).Error)
+
+	services.CreateUser(models.User{Email: "race@test.com", FirstName: "Race", LastName: "User"}, "pw")
+
+	err = services.ConfirmEmailChange(user.ID, pending.PendingEmailCode)
+	assert.ErrorIs(t, err, appError.ErrUserExists)
+
+	// 8. Wrong guesses use up the code, then even the right code is locked out
+	config.DB.Model(&pending).Update("email_verification_sent_at", nil)
+	services.RequestEmailChange(user.ID, "guess@test.com", password)
+	assert.NoError(t, config.DB.First(&pending, user.ID).Error)
+	code := pending.PendingEmailCode
+
+	for i := 0; i < 5; i++ {
+		err = services.ConfirmEmailChange(user.ID, "000000")
+		assert.ErrorIs(t, err, appError.ErrInvalidVerificationCode)
+	}
+
+	assert.NoError(t, config.DB.First(&pending, user.ID).Error)
+	assert.Empty(t, pending.PendingEmailCode)
+
+	err = services.ConfirmEmailChange(user.ID, code)
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+}
+
+func TestAuthService_EmailChangeOAuth(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	user := models.User{Email: "oauth@test.com", FirstName: "OAuth", LastName: "User", AuthProvider: "google"}
+	config.DB.Create(&user)
+
+	// OAuth accounts are found by email on their next sign-in, so the email can't change
+	err := services.RequestEmailChange(user.ID, "new-oauth@test.com", "")
+	assert.ErrorIs(t, err, appError.ErrEmailManagedByProvider)
+
+	pendingEmail := "new-oauth@test.com"
+	config.DB.Model(&user).Updates(map[string]any{"pending_email": pendingEmail, "pending_email_code": "123456"})
+
+	err = services.ConfirmEmailChange(user.ID, "123456")
+	assert.ErrorIs(t, err, appError.ErrEmailManagedByProvider)
+
+	var stored models.User
+	config.DB.First(&stored, user.ID)
+	assert.Equal(t, "oauth@test.com", stored.Email)
+}
+
+func TestAuthService_BruteForceProtection(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	email := "locked@test.com"
+	password := "password123"
+	services.CreateUser(models.User{Email: email, FirstName: "Locked", LastName: "User"}, password)
+
+	// 1. Account is locked after 5 failed logins, even for the right password
+	for i := 0; i < 5; i++ {
+		_, _, err := services.Login(email, "wrongpassword", services.SessionClient{})
+		assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
+	}
+
+	_, _, err := services.Login(email, password, services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	var rateLimitErr *appError.RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+	assert.Greater(t, rateLimitErr.RetryAfter, time.Duration(0))
+
+	// 2. The lockout expires
+	config.DB.Model(&models.AuthThrottle{}).Where("key = ?", "login:source:"+email).
+		Update("locked_until", time.Now().Add(-1*time.Second))
+
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	assert.NoError(t, err)
+
+	// 3. IP is locked after 20 failed logins across accounts
+	client := services.SessionClient{IPAddress: "203.0.113.7"}
+	for i := 0; i < 20; i++ {
+		services.Login(fmt.Sprintf("ghost%d@test.com", i), "wrongpassword", client)
+	}
+
+	_, _, err = services.Login(email, password, client)
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	_, _, err = services.Login(email, password, services.SessionClient{IPAddress: "198.51.100.1"})
+	assert.NoError(t, err)
+}
+
+func TestAuthService_LockoutIsPerSource(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	email := "victim@test.com"
+	password := "password123"
+	services.CreateUser(models.User{Email: email, FirstName: "Victim", LastName: "User"}, password)
+
+	attacker := services.SessionClient{IPAddress: "203.0.113.7"}
+	owner := services.SessionClient{IPAddress: "198.51.100.1"}
+
+	// 1. Failed logins lock the account only from the attacker's address
+	for i := 0; i < 5; i++ {
+		services.Login(email, "wrongpassword", attacker)
+	}
+
+	_, _, err := services.Login(email, password, attacker)
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	_, _, err = services.Login(email, password, owner)
+	assert.NoError(t, err)
+
+	// 2. Tradeoff: guesses spread over many addresses still lock the account for everyone,
+	// once the account-wide limit is reached
+	for i := 0; i < 50; i++ {
+		services.Login(email, "wrongpassword", services.SessionClient{IPAddress: fmt.Sprintf("192.0.2.%d", i)})
+	}
+
+	_, _, err = services.Login(email, password, owner)
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+}
+
+func TestAuthService_ResetCodeGuessLimit(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	email := "guess@test.com"
+	services.CreateUser(models.User{Email: email, FirstName: "Guess", LastName: "User"}, "password123")
+
+	// Note: Email sending may fail in test environment, but the code is stored before sending
+	services.RequestPasswordReset(email, "")
+
+	var user models.User
+	config.DB.Where("email = ?", email).First(&user)
+	code := user.PasswordResetCode
+	assert.NotEmpty(t, code)
+
+	// 1. Wrong guesses use up the code
+	for i := 0; i < 5; i++ {
+		err := services.ResetPassword(email, "000000", "newPassword456", "")
+		assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
+	}
+
+	config.DB.Where("email = ?", email).First(&user)
+	assert.Empty(t, user.PasswordResetCode)
+
+	// 2. Further attempts are locked out, also with the right code
+	err := services.ResetPassword(email, code, "newPassword456", "")
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	// 3. Reset requests are limited too
+	services.RequestPasswordReset(email, "")
+	services.RequestPasswordReset(email, "")
+
+	err = services.RequestPasswordReset(email, "")
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"server/common/backplane"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+	"server/common/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func receiveEnvelope(t *testing.T, events <-chan backplane.Envelope) (backplane.Envelope, bool) {
+	t.Helper()
+
+	select {
+	case env := <-events:
+		return env, true
+	case <-time.After(2 * time.Second):
+		return backplane.Envelope{}, false
+	}
+}
+
+func TestBackplane_Postgres(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bp := backplane.NewPostgres(testDSN, config.DB)
+	events, err := bp.Subscribe(ctx)
+	assert.NoError(t, err)
+
+	recipientID := uint(7)
+	evt := dto.RealtimeEventDto{Type: dto.RealtimeEventTypingStart, RecipientID: &recipientID, UserID: 3}
+
+	// 1. Events of rolled back transactions are never delivered
+	_ = config.DB.Transaction(func(tx *gorm.DB) error {
+		assert.NoError(t, bp.Publish(backplane.Envelope{Event: evt}, tx))
+		return errors.New("rollback")
+	})
+
+	// 2. Committed events reach the subscriber
+	assert.NoError(t, bp.Publish(backplane.Envelope{Event: evt, UserIDs: []uint{7}}, nil))
+
+	env, ok := receiveEnvelope(t, events)
+	assert.True(t, ok)
+	assert.Equal(t, dto.RealtimeEventTypingStart, env.Event.Type)
+	assert.Equal(t, uint(3), env.Event.UserID)
+	assert.Equal(t, []uint{7}, env.UserIDs)
+
+	// 3. Events larger than a NOTIFY payload are passed by reference
+	content := strings.Repeat("ø", 5000)
+	large := dto.RealtimeEventDto{Type: dto.RealtimeEventMessage, UserID: 3, Message: &dto.MessageResponseDto{Content: content}}
+	assert.NoError(t, bp.Publish(backplane.Envelope{Event: large}, nil))
+
+	env, ok = receiveEnvelope(t, events)
+	assert.True(t, ok)
+	assert.Equal(t, content, env.Event.Message.Content)
+
+	var count int64
+	config.DB.Model(&models.BackplanePayload{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestBackplane_PublishesMembershipChanges(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bp := backplane.NewPostgres(testDSN, config.DB)
+	events, err := bp.Subscribe(ctx)
+	assert.NoError(t, err)
+
+	services.SetBackplane(bp)
+	defer services.SetBackplane(nil)
+
+	u1, _ := services.CreateUser(models.User{Email: "bp1@test.com", FirstName: "A", LastName: "A"}, "pw")
+	u2, _ := services.CreateUser(models.User{Email: "bp2@test.com", FirstName: "B", LastName: "B"}, "pw")
+
+	conversation, err := services.CreateGroupConversation(u1.ID, []uint{u2.ID}, "Group")
+	assert.NoError(t, err)
+
+	env, ok := receiveEnvelope(t, events)
+	assert.True(t, ok)
+	assert.Equal(t, dto.RealtimeEventMembershipChanged, env.Event.Type)
+	assert.Equal(t, conversation.ID, *env.Event.ConversationID)
+	assert.ElementsMatch(t, []uint{u1.ID, u2.ID}, env.Event.Membership.Added)
+	assert.ElementsMatch(t, []uint{u1.ID, u2.ID}, env.UserIDs)
+}
+
+func TestBackplane_PublishesSessionRevocations(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bp := backplane.NewPostgres(testDSN, config.DB)
+	events, err := bp.Subscribe(ctx)
+	assert.NoError(t, err)
+
+	services.SetBackplane(bp)
+	defer services.SetBackplane(nil)
+
+	user, _ := services.CreateUser(models.User{Email: "bp-session@test.com", FirstName: "A", LastName: "A"}, "password123")
+	_, tokens, _ := services.Login("bp-session@test.com", "password123", services.SessionClient{})
+	claims, _ := services.ValidateJWTToken(tokens.AccessToken)
+
+	// 1. Logging out a device names its session
+	assert.NoError(t, services.RevokeSession(user.ID, claims.SessionID))
+
+	env, ok := receiveEnvelope(t, events)
+	assert.True(t, ok)
+	assert.Equal(t, dto.RealtimeEventSessionRevoked, env.Event.Type)
+	assert.Equal(t, claims.SessionID, *env.Event.SessionID)
+	assert.Equal(t, []uint{user.ID}, env.UserIDs)
+
+	// 2. Logging out everywhere covers every session
+	assert.NoError(t, services.RevokeAllSessions(user.ID))
+
+	env, ok = receiveEnvelope(t, events)
+	assert.True(t, ok)
+	assert.Equal(t, dto.RealtimeEventSessionRevoked, env.Event.Type)
+	assert.Nil(t, env.Event.SessionID)
+	assert.Equal(t, []uint{user.ID}, env.UserIDs)
+}
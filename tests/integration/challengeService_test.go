@@ -404,3 +404,51 @@ func TestChallengeService_AddUserToChallenge(t *testing.T) {
 	err = services.AcceptInvitation(nonExistentUserInvitation.ID, 99999)
 	assert.Error(t, err, "Should error when user doesn't exist")
 }
+
+func TestChallengeService_Discover(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	creator, _ := services.CreateUser(models.User{Email: "disc-creator@test.com", FirstName: "C", LastName: "C"}, "pw")
+	player, _ := services.CreateUser(models.User{Email: "disc-player@test.com", FirstName: "P", LastName: "P"}, "pw")
+
+	maxDistance := 10
+	err := services.UpdateUser(player.ID, dto.UserUpdateDto{
+		SportProfiles: []dto.UserSportProfileDto{
+			{Sport: "Tennis", MaxTravelDistanceKm: &maxDistance},
+		},
+	})
+	assert.NoError(t, err)
+
+	newChallenge := func(sport string, lat, lon float64) models.Challenge {
+		m := dto.ChallengeCreateDtoToModel(dto.ChallengeCreateDto{
+			Name:      "Match",
+			Sport:     sport,
+			IsPublic:  true,
+			Date:      time.Now().Add(48 * time.Hour),
+			StartTime: time.Now().Add(48 * time.Hour),
+			Location: dto.LocationCreateDto{
+				Address: sport, Latitude: lat, Longitude: lon, PostalCode: "1", City: "C", Country: "D",
+			},
+		})
+		m.CreatorID = creator.ID
+		created, err := services.CreateChallenge(m, []uint{})
+		assert.NoError(t, err)
+		return created
+	}
+
+	near := newChallenge("Tennis", 55.68, 12.57) // Copenhagen
+	newChallenge("Tennis", 56.16, 10.20)         // Aarhus, too far
+	newChallenge("Football", 55.68, 12.58)       // Not in profile
+
+	origin := models.Point{Lat: 55.67, Lon: 12.56}
+	list, err := services.DiscoverChallenges(player.ID, &origin)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, near.ID, list[0].ID)
+
+	// Without origin the distance filter is skipped
+	list, err = services.DiscoverChallenges(player.ID, nil)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+}
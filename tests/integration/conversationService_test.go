@@ -3,6 +3,7 @@ package integration
 import (
 	"testing"
 
+	"server/common/appError"
 	"server/common/config"
 	"server/common/models"
 	"server/common/services"
@@ -403,3 +404,98 @@ func TestListConversations_OnlyConversationsWithMessages(t *testing.T) {
 	assert.Len(t, unreadCounts, 1, "Should return 1 unread count")
 	assert.Len(t, lastMessages, 1, "Should return 1 last message")
 }
+
+func TestGroupAdministration(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	user3 := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&user3)
+
+	group, err := services.CreateGroupConversation(user1.ID, []uint{user2.ID}, "Padel")
+	assert.NoError(t, err)
+
+	systemEvents := func() []models.SystemEvent {
+		var messages []models.Message
+		config.DB.Where("conversation_id = ? AND system_event IS NOT NULL", group.ID).Order("id").Find(&messages)
+
+		events := make([]models.SystemEvent, len(messages))
+		for i, m := range messages {
+			events[i] = *m.SystemEvent
+		}
+		return events
+	}
+
+	// 1. Only admins manage the group, the creator is the first one
+	_, err = services.RenameGroupConversation(group.ID, user2.ID, "Tennis")
+	assert.ErrorIs(t, err, appError.ErrNotConversationAdmin)
+
+	renamed, err := services.RenameGroupConversation(group.ID, user1.ID, "Tennis")
+	assert.NoError(t, err)
+	assert.Equal(t, "Tennis", *renamed.Title)
+
+	// 2. Adding and removing participants
+	_, err = services.AddGroupParticipants(group.ID, user1.ID, []uint{user3.ID, user2.ID})
+	assert.NoError(t, err)
+	isMember, _ := services.IsConversationMember(group.ID, user3.ID)
+	assert.True(t, isMember)
+
+	_, err = services.RemoveGroupParticipant(group.ID, user1.ID, user3.ID)
+	assert.NoError(t, err)
+	isMember, _ = services.IsConversationMember(group.ID, user3.ID)
+	assert.False(t, isMember)
+
+	// 3. The last admin can't be revoked, a second one can
+	_, err = services.SetGroupAdmin(group.ID, user1.ID, user1.ID, false)
+	assert.ErrorIs(t, err, appError.ErrLastConversationAdmin)
+
+	_, err = services.SetGroupAdmin(group.ID, user1.ID, user2.ID, true)
+	assert.NoError(t, err)
+	_, err = services.SetGroupAdmin(group.ID, user2.ID, user1.ID, false)
+	assert.NoError(t, err)
+
+	// 4. When the last admin leaves, the remaining participant takes over
+	_, err = services.AddGroupParticipants(group.ID, user2.ID, []uint{user3.ID})
+	assert.NoError(t, err)
+	assert.NoError(t, services.LeaveGroupConversation(group.ID, user2.ID))
+
+	var participant models.ConversationParticipant
+	config.DB.Where("conversation_id = ? AND user_id = ?", group.ID, user1.ID).First(&participant)
+	assert.True(t, participant.IsAdmin)
+
+	var left models.ConversationParticipant
+	config.DB.Where("conversation_id = ? AND user_id = ?", group.ID, user2.ID).First(&left)
+	assert.NotNil(t, left.LeftAt)
+	assert.False(t, left.IsAdmin)
+
+	// 5. Every change posted a system message, which nobody can edit
+	assert.Equal(t, []models.SystemEvent{
+		models.SystemEventGroupRenamed,
+		models.SystemEventParticipantAdded,
+		models.SystemEventParticipantRemoved,
+		models.SystemEventAdminGranted,
+		models.SystemEventAdminRevoked,
+		models.SystemEventParticipantAdded,
+		models.SystemEventParticipantLeft,
+		models.SystemEventAdminGranted,
+	}, systemEvents())
+
+	var system models.Message
+	config.DB.Where("conversation_id = ? AND sender_id = ? AND system_event IS NOT NULL", group.ID, user1.ID).First(&system)
+	_, err = services.EditMessage(group.ID, system.ID, user1.ID, "Changed")
+	assert.ErrorIs(t, err, appError.ErrNotMessageSender)
+
+	// 6. Team and challenge conversations are managed by their sync functions
+	team := models.Team{Name: "Team", CreatorID: user1.ID}
+	config.DB.Create(&team)
+	assert.NoError(t, services.SyncTeamConversationMembers(team.ID, []uint{user1.ID, user2.ID}))
+
+	teamConversation, _ := services.EnsureTeamConversation(team.ID)
+	_, err = services.RenameGroupConversation(teamConversation.ID, user1.ID, "Renamed")
+	assert.ErrorIs(t, err, appError.ErrConversationNotEditable)
+	assert.ErrorIs(t, services.LeaveGroupConversation(teamConversation.ID, user2.ID), appError.ErrConversationNotEditable)
+}
@@ -3,6 +3,7 @@ package integration
 import (
 	"server/api/cron/tasks"
 	"server/common/config"
+	"server/common/dto"
 	"server/common/models"
 	"server/common/services"
 	"testing"
@@ -253,3 +254,138 @@ func TestNotifiUserMissingParticipants12H(t *testing.T) {
 		Count(&cntC)
 	assert.Equal(t, int64(0), cntC)
 }
+
+// ------- TESTS FOR SOFT DELETE PURGE ------- \\
+
+func TestPurgeSoftDeletedTeamsAndChallenges(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	fixed := time.Date(2026, 1, 11, 10, 0, 0, 0, time.UTC)
+	oldNow := tasks.NowFunc
+	tasks.NowFunc = func() time.Time { return fixed }
+	defer func() { tasks.NowFunc = oldNow }()
+
+	config.AppConfig.SoftDeleteRetentionDays = 30
+
+	creator, _ := services.CreateUser(models.User{Email: "creatorPurge@test.com", FirstName: "C", LastName: "Creator"}, "pwd1")
+
+	oldTeam, _ := services.CreateTeam(models.Team{Name: "Old", CreatorID: creator.ID}, nil, nil)
+	recentTeam, _ := services.CreateTeam(models.Team{Name: "Recent", CreatorID: creator.ID}, nil, nil)
+
+	// Team conversation with a message that must be purged together with the team
+	err := services.SyncTeamConversationMembers(oldTeam.ID, []uint{creator.ID})
+	assert.NoError(t, err)
+	conv, _ := services.EnsureTeamConversation(oldTeam.ID)
+	_, err = services.SendMessage(conv.ID, creator.ID, "hello")
+	assert.NoError(t, err)
+
+	oldChallenge, err := services.CreateChallenge(models.Challenge{
+		CreatorID: creator.ID,
+		Date:      fixed,
+		StartTime: fixed.Add(-60 * 24 * time.Hour),
+	}, nil)
+	assert.NoError(t, err)
+
+	// Soft delete all, backdating the old ones past the retention period
+	services.SoftDeleteTeam(oldTeam.ID)
+	services.SoftDeleteTeam(recentTeam.ID)
+	services.DeleteChallenge(oldChallenge.ID)
+
+	config.DB.Unscoped().Model(&models.Team{}).Where("id = ?", oldTeam.ID).Update("deleted_at", fixed.AddDate(0, 0, -31))
+	config.DB.Unscoped().Model(&models.Team{}).Where("id = ?", recentTeam.ID).Update("deleted_at", fixed.AddDate(0, 0, -1))
+	config.DB.Unscoped().Model(&models.Challenge{}).Where("id = ?", oldChallenge.ID).Update("deleted_at", fixed.AddDate(0, 0, -31))
+
+	tasks.RunPurgeSoftDeletedTeamsAndChallenges()
+
+	var count int64
+	config.DB.Unscoped().Model(&models.Team{}).Where("id = ?", oldTeam.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	config.DB.Unscoped().Model(&models.Team{}).Where("id = ?", recentTeam.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	config.DB.Unscoped().Model(&models.Challenge{}).Where("id = ?", oldChallenge.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	config.DB.Model(&models.Conversation{}).Where("id = ?", conv.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	config.DB.Model(&models.Message{}).Where("conversation_id = ?", conv.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestAnonymizeDeletedUsers(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	fixed := time.Now().UTC()
+	oldNow := tasks.NowFunc
+	tasks.NowFunc = func() time.Time { return fixed }
+	defer func() { tasks.NowFunc = oldNow }()
+
+	config.AppConfig.AccountDeletionGraceDays = 30
+
+	leaver, _ := services.CreateUser(models.User{Email: "leaver@test.com", FirstName: "Leaver", LastName: "L", City: "Odense"}, "pwd1")
+	recent, _ := services.CreateUser(models.User{Email: "recent@test.com", FirstName: "Recent", LastName: "R"}, "pwd1")
+	other, _ := services.CreateUser(models.User{Email: "otherAnon@test.com", FirstName: "Other", LastName: "O"}, "pwd1")
+
+	// Team owned by the leaver, with another member who should take over
+	team, _ := services.CreateTeam(models.Team{Name: "Handover", CreatorID: leaver.ID}, nil, nil)
+	config.DB.Create(&models.TeamMember{TeamID: team.ID, UserID: other.ID, Role: models.RoleAdmin})
+
+	// Upcoming public challenge hosted by the leaver, which should be cancelled
+	hosted, err := services.CreateChallenge(models.Challenge{
+		CreatorID: leaver.ID,
+		IsPublic:  true,
+		Date:      fixed,
+		StartTime: fixed.Add(24 * time.Hour),
+	}, nil)
+	assert.NoError(t, err)
+
+	// Direct conversation with a message that must survive
+	conv, _ := services.CreateDirectConversation(leaver.ID, other.ID)
+	msg, err := services.SendMessage(conv.ID, leaver.ID, "bye")
+	assert.NoError(t, err)
+
+	// Delete both, backdating one past the grace period
+	assert.NoError(t, services.DeleteUser(*leaver, leaver.Email))
+	assert.NoError(t, services.DeleteUser(*recent, recent.Email))
+	config.DB.Unscoped().Model(&models.User{}).Where("id = ?", leaver.ID).Update("deleted_at", fixed.AddDate(0, 0, -31))
+	config.DB.Unscoped().Model(&models.User{}).Where("id = ?", recent.ID).Update("deleted_at", fixed.AddDate(0, 0, -1))
+
+	tasks.RunAnonymizeDeletedUsers()
+
+	// 1. Personal fields are scrubbed
+	var anonymized models.User
+	config.DB.Unscoped().First(&anonymized, leaver.ID)
+	assert.NotNil(t, anonymized.AnonymizedAt)
+	assert.Equal(t, models.DeletedUserName, anonymized.FirstName)
+	assert.Empty(t, anonymized.City)
+	assert.NotEqual(t, "leaver@test.com", anonymized.Email)
+
+	// 2. Users within the grace period are untouched
+	var untouched models.User
+	config.DB.Unscoped().First(&untouched, recent.ID)
+	assert.Nil(t, untouched.AnonymizedAt)
+	assert.Equal(t, "Recent", untouched.FirstName)
+
+	// 3. Messages are kept and shown as deleted user
+	kept, err := services.GetMessageByID(msg.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeletedUserName, dto.ToMessageResponseDto(*kept).Sender.FirstName)
+
+	// 4. Ownership is handed over
+	var member models.TeamMember
+	config.DB.Where("team_id = ? AND user_id = ?", team.ID, other.ID).First(&member)
+	assert.Equal(t, models.RoleOwner, member.Role)
+
+	var handedOver models.Team
+	config.DB.First(&handedOver, team.ID)
+	assert.Equal(t, other.ID, handedOver.CreatorID)
+
+	// 5. Upcoming public challenges are cancelled
+	var count int64
+	config.DB.Model(&models.Challenge{}).Where("id = ?", hosted.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
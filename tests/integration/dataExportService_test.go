@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"server/api/cron/tasks"
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataExportService_Flow(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.DataExportExpirationHours = 48
+
+	user, _ := services.CreateUser(models.User{Email: "export@test.com", FirstName: "Export", LastName: "E", Settings: &models.UserSettings{}}, "pw")
+
+	// 1. Request export
+	export, err := services.RequestDataExport(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DataExportPending, export.Status)
+
+	// 2. Only one export at a time
+	_, err = services.RequestDataExport(user.ID)
+	assert.ErrorIs(t, err, appError.ErrDataExportInProgress)
+
+	// 3. Cron builds the ZIP
+	tasks.RunProcessDataExports()
+
+	latest, err := services.GetLatestDataExport(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DataExportReady, latest.Status)
+	assert.NotNil(t, latest.Token)
+
+	// 4. Download with token
+	ready, err := services.GetDataExportByToken(*latest.Token)
+	assert.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(ready.Data), int64(len(ready.Data)))
+	assert.NoError(t, err)
+
+	names := make([]string, len(zr.File))
+	for i, f := range zr.File {
+		names[i] = f.Name
+	}
+	assert.Contains(t, names, "profile.json")
+	assert.Contains(t, names, "messages.json")
+	assert.Contains(t, names, "eula_acceptances.json")
+
+	// 5. A new export can be requested once the previous one is done
+	_, err = services.RequestDataExport(user.ID)
+	assert.NoError(t, err)
+}
+
+func TestDataExportService_Expired(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	user, _ := services.CreateUser(models.User{Email: "expired@test.com", FirstName: "Expired", LastName: "E"}, "pw")
+
+	token := "expired-token"
+	past := time.Now().Add(-time.Hour)
+	config.DB.Create(&models.DataExport{
+		UserID:    user.ID,
+		Status:    models.DataExportReady,
+		Token:     &token,
+		Data:      []byte("zip"),
+		ExpiresAt: &past,
+	})
+
+	// 1. Expired tokens are rejected
+	_, err := services.GetDataExportByToken(token)
+	assert.ErrorIs(t, err, appError.ErrDataExportExpired)
+
+	// 2. Cleanup removes the expired export
+	tasks.RunCleanupExpiredDataExports()
+
+	var count int64
+	config.DB.Model(&models.DataExport{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDataExportService_InterruptedProcessing(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	user, _ := services.CreateUser(models.User{Email: "stuck@test.com", FirstName: "Stuck", LastName: "S"}, "pw")
+
+	startedAt := time.Now().Add(-time.Hour)
+	stuck := models.DataExport{UserID: user.ID, Status: models.DataExportProcessing, StartedAt: &startedAt}
+	config.DB.Create(&stuck)
+
+	// 1. The interrupted export blocks new requests
+	_, err := services.RequestDataExport(user.ID)
+	assert.ErrorIs(t, err, appError.ErrDataExportInProgress)
+
+	// 2. Cron marks it failed
+	tasks.RunProcessDataExports()
+
+	var failed models.DataExport
+	config.DB.Omit("data").First(&failed, stuck.ID)
+	assert.Equal(t, models.DataExportFailed, failed.Status)
+
+	// 3. The user can request a new one
+	_, err = services.RequestDataExport(user.ID)
+	assert.NoError(t, err)
+}
+
+func TestDataExportService_ConcurrentRequests(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	user, _ := services.CreateUser(models.User{Email: "concurrent@test.com", FirstName: "Concurrent", LastName: "C"}, "pw")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			services.RequestDataExport(user.ID)
+		}()
+	}
+	wg.Wait()
+
+	// Only one of the concurrent requests creates an export
+	var count int64
+	config.DB.Model(&models.DataExport{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
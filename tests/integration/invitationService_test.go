@@ -2,6 +2,7 @@ package integration
 
 import (
 	"server/common/appError"
+	"server/common/dto"
 	"server/common/models"
 	"server/common/services"
 	"testing"
@@ -122,3 +123,41 @@ func TestInvitationService_DeclineResend(t *testing.T) {
 	list2, _ := services.GetInvitationsByUserId(u2.ID)
 	assert.Equal(t, models.StatusPending, list2[0].Status)
 }
+
+func TestInvitationService_FriendRequestPrivacy(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	target, _ := services.CreateUser(models.User{Email: "target@p.com", FirstName: "T", LastName: "T", Settings: &models.UserSettings{}}, "pw")
+	mutual, _ := services.CreateUser(models.User{Email: "mutual@p.com", FirstName: "M", LastName: "M"}, "pw")
+	stranger, _ := services.CreateUser(models.User{Email: "stranger@p.com", FirstName: "S", LastName: "S"}, "pw")
+
+	// Make target and mutual friends
+	err := services.SendInvitation(&models.Invitation{InviterId: mutual.ID, InviteeId: target.ID, ResourceType: models.ResourceTypeFriend})
+	assert.NoError(t, err)
+	list, _ := services.GetInvitationsByUserId(target.ID)
+	assert.NoError(t, services.AcceptInvitation(list[0].ID, target.ID))
+
+	// 1. Nobody can send requests
+	nobody := models.PrivacyNobody
+	assert.NoError(t, services.UpdateUserSettings(target.ID, dto.UserSettingsUpdateDto{PrivacyFriendRequests: &nobody}))
+
+	err = services.SendInvitation(&models.Invitation{InviterId: stranger.ID, InviteeId: target.ID, ResourceType: models.ResourceTypeFriend})
+	assert.ErrorIs(t, err, appError.ErrFriendRequestsNotAllowed)
+
+	// 2. Only friends of friends can send requests
+	friends := models.PrivacyFriends
+	assert.NoError(t, services.UpdateUserSettings(target.ID, dto.UserSettingsUpdateDto{PrivacyFriendRequests: &friends}))
+
+	err = services.SendInvitation(&models.Invitation{InviterId: stranger.ID, InviteeId: target.ID, ResourceType: models.ResourceTypeFriend})
+	assert.ErrorIs(t, err, appError.ErrFriendRequestsNotAllowed)
+
+	// Stranger becomes friends with mutual
+	err = services.SendInvitation(&models.Invitation{InviterId: stranger.ID, InviteeId: mutual.ID, ResourceType: models.ResourceTypeFriend})
+	assert.NoError(t, err)
+	list, _ = services.GetInvitationsByUserId(mutual.ID)
+	assert.NoError(t, services.AcceptInvitation(list[0].ID, mutual.ID))
+
+	err = services.SendInvitation(&models.Invitation{InviterId: stranger.ID, InviteeId: target.ID, ResourceType: models.ResourceTypeFriend})
+	assert.NoError(t, err)
+}
@@ -1,12 +1,16 @@
 package integration
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"server/common/appError"
 	"server/common/config"
+	"server/common/dto"
 	"server/common/models"
 	"server/common/services"
+	"server/common/validator"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -225,3 +229,370 @@ func TestGetMessageByID(t *testing.T) {
 	assert.NotNil(t, retrievedMessage3.Sender)
 	assert.Equal(t, user3.ID, retrievedMessage3.Sender.ID)
 }
+
+func TestGetMessagesSince_Replay(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	user3 := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&user3)
+
+	group, _ := services.CreateGroupConversation(user1.ID, []uint{user2.ID, user3.ID}, "Group")
+	direct, _ := services.CreateDirectConversation(user2.ID, user3.ID)
+
+	first, _ := services.SendMessage(group.ID, user2.ID, "First")
+	services.SendMessage(group.ID, user3.ID, "From blocked user")
+	services.SendMessage(direct.ID, user2.ID, "Not in my conversations")
+	assert.NoError(t, services.BlockUser(user1.ID, user3.ID))
+
+	// 1. Only messages from the user's own conversations, without blocked senders
+	messages, err := services.GetMessagesSince(user1.ID, 0, nil, 100)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "First", messages[0].Content)
+
+	// 2. Resume after the last seen message or time
+	second, _ := services.SendMessage(group.ID, user2.ID, "Second")
+
+	messages, err = services.GetMessagesSince(user1.ID, first.ID, nil, 100)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, second.ID, messages[0].ID)
+
+	messages, err = services.GetMessagesSince(user1.ID, 0, &first.CreatedAt, 100)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, second.ID, messages[0].ID)
+}
+
+func TestGetMessagesSince_RemovedMember(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+
+	group, _ := services.CreateGroupConversation(user1.ID, []uint{user2.ID}, "Padel")
+	_, err := services.RemoveGroupParticipant(group.ID, user1.ID, user2.ID)
+	assert.NoError(t, err)
+
+	_, err = services.SendMessage(group.ID, user1.ID, "After you left")
+	assert.NoError(t, err)
+
+	// A removed member resumes and receives nothing, not even the system message about the removal
+	messages, err := services.GetMessagesSince(user2.ID, 0, nil, 100)
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestEditAndDeleteMessage(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+
+	conv, _ := services.CreateDirectConversation(user1.ID, user2.ID)
+	msg, _ := services.SendMessage(conv.ID, user1.ID, "Game on at 17?")
+
+	// 1. Only the sender can edit, the previous version is kept
+	_, err := services.EditMessage(conv.ID, msg.ID, user2.ID, "Hacked")
+	assert.ErrorIs(t, err, appError.ErrNotMessageSender)
+
+	edited, err := services.EditMessage(conv.ID, msg.ID, user1.ID, "Game on at 18?")
+	assert.NoError(t, err)
+	assert.Equal(t, "Game on at 18?", edited.Content)
+	assert.NotNil(t, edited.EditedAt)
+
+	edits, err := services.GetMessageEdits(conv.ID, msg.ID, user2.ID)
+	assert.NoError(t, err)
+	assert.Len(t, edits, 1)
+	assert.Equal(t, "Game on at 17?", edits[0].Content)
+
+	// 2. Deleting leaves a tombstone without content or history
+	assert.NoError(t, services.DeleteMessage(conv.ID, msg.ID, user1.ID))
+	assert.NoError(t, services.DeleteMessage(conv.ID, msg.ID, user1.ID))
+
+	messages, _, _, err := services.GetMessages(conv.ID, user2.ID, 50, nil)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.True(t, messages[0].IsDeleted())
+	assert.Empty(t, messages[0].Content)
+
+	edits, err = services.GetMessageEdits(conv.ID, msg.ID, user2.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, edits)
+
+	// 3. Tombstones can't be edited
+	_, err = services.EditMessage(conv.ID, msg.ID, user1.ID, "Back again")
+	assert.ErrorIs(t, err, appError.ErrMessageDeleted)
+}
+
+func TestToggleReaction(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	outsider := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&outsider)
+
+	conv, _ := services.CreateDirectConversation(user1.ID, user2.ID)
+	msg, _ := services.SendMessage(conv.ID, user1.ID, "Game on at 18:00?")
+
+	// 1. Both members react with the same emoji, one also with another
+	_, added, err := services.ToggleReaction(conv.ID, msg.ID, user1.ID, "👍")
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	_, _, err = services.ToggleReaction(conv.ID, msg.ID, user2.ID, "👍")
+	assert.NoError(t, err)
+
+	reacted, _, err := services.ToggleReaction(conv.ID, msg.ID, user2.ID, "🔥")
+	assert.NoError(t, err)
+
+	reactions := dto.ToMessageResponseDtoForUser(*reacted, user1.ID).Reactions
+	assert.Len(t, reactions, 2)
+	assert.Equal(t, dto.ReactionCountResponseDto{Emoji: "👍", Count: 2, ReactedByMe: true}, reactions[0])
+	assert.Equal(t, dto.ReactionCountResponseDto{Emoji: "🔥", Count: 1, ReactedByMe: false}, reactions[1])
+
+	// 2. Toggling again removes only the user's own reaction
+	_, added, err = services.ToggleReaction(conv.ID, msg.ID, user2.ID, "👍")
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	messages, _, _, err := services.GetMessages(conv.ID, user2.ID, 50, nil)
+	assert.NoError(t, err)
+	reactions = dto.ToMessageResponseDtoForUser(messages[0], user2.ID).Reactions
+	assert.Equal(t, dto.ReactionCountResponseDto{Emoji: "👍", Count: 1, ReactedByMe: false}, reactions[0])
+	assert.Equal(t, dto.ReactionCountResponseDto{Emoji: "🔥", Count: 1, ReactedByMe: true}, reactions[1])
+
+	// 3. Non-members can't react
+	_, _, err = services.ToggleReaction(conv.ID, msg.ID, outsider.ID, "👍")
+	assert.ErrorIs(t, err, appError.ErrNotConversationMember)
+
+	// 4. Deleting the message removes its reactions, tombstones can't be reacted to
+	assert.NoError(t, services.DeleteMessage(conv.ID, msg.ID, user1.ID))
+
+	var count int64
+	config.DB.Model(&models.MessageReaction{}).Where("message_id = ?", msg.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	_, _, err = services.ToggleReaction(conv.ID, msg.ID, user1.ID, "👍")
+	assert.ErrorIs(t, err, appError.ErrMessageDeleted)
+}
+
+func TestSendReply_Thread(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	user3 := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&user3)
+
+	group, _ := services.CreateGroupConversation(user1.ID, []uint{user2.ID, user3.ID}, "Padel")
+	other, _ := services.CreateDirectConversation(user1.ID, user2.ID)
+
+	question, _ := services.SendMessage(group.ID, user1.ID, "Game on at 18:00?")
+
+	// 1. A reply carries a preview of its parent
+	reply, err := services.SendReply(group.ID, user2.ID, question.ID, "Count me in")
+	assert.NoError(t, err)
+	assert.Equal(t, question.ID, *reply.ReplyToMessageID)
+	assert.Equal(t, "Game on at 18:00?", dto.ToMessageResponseDto(*reply).ReplyTo.Content)
+
+	nested, err := services.SendReply(group.ID, user3.ID, reply.ID, "Me too")
+	assert.NoError(t, err)
+
+	// Parents must be in the same conversation
+	_, err = services.SendReply(other.ID, user2.ID, question.ID, "Wrong chat")
+	assert.ErrorIs(t, err, appError.ErrMessageNotFound)
+
+	// 2. The thread holds every reply below the message
+	root, replies, err := services.GetMessageThread(group.ID, question.ID, user2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, question.ID, root.ID)
+	assert.Len(t, replies, 2)
+	assert.Equal(t, reply.ID, replies[0].ID)
+	assert.Equal(t, nested.ID, replies[1].ID)
+
+	// 3. Users who blocked the parent's sender see the reply without its preview
+	assert.NoError(t, services.BlockUser(user3.ID, user1.ID))
+
+	messages, _, _, err := services.GetMessages(group.ID, user3.ID, 50, nil)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, question.ID, *messages[0].ReplyToMessageID)
+	assert.Nil(t, messages[0].ReplyTo)
+
+	_, _, err = services.GetMessageThread(group.ID, question.ID, user3.ID)
+	assert.ErrorIs(t, err, appError.ErrMessageNotFound)
+
+	_, err = services.SendReply(group.ID, user3.ID, question.ID, "Blocked")
+	assert.ErrorIs(t, err, appError.ErrMessageNotFound)
+
+	// 4. Deleted parents show as tombstones and can't be replied to
+	assert.NoError(t, services.DeleteMessage(group.ID, question.ID, user1.ID))
+
+	messages, _, _, err = services.GetMessages(group.ID, user2.ID, 50, nil)
+	assert.NoError(t, err)
+	preview := dto.ToMessageResponseDto(messages[1]).ReplyTo
+	assert.NotNil(t, preview)
+	assert.NotNil(t, preview.DeletedAt)
+	assert.Empty(t, preview.Content)
+
+	_, err = services.SendReply(group.ID, user2.ID, question.ID, "Too late")
+	assert.ErrorIs(t, err, appError.ErrMessageDeleted)
+}
+
+func TestMessageReceipts(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	user3 := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&user3)
+
+	group, _ := services.CreateGroupConversation(user1.ID, []uint{user2.ID, user3.ID}, "Padel")
+	first, _ := services.SendMessage(group.ID, user1.ID, "Game on at 18:00?")
+	time.Sleep(10 * time.Millisecond)
+	second, _ := services.SendMessage(group.ID, user1.ID, "Court 3")
+
+	receiptsFor := func(msg *models.Message) dto.MessageResponseDto {
+		participants, err := services.GetReceiptParticipants(group.ID, user1.ID)
+		assert.NoError(t, err)
+
+		res := dto.ToMessageResponseDto(*msg)
+		dto.ApplyMessageReceipts(&res, participants, user1.ID)
+		return res
+	}
+
+	// 1. Nobody has received the messages yet
+	assert.Equal(t, dto.MessageStatusSent, receiptsFor(first).Status)
+
+	// 2. Delivered once every other participant has it on a device, the time never moves back
+	assert.NoError(t, services.MarkConversationDelivered(group.ID, user2.ID, second.CreatedAt))
+	assert.NoError(t, services.MarkConversationDelivered(group.ID, user2.ID, first.CreatedAt))
+	assert.Equal(t, dto.MessageStatusSent, receiptsFor(second).Status)
+
+	assert.NoError(t, services.MarkConversationDelivered(group.ID, user3.ID, second.CreatedAt))
+	assert.Equal(t, dto.MessageStatusDelivered, receiptsFor(second).Status)
+
+	// 3. Seen by lists who read the message, read once everybody did
+	assert.NoError(t, services.MarkConversationRead(group.ID, user2.ID, second.CreatedAt))
+	assert.NoError(t, services.MarkConversationRead(group.ID, user3.ID, first.CreatedAt))
+
+	// A late read from another device doesn't move the time back
+	assert.NoError(t, services.MarkConversationRead(group.ID, user2.ID, first.CreatedAt))
+
+	res := receiptsFor(first)
+	assert.ElementsMatch(t, []uint{user2.ID, user3.ID}, res.SeenBy)
+	assert.Equal(t, dto.MessageStatusRead, res.Status)
+
+	res = receiptsFor(second)
+	assert.Equal(t, []uint{user2.ID}, res.SeenBy)
+	assert.Equal(t, dto.MessageStatusDelivered, res.Status)
+
+	// 4. Receipts of blocked users are hidden
+	assert.NoError(t, services.BlockUser(user1.ID, user3.ID))
+	res = receiptsFor(second)
+	assert.Equal(t, dto.MessageStatusRead, res.Status)
+
+	// Other participants see who read, but no status on messages they didn't send
+	participants, err := services.GetReceiptParticipants(group.ID, user2.ID)
+	assert.NoError(t, err)
+	res = dto.ToMessageResponseDto(*first)
+	dto.ApplyMessageReceipts(&res, participants, user2.ID)
+	assert.Equal(t, []uint{user3.ID}, res.SeenBy)
+	assert.Empty(t, res.Status)
+
+	// 5. Users who left can't send read receipts
+	assert.NoError(t, services.LeaveGroupConversation(group.ID, user3.ID))
+	err = services.MarkConversationRead(group.ID, user3.ID, second.CreatedAt)
+	assert.ErrorIs(t, err, appError.ErrNotConversationMember)
+}
+
+func TestMentionsAndMute(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One"}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two"}
+	user3 := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three"}
+	outsider := models.User{Email: "user4@test.com", Password: &password, FirstName: "User", LastName: "Four"}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&user3)
+	config.DB.Create(&outsider)
+
+	group, _ := services.CreateGroupConversation(user1.ID, []uint{user2.ID, user3.ID}, "Padel")
+
+	// 1. Mentions of participants are stored, the sender and outsiders are ignored
+	content := fmt.Sprintf("@[User Two](%d) @[User One](%d) @[User Four](%d) @[User Two](%d) court 3?",
+		user2.ID, user1.ID, outsider.ID, user2.ID)
+	msg, err := services.SendMessage(group.ID, user1.ID, content)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{user2.ID}, dto.ToMessageResponseDto(*msg).Mentions)
+
+	// 2. Editing the message updates the mentions
+	edited, err := services.EditMessage(group.ID, msg.ID, user1.ID, fmt.Sprintf("@[User Three](%d) court 3?", user3.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{user3.ID}, dto.ToMessageResponseDto(*edited).Mentions)
+
+	var count int64
+	config.DB.Model(&models.MessageMention{}).Where("message_id = ?", msg.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 3. Muting until a time, a mute in the past is rejected
+	past := time.Now().Add(-time.Hour)
+	err = validator.V.Struct(dto.UpdateConversationNotificationsDto{Muted: true, MutedUntil: &past})
+	assert.Error(t, err)
+
+	until := time.Now().Add(time.Hour)
+	participant, err := services.UpdateConversationNotifications(group.ID, user2.ID, dto.UpdateConversationNotificationsDto{
+		Muted:      true,
+		MutedUntil: &until,
+	})
+	assert.NoError(t, err)
+	assert.True(t, participant.IsMuted(time.Now()))
+	assert.False(t, participant.IsMuted(until.Add(time.Minute)))
+
+	// 4. Muting forever and only wanting mentions
+	participant, err = services.UpdateConversationNotifications(group.ID, user2.ID, dto.UpdateConversationNotificationsDto{
+		Muted:        true,
+		MentionsOnly: true,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, participant.MutedUntil)
+	assert.True(t, participant.IsMuted(time.Now().Add(24*365*time.Hour)))
+
+	settings := dto.ToConversationNotificationsResponseDto(*participant)
+	assert.True(t, settings.Muted)
+	assert.True(t, settings.MentionsOnly)
+
+	// 5. Unmuting
+	participant, err = services.UpdateConversationNotifications(group.ID, user2.ID, dto.UpdateConversationNotificationsDto{})
+	assert.NoError(t, err)
+	assert.False(t, participant.IsMuted(time.Now()))
+	assert.Nil(t, participant.MutedAt)
+
+	// 6. Only participants have settings
+	_, err = services.UpdateConversationNotifications(group.ID, outsider.ID, dto.UpdateConversationNotificationsDto{Muted: true})
+	assert.ErrorIs(t, err, appError.ErrNotConversationMember)
+}
@@ -0,0 +1,206 @@
+package integration
+
+import (
+	"errors"
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"server/common/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFAService_EnrollAndLogin(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	email := "mfa@test.com"
+	password := "password123"
+	user, _ := services.CreateUser(models.User{Email: email, FirstName: "Mfa", LastName: "User"}, password)
+
+	// 1. Enroll, 2FA isn't active before confirmation
+	enrollment, err := services.EnrollMFA(user.ID, 0, password)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	assert.NoError(t, err)
+
+	// 2. Confirm with a code from the authenticator
+	_, err = services.ConfirmMFA(user.ID, "000000")
+	assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(enrollment.Secret, step)
+	recoveryCodes, err := services.ConfirmMFA(user.ID, code)
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+
+	_, err = services.EnrollMFA(user.ID, 0, password)
+	assert.ErrorIs(t, err, appError.ErrMFAAlreadyEnabled)
+
+	// 3. Password login now requires a second step
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	var mfaErr *appError.MFARequiredError
+	assert.True(t, errors.As(err, &mfaErr))
+	assert.NotEmpty(t, mfaErr.Token)
+
+	// 4. Wrong code and replayed code are rejected
+	_, _, err = services.VerifyMFALogin(mfaErr.Token, "000000", services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+
+	_, _, err = services.VerifyMFALogin(mfaErr.Token, code, services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+
+	// 5. Recovery code completes the login once
+	loggedIn, tokens, err := services.VerifyMFALogin(mfaErr.Token, recoveryCodes[0], services.SessionClient{})
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	// The challenge is used up
+	_, _, err = services.VerifyMFALogin(mfaErr.Token, recoveryCodes[1], services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidToken)
+
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	assert.True(t, errors.As(err, &mfaErr))
+
+	_, _, err = services.VerifyMFALogin(mfaErr.Token, recoveryCodes[0], services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+
+	// 6. Disable with a recovery code
+	err = services.DisableMFA(user.ID, recoveryCodes[1])
+	assert.NoError(t, err)
+
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	assert.NoError(t, err)
+}
+
+func TestMFAService_EnrollRequiresReauthentication(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	password := "password123"
+	user, _ := services.CreateUser(models.User{Email: "mfa-reauth@test.com", FirstName: "Mfa", LastName: "User"}, password)
+
+	// 1. A wrong password is rejected and no secret is stored
+	_, err := services.EnrollMFA(user.ID, 0, "wrongpassword")
+	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
+
+	var stored models.User
+	config.DB.First(&stored, user.ID)
+	assert.Nil(t, stored.TOTPSecret)
+
+	_, err = services.EnrollMFA(user.ID, 0, password)
+	assert.NoError(t, err)
+
+	// 2. OAuth accounts must have signed in recently
+	oauthUser := models.User{Email: "mfa-oauth@test.com", FirstName: "Mfa", LastName: "User", AuthProvider: "google"}
+	config.DB.Create(&oauthUser)
+
+	tokens, _ := services.CreateSession(&oauthUser, services.SessionClient{})
+	claims, _ := services.ValidateJWTToken(tokens.AccessToken)
+
+	config.DB.Model(&models.UserSession{}).Where("id = ?", claims.SessionID).
+		Update("created_at", time.Now().Add(-time.Hour))
+
+	_, err = services.EnrollMFA(oauthUser.ID, claims.SessionID, "")
+	assert.ErrorIs(t, err, appError.ErrReauthRequired)
+
+	tokens, _ = services.CreateSession(&oauthUser, services.SessionClient{})
+	claims, _ = services.ValidateJWTToken(tokens.AccessToken)
+
+	_, err = services.EnrollMFA(oauthUser.ID, claims.SessionID, "")
+	assert.NoError(t, err)
+}
+
+func TestMFAService_WrongCodesAreThrottled(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	password := "password123"
+	user, _ := services.CreateUser(models.User{Email: "mfa-throttle@test.com", FirstName: "Mfa", LastName: "User"}, password)
+
+	enrollment, _ := services.EnrollMFA(user.ID, 0, password)
+
+	// 1. Confirming counts wrong codes
+	for i := 0; i < 5; i++ {
+		_, err := services.ConfirmMFA(user.ID, "000000")
+		assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+	}
+
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	_, err := services.ConfirmMFA(user.ID, code)
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	// The lockout expires, a valid code clears the failures
+	config.DB.Model(&models.AuthThrottle{}).Where("key = ?", "login:source:mfa-throttle@test.com").
+		Update("locked_until", time.Now().Add(-1*time.Second))
+
+	recoveryCodes, err := services.ConfirmMFA(user.ID, code)
+	assert.NoError(t, err)
+
+	// 2. Disabling and regenerating share the lockout, even a valid code is rejected while locked
+	for i := 0; i < 3; i++ {
+		err := services.DisableMFA(user.ID, "000000")
+		assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := services.RegenerateRecoveryCodes(user.ID, "000000")
+		assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+	}
+
+	err = services.DisableMFA(user.ID, recoveryCodes[0])
+	assert.ErrorIs(t, err, appError.ErrTooManyAttempts)
+
+	var mfaUser models.User
+	config.DB.First(&mfaUser, user.ID)
+	assert.NotNil(t, mfaUser.TOTPEnabledAt)
+}
+
+func TestMFAService_DeletedAccountRestoredAfterCode(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.AccountDeletionGraceDays = 30
+
+	email := "mfa-deleted@test.com"
+	password := "password123"
+	user, _ := services.CreateUser(models.User{Email: email, FirstName: "Mfa", LastName: "User"}, password)
+
+	enrollment, _ := services.EnrollMFA(user.ID, 0, password)
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	recoveryCodes, _ := services.ConfirmMFA(user.ID, code)
+
+	err := services.DeleteUser(*user, email)
+	assert.NoError(t, err)
+
+	// 1. The password alone doesn't restore the account
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	var mfaErr *appError.MFARequiredError
+	assert.True(t, errors.As(err, &mfaErr))
+
+	_, err = services.GetUserByID(user.ID)
+	assert.Error(t, err)
+
+	_, _, err = services.VerifyMFALogin(mfaErr.Token, "000000", services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidMFACode)
+
+	_, err = services.GetUserByID(user.ID)
+	assert.Error(t, err)
+
+	// 2. The second factor restores it
+	_, _, err = services.VerifyMFALogin(mfaErr.Token, recoveryCodes[0], services.SessionClient{})
+	assert.NoError(t, err)
+
+	_, err = services.GetUserByID(user.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+	"server/common/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence(t *testing.T) {
+	setupTest(t)
+
+	password := "hash"
+	user1 := models.User{Email: "user1@test.com", Password: &password, FirstName: "User", LastName: "One", Settings: &models.UserSettings{}}
+	user2 := models.User{Email: "user2@test.com", Password: &password, FirstName: "User", LastName: "Two", Settings: &models.UserSettings{}}
+	user3 := models.User{Email: "user3@test.com", Password: &password, FirstName: "User", LastName: "Three", Settings: &models.UserSettings{}}
+	config.DB.Create(&user1)
+	config.DB.Create(&user2)
+	config.DB.Create(&user3)
+
+	// user2 is a friend of user1, user3 a stranger
+	config.DB.Model(&user1).Association("Friends").Append(&user2)
+	config.DB.Model(&user2).Association("Friends").Append(&user1)
+
+	presenceOf := func(userID, viewerID uint) models.UserPresence {
+		presences, err := services.GetPresence([]uint{userID}, viewerID)
+		assert.NoError(t, err)
+		assert.Len(t, presences, 1)
+		return presences[0]
+	}
+
+	// 1. Online once connected, but only for friends and chat partners
+	connection, err := services.ConnectPresence(user1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PresenceOnline, presenceOf(user1.ID, user2.ID).Status)
+	assert.Equal(t, models.PresenceOffline, presenceOf(user1.ID, user3.ID).Status)
+
+	// 2. A second connection keeps the user online when the first closes
+	second, err := services.ConnectPresence(user1.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, services.DisconnectPresence(connection, user1.ID))
+	assert.Equal(t, models.PresenceOnline, presenceOf(user1.ID, user2.ID).Status)
+
+	// 3. Away when the app sent nothing for a while
+	config.DB.Model(&models.PresenceConnection{}).Where("id = ?", second).
+		Update("last_active_at", config.DB.NowFunc().Add(-10*time.Minute))
+	assert.NoError(t, services.RefreshPresence([]uint{user1.ID}))
+	assert.Equal(t, models.PresenceAway, presenceOf(user1.ID, user2.ID).Status)
+
+	// 4. Offline with a last seen time when the last connection closes
+	assert.NoError(t, services.DisconnectPresence(second, user1.ID))
+	presence := presenceOf(user1.ID, user2.ID)
+	assert.Equal(t, models.PresenceOffline, presence.Status)
+	assert.NotNil(t, presence.LastSeenAt)
+
+	// 5. Connections of instances that stopped sending heartbeats expire
+	_, err = services.ConnectPresence(user2.ID)
+	assert.NoError(t, err)
+	config.DB.Model(&models.PresenceConnection{}).Where("user_id = ?", user2.ID).
+		Update("last_heartbeat_at", config.DB.NowFunc().Add(-time.Hour))
+	assert.NoError(t, services.ExpirePresenceConnections())
+	assert.Equal(t, models.PresenceOffline, presenceOf(user2.ID, user1.ID).Status)
+
+	// 6. Users who hide their presence always appear offline without a last seen time
+	_, err = services.ConnectPresence(user1.ID)
+	assert.NoError(t, err)
+
+	hide := false
+	assert.NoError(t, services.UpdateUserSettings(user1.ID, dto.UserSettingsUpdateDto{ShowPresence: &hide}))
+	presence = presenceOf(user1.ID, user2.ID)
+	assert.Equal(t, models.PresenceOffline, presence.Status)
+	assert.Nil(t, dto.ToPresenceResponseDto(presence).LastSeenAt)
+
+	// Users still see their own presence
+	assert.Equal(t, models.PresenceOnline, presenceOf(user1.ID, user1.ID).Status)
+
+	// 7. Blocked users don't see each other
+	show := true
+	assert.NoError(t, services.UpdateUserSettings(user1.ID, dto.UserSettingsUpdateDto{ShowPresence: &show}))
+	assert.Equal(t, models.PresenceOnline, presenceOf(user1.ID, user2.ID).Status)
+
+	assert.NoError(t, services.BlockUser(user2.ID, user1.ID))
+	assert.Equal(t, models.PresenceOffline, presenceOf(user1.ID, user2.ID).Status)
+}
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"server/common/appError"
 	"server/common/config"
 	"server/common/dto"
 	"server/common/models"
@@ -114,3 +115,94 @@ func TestReportService_CreateReport(t *testing.T) {
 		assert.Contains(t, err.Error(), "violates foreign key constraint")
 	})
 }
+
+func TestReportService_DeduplicateAndSnapshot(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	reporter, _ := services.CreateUser(models.User{Email: "rep@s.com", FirstName: "R", LastName: "R"}, "pw")
+	sender, _ := services.CreateUser(models.User{Email: "snd@s.com", FirstName: "S", LastName: "S"}, "pw")
+	outsider, _ := services.CreateUser(models.User{Email: "out@s.com", FirstName: "O", LastName: "O"}, "pw")
+
+	conv, _ := services.CreateDirectConversation(reporter.ID, sender.ID)
+	msg, err := services.SendMessage(conv.ID, sender.ID, "something nasty")
+	assert.NoError(t, err)
+
+	req := dto.ReportCreateDto{TargetID: msg.ID, TargetType: "MESSAGE", Reason: "HARASSMENT"}
+
+	// 1. Reporting twice keeps a single pending report
+	assert.NoError(t, services.CreateReport(reporter.ID, req))
+	assert.NoError(t, services.CreateReport(reporter.ID, req))
+
+	var reports []models.Report
+	config.DB.Where("target_type = ? AND target_id = ?", "MESSAGE", msg.ID).Find(&reports)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, 3, reports[0].Severity)
+
+	// 2. The message content survives deletion of the message
+	config.DB.Delete(&models.Message{}, msg.ID)
+
+	reports, err = services.GetReportsForTarget(models.ReportTargetMessage, msg.ID)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "something nasty", *reports[0].TargetSnapshot)
+	assert.Equal(t, sender.ID, *reports[0].TargetOwnerID)
+
+	// 3. Messages the reporter can't see can't be reported
+	other, _ := services.CreateDirectConversation(sender.ID, reporter.ID)
+	hidden, _ := services.SendMessage(other.ID, sender.ID, "hi")
+	err = services.CreateReport(outsider.ID, dto.ReportCreateDto{TargetID: hidden.ID, TargetType: "MESSAGE", Reason: "SPAM"})
+	assert.ErrorIs(t, err, appError.ErrNotConversationMember)
+}
+
+func TestReportService_ModerationQueue(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	moderator, _ := services.CreateUser(models.User{Email: "mod@q.com", FirstName: "M", LastName: "M"}, "pw")
+	r1, _ := services.CreateUser(models.User{Email: "r1@q.com", FirstName: "1", LastName: "1"}, "pw")
+	r2, _ := services.CreateUser(models.User{Email: "r2@q.com", FirstName: "2", LastName: "2"}, "pw")
+	spammer, _ := services.CreateUser(models.User{Email: "spam@q.com", FirstName: "S", LastName: "S"}, "pw")
+	bully, _ := services.CreateUser(models.User{Email: "bully@q.com", FirstName: "B", LastName: "B"}, "pw")
+
+	// Two spam reports against one user, a single harassment report against another
+	services.CreateReport(r1.ID, dto.ReportCreateDto{TargetID: spammer.ID, TargetType: "USER", Reason: "SPAM"})
+	services.CreateReport(r2.ID, dto.ReportCreateDto{TargetID: spammer.ID, TargetType: "USER", Reason: "SPAM"})
+	services.CreateReport(r1.ID, dto.ReportCreateDto{TargetID: bully.ID, TargetType: "USER", Reason: "HARASSMENT"})
+
+	// 1. Grouped by target, the more severe reason comes first
+	queue, err := services.GetModerationQueue(services.ModerationQueueFilters{})
+	assert.NoError(t, err)
+	assert.Len(t, queue, 2)
+	assert.Equal(t, bully.ID, queue[0].TargetID)
+	assert.Equal(t, spammer.ID, queue[1].TargetID)
+	assert.Equal(t, int64(2), queue[1].ReportCount)
+
+	// 2. Resolving closes every pending report on the target and notifies the reporters
+	err = services.ResolveReports(moderator.ID, models.ReportTargetUser, spammer.ID, models.ReportStatusResolved, "Warned the user")
+	assert.NoError(t, err)
+
+	reports, _ := services.GetReportsForTarget(models.ReportTargetUser, spammer.ID)
+	for _, r := range reports {
+		assert.Equal(t, models.ReportStatusResolved, r.Status)
+		assert.Equal(t, "Warned the user", r.ResolutionNote)
+		assert.Equal(t, moderator.ID, *r.ResolvedByID)
+	}
+
+	notifs, _ := services.GetMyNotifications(r2.ID, services.NotificationFilters{})
+	assert.Len(t, notifs, 1)
+	assert.Equal(t, models.NotifTypeReportResolved, notifs[0].Type)
+
+	err = services.ResolveReports(moderator.ID, models.ReportTargetUser, spammer.ID, models.ReportStatusDismissed, "")
+	assert.ErrorIs(t, err, appError.ErrNoPendingReports)
+
+	// 3. Dismiss the other one
+	err = services.ResolveReports(moderator.ID, models.ReportTargetUser, bully.ID, models.ReportStatusDismissed, "")
+	assert.NoError(t, err)
+
+	queue, _ = services.GetModerationQueue(services.ModerationQueueFilters{})
+	assert.Empty(t, queue)
+
+	// 4. The same reporter can report again once the earlier report is closed
+	assert.NoError(t, services.CreateReport(r1.ID, dto.ReportCreateDto{TargetID: bully.ID, TargetType: "USER", Reason: "HARASSMENT"}))
+}
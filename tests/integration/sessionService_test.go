@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionService_RefreshAndRevoke(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+	config.AppConfig.AccessTokenExpirationMinutes = 15
+	config.AppConfig.RefreshTokenExpirationDays = 30
+
+	email := "session@test.com"
+	password := "password123"
+	services.CreateUser(models.User{Email: email, FirstName: "Session", LastName: "User"}, password)
+
+	// 1. Login on two devices
+	user, phone, err := services.Login(email, password, services.SessionClient{DeviceName: "Phone"})
+	assert.NoError(t, err)
+	_, tablet, err := services.Login(email, password, services.SessionClient{DeviceName: "Tablet"})
+	assert.NoError(t, err)
+
+	sessions, err := services.GetActiveSessions(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	phoneClaims, _ := services.ValidateJWTToken(phone.AccessToken)
+	assert.NoError(t, services.ValidateSession(user.ID, phoneClaims.SessionID))
+
+	// 2. Refresh rotates the refresh token and keeps the session
+	_, refreshed, err := services.RefreshSession(phone.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, phone.RefreshToken, refreshed.RefreshToken)
+
+	refreshedClaims, _ := services.ValidateJWTToken(refreshed.AccessToken)
+	assert.Equal(t, phoneClaims.SessionID, refreshedClaims.SessionID)
+
+	// 3. Reusing the old refresh token revokes the session
+	_, _, err = services.RefreshSession(phone.RefreshToken)
+	assert.ErrorIs(t, err, appError.ErrSessionRevoked)
+
+	_, _, err = services.RefreshSession(refreshed.RefreshToken)
+	assert.ErrorIs(t, err, appError.ErrSessionRevoked)
+	assert.ErrorIs(t, services.ValidateSession(user.ID, phoneClaims.SessionID), appError.ErrSessionRevoked)
+
+	// 4. Logout revokes a single session
+	tabletClaims, _ := services.ValidateJWTToken(tablet.AccessToken)
+	assert.NoError(t, services.ValidateSession(user.ID, tabletClaims.SessionID))
+	assert.NoError(t, services.RevokeSession(user.ID, tabletClaims.SessionID))
+	assert.ErrorIs(t, services.ValidateSession(user.ID, tabletClaims.SessionID), appError.ErrSessionRevoked)
+
+	// 5. Log out all devices
+	_, laptop, _ := services.Login(email, password, services.SessionClient{DeviceName: "Laptop"})
+	laptopClaims, _ := services.ValidateJWTToken(laptop.AccessToken)
+
+	// The chat checks its connected sessions in one query
+	ended, err := services.GetInvalidSessionIDs([]uint{phoneClaims.SessionID, tabletClaims.SessionID, laptopClaims.SessionID})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{phoneClaims.SessionID, tabletClaims.SessionID}, ended)
+
+	assert.NoError(t, services.RevokeAllSessions(user.ID))
+	assert.ErrorIs(t, services.ValidateSession(user.ID, laptopClaims.SessionID), appError.ErrSessionRevoked)
+
+	_, _, err = services.RefreshSession(laptop.RefreshToken)
+	assert.ErrorIs(t, err, appError.ErrSessionRevoked)
+
+	sessions, _ = services.GetActiveSessions(user.ID)
+	assert.Empty(t, sessions)
+
+	// 6. Tokens without a session are rejected
+	assert.ErrorIs(t, services.ValidateSession(user.ID, 0), appError.ErrSessionRevoked)
+}
@@ -16,6 +16,10 @@ import (
 
 var setupOnce sync.Once
 
+// Connection string for the Docker "postgres-test" container
+// Port 5433 matches the docker-compose.yml test service
+const testDSN = "host=localhost user=test_user password=test_password dbname=challenger_test port=5433 sslmode=disable"
+
 // TestMain acts as the entry point for all tests in this package.
 func TestMain(m *testing.M) {
 	setupTestDB()
@@ -25,13 +29,9 @@ func TestMain(m *testing.M) {
 
 func setupTestDB() {
 	setupOnce.Do(func() {
-		// Connection string for the Docker "postgres-test" container
-		// Port 5433 matches the docker-compose.yml test service
-		dsn := "host=localhost user=test_user password=test_password dbname=challenger_test port=5433 sslmode=disable"
-
 		var err error
 		// We explicitly assign to the global variable in the config package
-		config.DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+		config.DB, err = gorm.Open(postgres.Open(testDSN), &gorm.Config{
 			Logger: logger.Default.LogMode(logger.Silent),
 		})
 		if err != nil {
@@ -97,12 +97,29 @@ func clearDB() {
 
 	// Truncate tables in specific order to handle foreign keys
 	tables := []string{
+		"admin_audit_logs",
+		"backplane_payloads",
+		"presence_connections",
+		"user_presences",
+		"eula_acceptances",
+		"eula_versions",
 		"reports",
+		"message_edits",
+		"message_reactions",
+		"message_mentions",
+		"message_attachments",
 		"messages",
 		"notifications",
 		"invitations",
+		"team_invite_links",
+		"data_exports",
+		"user_sessions",
+		"auth_throttles",
+		"mfa_recovery_codes",
+		"mfa_challenges",
 		"team_sports",
 		"user_favorite_sports",
+		"user_sport_profiles",
 		"team_members",
 		"user_friends",
 		"challenge_teams",
@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"errors"
+	"server/common/appError"
+	"server/common/config"
+	"server/common/dto"
+	"server/common/models"
+	"server/common/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspensionService_BanUser(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.JWTSecret = "test_secret_key_12345"
+
+	admin, _ := services.CreateUser(models.User{Email: "admin@b.com", FirstName: "A", LastName: "A"}, "pw")
+	moderator, _ := services.CreateUser(models.User{Email: "mod@b.com", FirstName: "M", LastName: "M"}, "pw")
+	user, _ := services.CreateUser(models.User{Email: "user@b.com", FirstName: "U", LastName: "U"}, "password123")
+	config.DB.Model(&models.User{}).Where("id = ?", admin.ID).Update("role", models.UserRoleAdmin)
+	config.DB.Model(&models.User{}).Where("id = ?", moderator.ID).Update("role", models.UserRoleModerator)
+
+	// 1. Moderators can't act on admins, nor on themselves
+	_, err := services.BanUser(moderator.ID, admin.ID, dto.BanUserDto{Reason: "test"})
+	assert.ErrorIs(t, err, appError.ErrAdminRequired)
+
+	_, err = services.BanUser(moderator.ID, moderator.ID, dto.BanUserDto{Reason: "test"})
+	assert.ErrorIs(t, err, appError.ErrSameUser)
+
+	// 2. A ban blocks logins and tells the user why
+	banned, err := services.BanUser(moderator.ID, user.ID, dto.BanUserDto{Reason: "Harassment"})
+	assert.NoError(t, err)
+	assert.True(t, banned.IsBanned())
+
+	_, _, err = services.Login(user.Email, "password123", services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrAccountBanned)
+
+	var restrictedErr *appError.AccountRestrictedError
+	assert.True(t, errors.As(err, &restrictedErr))
+	assert.Equal(t, "Harassment", restrictedErr.Reason)
+	assert.Nil(t, restrictedErr.Until)
+
+	// 3. The wrong password doesn't reveal the ban
+	_, _, err = services.Login(user.Email, "wrong", services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
+
+	// 4. Lifting the ban allows logins again
+	_, err = services.UnbanUser(moderator.ID, user.ID)
+	assert.NoError(t, err)
+
+	_, _, err = services.Login(user.Email, "password123", services.SessionClient{})
+	assert.NoError(t, err)
+
+	logs, err := services.GetAdminAuditLogs(services.AdminAuditLogFilters{AdminID: &moderator.ID})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, models.AdminActionUserUnbanned, logs[0].Action)
+	assert.Equal(t, models.AdminActionUserBanned, logs[1].Action)
+}
+
+func TestSuspensionService_HidesChallengesOfSuspendedCreators(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	admin, _ := services.CreateUser(models.User{Email: "admin@c.com", FirstName: "A", LastName: "A"}, "pw")
+	creator, _ := services.CreateUser(models.User{Email: "creator@c.com", FirstName: "C", LastName: "C"}, "pw")
+	player, _ := services.CreateUser(models.User{Email: "player@c.com", FirstName: "P", LastName: "P"}, "pw")
+	viewer, _ := services.CreateUser(models.User{Email: "viewer@c.com", FirstName: "V", LastName: "V"}, "pw")
+	config.DB.Model(&models.User{}).Where("id = ?", admin.ID).Update("role", models.UserRoleAdmin)
+
+	chal := models.Challenge{
+		Name:      "Public Match",
+		CreatorID: creator.ID,
+		IsPublic:  true,
+		Date:      time.Now().Add(24 * time.Hour),
+		StartTime: time.Now().Add(24 * time.Hour),
+		Location:  models.Location{Address: "L", Coordinates: models.Point{Lat: 0, Lon: 0}, PostalCode: "1", City: "C", Country: "C"},
+	}
+	created, err := services.CreateChallenge(chal, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, services.JoinChallenge(created.ID, player.ID))
+
+	list, _ := services.GetChallenges(viewer.ID)
+	assert.Len(t, list, 1)
+
+	// 1. Hidden from other users while the suspension lasts, participants still see it
+	_, err = services.SuspendUser(admin.ID, creator.ID, dto.SuspendUserDto{Until: time.Now().Add(time.Hour), Reason: "Spam"})
+	assert.NoError(t, err)
+
+	list, _ = services.GetChallenges(viewer.ID)
+	assert.Len(t, list, 0)
+
+	list, _ = services.GetChallenges(player.ID)
+	assert.Len(t, list, 1)
+
+	// 2. Visible again once the suspension has passed
+	config.DB.Model(&models.User{}).Where("id = ?", creator.ID).Update("suspended_until", time.Now().Add(-time.Minute))
+
+	list, _ = services.GetChallenges(viewer.ID)
+	assert.Len(t, list, 1)
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"server/common/appError"
+	"server/common/config"
+	"server/common/models"
+	"server/common/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamInviteLinkService_RedeemFlow(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	owner, _ := services.CreateUser(models.User{Email: "owner@link.com", FirstName: "O", LastName: "O"}, "pw")
+	joiner, _ := services.CreateUser(models.User{Email: "joiner@link.com", FirstName: "J", LastName: "J"}, "pw")
+	second, _ := services.CreateUser(models.User{Email: "second@link.com", FirstName: "S", LastName: "S"}, "pw")
+	team, _ := services.CreateTeam(models.Team{Name: "Link Team", CreatorID: owner.ID}, nil, nil)
+
+	// 1. Create link with a single use and admin role
+	maxUses := 1
+	link, err := services.CreateTeamInviteLink(models.TeamInviteLink{
+		TeamID:    team.ID,
+		CreatorID: owner.ID,
+		MaxUses:   &maxUses,
+		Role:      models.RoleAdmin,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, link.Token)
+
+	// 2. Redeem
+	joined, err := services.RedeemTeamInviteLink(link.Token, joiner.ID)
+	assert.NoError(t, err)
+	assert.Len(t, joined.Users, 2)
+
+	var member models.TeamMember
+	config.DB.Where("team_id = ? AND user_id = ?", team.ID, joiner.ID).First(&member)
+	assert.Equal(t, models.RoleAdmin, member.Role)
+
+	// 3. Owner is notified
+	var count int64
+	config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND type = ?", owner.ID, models.NotifTypeTeamJoinedLink).
+		Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 4. Link is used up
+	_, err = services.RedeemTeamInviteLink(link.Token, second.ID)
+	assert.ErrorIs(t, err, appError.ErrInviteLinkExhausted)
+}
+
+func TestTeamInviteLinkService_OwnerManagement(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	owner, _ := services.CreateUser(models.User{Email: "own@mgmt.com", FirstName: "O", LastName: "O"}, "pw")
+	other, _ := services.CreateUser(models.User{Email: "other@mgmt.com", FirstName: "X", LastName: "X"}, "pw")
+	team, _ := services.CreateTeam(models.Team{Name: "Mgmt Team", CreatorID: owner.ID}, nil, nil)
+
+	// 1. Non-owners can't create, list or revoke links
+	_, err := services.CreateTeamInviteLink(models.TeamInviteLink{TeamID: team.ID, CreatorID: other.ID})
+	assert.ErrorIs(t, err, appError.ErrUnauthorized)
+
+	link, err := services.CreateTeamInviteLink(models.TeamInviteLink{TeamID: team.ID, CreatorID: owner.ID})
+	assert.NoError(t, err)
+
+	_, err = services.GetTeamInviteLinks(team.ID, other.ID)
+	assert.ErrorIs(t, err, appError.ErrUnauthorized)
+
+	err = services.RevokeTeamInviteLink(team.ID, link.ID, other.ID)
+	assert.ErrorIs(t, err, appError.ErrUnauthorized)
+
+	// 2. Owner lists and revokes
+	links, err := services.GetTeamInviteLinks(team.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+
+	err = services.RevokeTeamInviteLink(team.ID, link.ID, owner.ID)
+	assert.NoError(t, err)
+
+	_, err = services.RedeemTeamInviteLink(link.Token, other.ID)
+	assert.ErrorIs(t, err, appError.ErrInviteLinkRevoked)
+
+	// 3. Owner role can't be handed out
+	_, err = services.CreateTeamInviteLink(models.TeamInviteLink{TeamID: team.ID, CreatorID: owner.ID, Role: models.RoleOwner})
+	assert.ErrorIs(t, err, appError.ErrInvalidTeamRole)
+}
+
+func TestTeamInviteLinkService_Expired(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	owner, _ := services.CreateUser(models.User{Email: "own@exp.com", FirstName: "O", LastName: "O"}, "pw")
+	joiner, _ := services.CreateUser(models.User{Email: "join@exp.com", FirstName: "J", LastName: "J"}, "pw")
+	team, _ := services.CreateTeam(models.Team{Name: "Exp Team", CreatorID: owner.ID}, nil, nil)
+
+	link, err := services.CreateTeamInviteLink(models.TeamInviteLink{TeamID: team.ID, CreatorID: owner.ID})
+	assert.NoError(t, err)
+
+	// Expire the link
+	config.DB.Model(&link).Update("expires_at", time.Now().Add(-time.Minute))
+
+	_, err = services.RedeemTeamInviteLink(link.Token, joiner.ID)
+	assert.ErrorIs(t, err, appError.ErrInviteLinkExpired)
+
+	// Already a member
+	link2, _ := services.CreateTeamInviteLink(models.TeamInviteLink{TeamID: team.ID, CreatorID: owner.ID})
+	_, err = services.RedeemTeamInviteLink(link2.Token, owner.ID)
+	assert.ErrorIs(t, err, appError.ErrUserAlreadyInTeam)
+}
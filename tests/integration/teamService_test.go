@@ -2,9 +2,11 @@ package integration
 
 import (
 	"server/common/appError"
+	"server/common/config"
 	"server/common/models"
 	"server/common/services"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -73,6 +75,48 @@ func TestTeamService_List(t *testing.T) {
 	assert.Len(t, userTeams, 2)
 }
 
+func TestTeamService_ListByUserPrivacy(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	owner, _ := services.CreateUser(models.User{Email: "owner@team.com", FirstName: "O", LastName: "O", Settings: &models.UserSettings{}}, "pw")
+	friend, _ := services.CreateUser(models.User{Email: "friend@team.com", FirstName: "F", LastName: "F"}, "pw")
+	stranger, _ := services.CreateUser(models.User{Email: "stranger@team.com", FirstName: "S", LastName: "S"}, "pw")
+	services.CreateTeam(models.Team{Name: "T1", CreatorID: owner.ID}, nil, nil)
+
+	config.DB.Model(owner).Association("Friends").Append(friend)
+	config.DB.Model(friend).Association("Friends").Append(owner)
+
+	// 1. Public by default
+	teams, err := services.GetTeamsByUserId(owner.ID, stranger.ID)
+	assert.NoError(t, err)
+	assert.Len(t, teams, 1)
+
+	// 2. Friends only hides the teams from strangers
+	config.DB.Model(&models.UserSettings{}).Where("user_id = ?", owner.ID).
+		Update("privacy_teams_and_challenges", models.PrivacyFriends)
+
+	teams, err = services.GetTeamsByUserId(owner.ID, stranger.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, teams)
+
+	teams, err = services.GetTeamsByUserId(owner.ID, friend.ID)
+	assert.NoError(t, err)
+	assert.Len(t, teams, 1)
+
+	// 3. Nobody hides them from friends, but not from the owner
+	config.DB.Model(&models.UserSettings{}).Where("user_id = ?", owner.ID).
+		Update("privacy_teams_and_challenges", models.PrivacyNobody)
+
+	teams, err = services.GetTeamsByUserId(owner.ID, friend.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, teams)
+
+	teams, err = services.GetTeamsByUserId(owner.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Len(t, teams, 1)
+}
+
 func TestTeamService_Membership(t *testing.T) {
 	teardown := setupTest(t)
 	defer teardown()
@@ -114,3 +158,38 @@ func TestTeamService_Membership(t *testing.T) {
 	tAfterLeave, _ := services.GetTeamByID(team.ID, creator.ID)
 	assert.Len(t, tAfterLeave.Users, 0)
 }
+
+func TestTeamService_Restore(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.SoftDeleteRestoreDays = 14
+
+	owner, _ := services.CreateUser(models.User{Email: "owner@restore.com", FirstName: "O", LastName: "O"}, "pw")
+	other, _ := services.CreateUser(models.User{Email: "other@restore.com", FirstName: "X", LastName: "X"}, "pw")
+	team, _ := services.CreateTeam(models.Team{Name: "Restore Team", CreatorID: owner.ID}, nil, nil)
+
+	// 1. Not deleted
+	_, err := services.RestoreTeam(team.ID, owner.ID)
+	assert.ErrorIs(t, err, appError.ErrNotDeleted)
+
+	err = services.SoftDeleteTeam(team.ID)
+	assert.NoError(t, err)
+
+	// 2. Only the owner can restore
+	_, err = services.RestoreTeam(team.ID, other.ID)
+	assert.ErrorIs(t, err, appError.ErrUnauthorized)
+
+	// 3. Owner restores
+	restored, err := services.RestoreTeam(team.ID, owner.ID)
+	assert.NoError(t, err)
+	assert.Len(t, restored.Users, 1)
+
+	// 4. Outside the restore window
+	services.SoftDeleteTeam(team.ID)
+	config.DB.Unscoped().Model(&models.Team{}).Where("id = ?", team.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -15))
+
+	_, err = services.RestoreTeam(team.ID, owner.ID)
+	assert.ErrorIs(t, err, appError.ErrRestoreWindowExpired)
+}
@@ -7,6 +7,7 @@ import (
 	"server/common/models"
 	"server/common/services"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -355,3 +356,147 @@ func TestUserService_FriendshipAndStats(t *testing.T) {
 	err = services.RemoveFriend(u1.ID, u1.ID)
 	assert.ErrorIs(t, err, appError.ErrInvalidFriendship)
 }
+
+func TestUserService_SportProfiles(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	user, _ := services.CreateUser(models.User{
+		Email:          "profile@test.com",
+		FirstName:      "P",
+		LastName:       "P",
+		FavoriteSports: []models.Sport{{Name: "Tennis"}},
+	}, "pw")
+
+	// 1. Add a profile for a sport that isn't a favorite yet
+	maxDistance := 15
+	err := services.UpdateUser(user.ID, dto.UserUpdateDto{
+		SportProfiles: []dto.UserSportProfileDto{
+			{
+				Sport:               "Football",
+				Level:               "advanced",
+				Position:            "Keeper",
+				PreferredDays:       []string{"saturday"},
+				PreferredTimes:      []string{"morning"},
+				MaxTravelDistanceKm: &maxDistance,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	fetched, _ := services.GetUserByID(user.ID)
+	assert.Len(t, fetched.FavoriteSports, 2)
+	assert.Len(t, fetched.SportProfiles, 1)
+
+	public := dto.ToPublicUserDtoResponse(*fetched)
+	assert.Len(t, public.SportProfiles, 1)
+	assert.Equal(t, "Football", public.SportProfiles[0].Sport.Name)
+	assert.Equal(t, models.SportLevelAdvanced, *public.SportProfiles[0].Level)
+	assert.Equal(t, []string{"saturday"}, public.SportProfiles[0].PreferredDays)
+
+	// 2. Removing the sport from favorites removes the profile
+	err = services.UpdateUser(user.ID, dto.UserUpdateDto{FavoriteSports: []string{"Tennis"}})
+	assert.NoError(t, err)
+
+	fetched, _ = services.GetUserByID(user.ID)
+	assert.Len(t, fetched.SportProfiles, 0)
+
+	// 3. Invalid sport
+	err = services.UpdateUser(user.ID, dto.UserUpdateDto{
+		SportProfiles: []dto.UserSportProfileDto{{Sport: "NotASport"}},
+	})
+	assert.ErrorIs(t, err, appError.ErrInvalidSport)
+}
+
+func TestUserService_PrivacySettings(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	owner, _ := services.CreateUser(models.User{Email: "priv@test.com", FirstName: "Private", LastName: "P", City: "Aarhus", BirthDate: time.Date(1995, 1, 1, 0, 0, 0, 0, time.UTC), Settings: &models.UserSettings{}}, "pw")
+	friend, _ := services.CreateUser(models.User{Email: "friend@test.com", FirstName: "Friend", LastName: "F"}, "pw")
+
+	// Defaults are public
+	settings, err := services.GetUserSettings(owner.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PrivacyEveryone, settings.PrivacyCity)
+
+	// 1. Hide age from everyone and city from non-friends
+	nobody := models.PrivacyNobody
+	friends := models.PrivacyFriends
+	err = services.UpdateUserSettings(owner.ID, dto.UserSettingsUpdateDto{
+		PrivacyAge:  &nobody,
+		PrivacyCity: &friends,
+	})
+	assert.NoError(t, err)
+
+	user, err := services.GetUserByID(owner.ID)
+	assert.NoError(t, err)
+
+	// 2. Strangers see neither
+	public := dto.ToPublicUserDtoResponse(*user)
+	assert.Nil(t, public.BirthDate)
+	assert.Empty(t, public.City)
+
+	// 3. Friends see the city but not the age
+	asFriend := dto.ToPublicUserDtoResponseForViewer(*user, dto.ProfileViewer{IsFriend: true})
+	assert.Nil(t, asFriend.BirthDate)
+	assert.Equal(t, "Aarhus", asFriend.City)
+
+	// 4. The owner always sees everything
+	self := dto.ToUserResponseDto(*user)
+	assert.NotNil(t, self.BirthDate)
+	assert.Equal(t, "Aarhus", self.City)
+	assert.Equal(t, models.PrivacyNobody, self.Settings.PrivacyAge)
+
+	// 5. GetUsers loads settings so search results are filtered too
+	users, _, err := services.GetUsers(friend.ID, "Private", 20, nil)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Empty(t, dto.ToUserResponseDtoForViewer(users[0], dto.ProfileViewer{}).City)
+
+	// 6. Nested users are loaded with their settings, so public fields are still shown
+	host, _ := services.CreateUser(models.User{Email: "host@test.com", FirstName: "Host", LastName: "H", City: "Odense", Settings: &models.UserSettings{}}, "pw")
+	created, err := services.CreateChallenge(models.Challenge{CreatorID: host.ID, Date: time.Now(), StartTime: time.Now().Add(time.Hour)}, nil)
+	assert.NoError(t, err)
+
+	challenge, err := services.GetChallengeByID(created.ID, friend.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Odense", dto.ToChallengeResponseDto(challenge).Creator.City)
+}
+
+func TestUserService_DeletionGracePeriod(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	config.AppConfig.AccountDeletionGraceDays = 30
+
+	email := "grace@test.com"
+	password := "password123"
+	user, _ := services.CreateUser(models.User{Email: email, FirstName: "Grace", LastName: "G"}, password)
+
+	// 1. Delete deactivates the account
+	err := services.DeleteUser(*user, email)
+	assert.NoError(t, err)
+
+	_, err = services.GetUserByID(user.ID)
+	assert.Error(t, err)
+
+	// 2. The email can't be reused during the grace period
+	_, err = services.CreateUser(models.User{Email: email, FirstName: "Other", LastName: "O"}, password)
+	assert.ErrorIs(t, err, appError.ErrUserExists)
+
+	// 3. Logging in within the grace period restores the account
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	assert.NoError(t, err)
+
+	_, err = services.GetUserByID(user.ID)
+	assert.NoError(t, err)
+
+	// 4. Logging in after the grace period fails
+	err = services.DeleteUser(*user, email)
+	assert.NoError(t, err)
+	config.DB.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Update("deleted_at", time.Now().AddDate(0, 0, -31))
+
+	_, _, err = services.Login(email, password, services.SessionClient{})
+	assert.ErrorIs(t, err, appError.ErrInvalidCredentials)
+}
@@ -0,0 +1,44 @@
+package dto_test
+
+import (
+	"server/common/dto"
+	"server/common/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicUserWithoutSettings(t *testing.T) {
+	user := models.User{
+		ID:        1,
+		FirstName: "Private",
+		City:      "Aarhus",
+		BirthDate: time.Date(1995, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Hide protected fields when settings aren't loaded", func(t *testing.T) {
+		public := dto.ToPublicUserDtoResponse(user)
+
+		assert.Equal(t, "Private", public.FirstName)
+		assert.Nil(t, public.BirthDate)
+		assert.Empty(t, public.City)
+	})
+
+	t.Run("Hide protected fields from friends too", func(t *testing.T) {
+		public := dto.ToPublicUserDtoResponseForViewer(user, dto.ProfileViewer{IsFriend: true})
+
+		assert.Nil(t, public.BirthDate)
+		assert.Empty(t, public.City)
+	})
+
+	t.Run("Show fields the loaded settings allow", func(t *testing.T) {
+		withSettings := user
+		withSettings.Settings = &models.UserSettings{PrivacyAge: models.PrivacyEveryone, PrivacyCity: models.PrivacyEveryone}
+
+		public := dto.ToPublicUserDtoResponse(withSettings)
+
+		assert.NotNil(t, public.BirthDate)
+		assert.Equal(t, "Aarhus", public.City)
+	})
+}
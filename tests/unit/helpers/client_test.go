@@ -0,0 +1,61 @@
+package helpers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"server/api/controllers/helpers"
+	"server/common/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetClientIP(t *testing.T) {
+	config.AppConfig.TrustedProxies = []string{"10.0.0.0/24", "192.0.2.50"}
+	defer func() { config.AppConfig.TrustedProxies = nil }()
+
+	t.Run("Use remote address", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+
+		assert.Equal(t, "192.0.2.1", helpers.GetClientIP(req))
+	})
+
+	t.Run("Ignore forwarded address from untrusted client", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+		assert.Equal(t, "192.0.2.1", helpers.GetClientIP(req))
+	})
+
+	t.Run("Use hop added by trusted proxy", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.50:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+		assert.Equal(t, "203.0.113.7", helpers.GetClientIP(req))
+	})
+
+	t.Run("Ignore spoofed hops before the rightmost untrusted one", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "1.2.3.4, 203.0.113.7, 10.0.0.2")
+
+		assert.Equal(t, "203.0.113.7", helpers.GetClientIP(req))
+	})
+
+	t.Run("Trusted proxy without forwarded address", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		assert.Equal(t, "10.0.0.1", helpers.GetClientIP(req))
+	})
+
+	t.Run("Remote address without port", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1"
+
+		assert.Equal(t, "192.0.2.1", helpers.GetClientIP(req))
+	})
+}
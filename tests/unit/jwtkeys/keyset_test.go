@@ -0,0 +1,152 @@
+package jwtkeys_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"server/common/jwtkeys"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func claims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func parse(ks *jwtkeys.KeySet, token string) error {
+	_, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()))
+	return err
+}
+
+func writeEd25519Keys(t *testing.T) (privatePath, publicPath string) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath = filepath.Join(dir, "private.pem")
+	publicPath = filepath.Join(dir, "public.pem")
+
+	assert.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600))
+	assert.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600))
+
+	return privatePath, publicPath
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := jwtkeys.Parse("", "", "")
+	assert.ErrorIs(t, err, jwtkeys.ErrNoKeys)
+
+	_, err = jwtkeys.Parse("a:HS256:secret", "b", "")
+	assert.ErrorIs(t, err, jwtkeys.ErrUnknownKey)
+
+	_, err = jwtkeys.Parse("a:HS256:one,a:HS256:two", "a", "")
+	assert.Error(t, err)
+
+	_, err = jwtkeys.Parse("a:none:secret", "a", "")
+	assert.Error(t, err)
+
+	_, err = jwtkeys.Parse("a:HS256", "a", "")
+	assert.Error(t, err)
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	// Tokens from before key IDs carry no kid and are signed with the plain secret
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("old-secret"))
+	assert.NoError(t, err)
+
+	oldKeys, err := jwtkeys.Parse("", "", "old-secret")
+	assert.NoError(t, err)
+	oldToken, err := oldKeys.Sign(claims())
+	assert.NoError(t, err)
+
+	// Roll out a new active key, the old one keeps verifying
+	keys, err := jwtkeys.Parse("2026-10:HS256:new-secret", "2026-10", "old-secret")
+	assert.NoError(t, err)
+
+	newToken, err := keys.Sign(claims())
+	assert.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &jwt.RegisteredClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-10", parsed.Header["kid"])
+
+	assert.NoError(t, parse(keys, legacyToken))
+	assert.NoError(t, parse(keys, oldToken))
+	assert.NoError(t, parse(keys, newToken))
+
+	// Once the old key is retired its tokens are rejected
+	retired, err := jwtkeys.Parse("2026-10:HS256:new-secret", "2026-10", "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, parse(retired, newToken))
+	assert.ErrorIs(t, parse(retired, oldToken), jwtkeys.ErrUnknownKey)
+	assert.ErrorIs(t, parse(retired, legacyToken), jwtkeys.ErrUnknownKey)
+}
+
+func TestKeySet_EdDSAPublicKeyOnly(t *testing.T) {
+	privatePath, publicPath := writeEd25519Keys(t)
+
+	signer, err := jwtkeys.Parse("ed:EdDSA:"+privatePath, "ed", "")
+	assert.NoError(t, err)
+
+	token, err := signer.Sign(claims())
+	assert.NoError(t, err)
+	assert.NoError(t, parse(signer, token))
+
+	// A verifier with only the public key accepts the token but can't issue any
+	verifier, err := jwtkeys.Parse("ed:EdDSA:"+publicPath, "", "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, parse(verifier, token))
+
+	_, err = verifier.Sign(claims())
+	assert.ErrorIs(t, err, jwtkeys.ErrNoSigningKey)
+	assert.True(t, signer.CanSign())
+	assert.False(t, verifier.CanSign())
+}
+
+func TestKeySet_CanSignNeedsActiveKey(t *testing.T) {
+	// Several keys without an active one can only verify
+	keys, err := jwtkeys.Parse("a:HS256:one,b:HS256:two", "", "")
+	assert.NoError(t, err)
+	assert.False(t, keys.CanSign())
+
+	keys, err = jwtkeys.Parse("a:HS256:one,b:HS256:two", "b", "")
+	assert.NoError(t, err)
+	assert.True(t, keys.CanSign())
+
+	single, err := jwtkeys.Parse("", "", "secret")
+	assert.NoError(t, err)
+	assert.True(t, single.CanSign())
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	_, publicPath := writeEd25519Keys(t)
+	publicPEM, err := os.ReadFile(publicPath)
+	assert.NoError(t, err)
+
+	verifier, err := jwtkeys.Parse("ed:EdDSA:"+publicPath+",hs:HS256:secret", "", "")
+	assert.NoError(t, err)
+
+	// An HMAC token claiming the EdDSA kid, signed with the public key as secret
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	token.Header["kid"] = "ed"
+	forged, err := token.SignedString(publicPEM)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, parse(verifier, forged), jwtkeys.ErrMethodMismatch)
+}
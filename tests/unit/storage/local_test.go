@@ -0,0 +1,44 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"server/common/storage"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Put(ctx, "conversations/1/photo.jpg", strings.NewReader("first")))
+	assert.NoError(t, s.Put(ctx, "conversations/1/photo.jpg", strings.NewReader("second")))
+
+	r, err := s.Get(ctx, "conversations/1/photo.jpg")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "second", string(data))
+
+	assert.NoError(t, s.Delete(ctx, "conversations/1/photo.jpg"))
+	assert.NoError(t, s.Delete(ctx, "conversations/1/photo.jpg"))
+
+	_, err = s.Get(ctx, "conversations/1/photo.jpg")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestLocal_RejectsKeysOutsideDir(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../escape"} {
+		assert.Error(t, s.Put(ctx, key, strings.NewReader("x")), key)
+		_, err := s.Get(ctx, key)
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,76 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"server/common/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SHA1 test vectors from RFC 6238 appendix B, truncated to 6 digits
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := totp.Code(secret, totp.Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	step := totp.Step(now)
+
+	t.Run("Accept current code", func(t *testing.T) {
+		code, _ := totp.Code(secret, step)
+		matched, ok := totp.Validate(secret, code, now, 0)
+		assert.True(t, ok)
+		assert.Equal(t, step, matched)
+	})
+
+	t.Run("Accept previous code for clock drift", func(t *testing.T) {
+		code, _ := totp.Code(secret, step-1)
+		_, ok := totp.Validate(secret, code, now, 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("Reject old code", func(t *testing.T) {
+		code, _ := totp.Code(secret, step-2)
+		_, ok := totp.Validate(secret, code, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Reject replayed code", func(t *testing.T) {
+		code, _ := totp.Code(secret, step)
+		_, ok := totp.Validate(secret, code, now, step)
+		assert.False(t, ok)
+	})
+
+	t.Run("Reject malformed code", func(t *testing.T) {
+		_, ok := totp.Validate(secret, "12345", now, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("Challenger", "jane@example.com", "JBSWY3DPEHPK3PXP")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Challenger:jane@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Challenger")
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"server/common/config"
+	"server/common/dto"
 	"server/common/validator"
 
 	"github.com/stretchr/testify/assert"
@@ -197,3 +198,38 @@ func TestCombinedValidations(t *testing.T) {
 	// Sport should remain unchanged (no sanitize tag)
 	assert.Equal(t, "Tennis", testStruct.Sport)
 }
+
+func TestSingleEmojiValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		valid bool
+	}{
+		{"Simple emoji", "👍", true},
+		{"Symbol with variation selector", "❤️", true},
+		{"Skin tone", "👍🏽", true},
+		{"ZWJ sequence", "👩‍💻", true},
+		{"Flag", "🇩🇰", true},
+		{"Subdivision flag", "🏴󠁧󠁢󠁥󠁮󠁧󠁿", true},
+		{"Keycap", "1️⃣", true},
+		{"Plain text", "lol", false},
+		{"Digit", "1", false},
+		{"Emoji with text", "👍 nice", false},
+		{"Two emoji", "👍👍", false},
+		{"Dangling joiner", "👩‍", false},
+		{"Single regional indicator", "🇩", false},
+		{"HTML", "<b>", false},
+		{"Empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.V.Struct(&dto.ToggleReactionDto{Emoji: tt.emoji})
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}